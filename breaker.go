@@ -0,0 +1,137 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/robotomize/gokuu/provider"
+)
+
+// ErrCircuitOpen the provider was skipped because its circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState byte
+
+const (
+	// CircuitStateClosed the provider is requested
+	CircuitStateClosed CircuitState = iota
+	// CircuitStateOpen the provider is skipped until the cool-down period ends
+	CircuitStateOpen
+	// CircuitStateHalfOpen a single request probes the provider after the cool-down period
+	CircuitStateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitStateOpen:
+		return "open"
+	case CircuitStateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// WithCircuitBreaker skip the provider for the cool-down period after the number of consecutive failed fetches.
+// Then a single fetch probes the provider, its success closes the circuit and its failure opens it again.
+// A zero threshold disables the circuit breaker
+func WithCircuitBreaker(threshold int, coolDown time.Duration) Option {
+	return func(e *exchanger) {
+		e.opts.BreakerThreshold = threshold
+		e.opts.BreakerCoolDown = coolDown
+	}
+}
+
+// WithProviderCircuitBreaker override the circuit breaker of the exchanger for the provider,
+// a zero threshold disables it
+func WithProviderCircuitBreaker(threshold int, coolDown time.Duration) ProviderOption {
+	return func(p *fetchPolicy) {
+		p.breakerSet = true
+		p.breakerThreshold = threshold
+		p.breakerCoolDown = coolDown
+	}
+}
+
+// breaker counts the consecutive failures of a provider. It is shared by the copies of the provider.
+// The concurrent requests of the latest and historical rates fail together during an outage, the failure
+// is counted once for the requests that ran while it was counted
+type breaker struct {
+	mtx      sync.Mutex
+	state    CircuitState
+	failures int
+	// counted the number of the failures counted since the start, it identifies the last one
+	counted  uint64
+	openedAt time.Time
+}
+
+// allow reports whether the provider is requested and returns the ticket of the request for done.
+// In the half-open state only the first caller probes the provider
+func (b *breaker) allow(now time.Time, coolDown time.Duration) (bool, CircuitState, uint64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	switch b.state {
+	case CircuitStateOpen:
+		if now.Sub(b.openedAt) < coolDown {
+			return false, b.state, b.counted
+		}
+
+		b.state = CircuitStateHalfOpen
+
+		return true, b.state, b.counted
+	case CircuitStateHalfOpen:
+		return false, b.state, b.counted
+	default:
+		return true, b.state, b.counted
+	}
+}
+
+// done records the result of the allowed request with the ticket and returns the new state. The failure is not
+// counted if another failure was counted after the request was allowed
+func (b *breaker) done(err error, ticket uint64, now time.Time, threshold int) CircuitState {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	switch {
+	case err == nil:
+		b.state = CircuitStateClosed
+		b.failures = 0
+	case b.state == CircuitStateHalfOpen:
+		b.state = CircuitStateOpen
+		b.openedAt = now
+		b.counted++
+	case ticket != b.counted:
+		// the outage is already counted by a concurrent request
+	default:
+		b.counted++
+		b.failures++
+		if b.failures >= threshold {
+			b.state = CircuitStateOpen
+			b.openedAt = now
+		}
+	}
+
+	return b.state
+}
+
+// release returns the half-open circuit to the open state without a result, the next request probes the provider
+func (b *breaker) release() CircuitState {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.state == CircuitStateHalfOpen {
+		b.state = CircuitStateOpen
+	}
+
+	return b.state
+}
+
+// isProviderFailure reports whether the error of the fetch says the provider is unavailable.
+// The missing history and the cancellation by the caller do not count
+func isProviderFailure(ctx context.Context, err error) bool {
+	return ctx.Err() == nil &&
+		!errors.Is(err, ErrHistoricalNotSupported) &&
+		!errors.Is(err, provider.ErrHistoryNotFound)
+}
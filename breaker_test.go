@@ -0,0 +1,370 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+func TestBreaker(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+	start := time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)
+
+	type step struct {
+		elapsed time.Duration
+		err     error
+		// release the half-open circuit without a result
+		release       bool
+		expectedAllow bool
+		expected      CircuitState
+	}
+
+	testCases := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "test_open_after_threshold",
+			steps: []step{
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateClosed},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateClosed},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateOpen},
+				{elapsed: 30 * time.Second, expected: CircuitStateOpen},
+			},
+		},
+		{
+			name: "test_success_resets_failures",
+			steps: []step{
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateClosed},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateClosed},
+				{expectedAllow: true, expected: CircuitStateClosed},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateClosed},
+			},
+		},
+		{
+			name: "test_half_open_probe_closes",
+			steps: []step{
+				{err: errUnavailable, expectedAllow: true},
+				{err: errUnavailable, expectedAllow: true},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateOpen},
+				{elapsed: time.Minute, expectedAllow: true, expected: CircuitStateClosed},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateClosed},
+			},
+		},
+		{
+			name: "test_half_open_probe_opens",
+			steps: []step{
+				{err: errUnavailable, expectedAllow: true},
+				{err: errUnavailable, expectedAllow: true},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateOpen},
+				{elapsed: time.Minute, err: errUnavailable, expectedAllow: true, expected: CircuitStateOpen},
+				{elapsed: 90 * time.Second, expected: CircuitStateOpen},
+				{elapsed: 2 * time.Minute, expectedAllow: true, expected: CircuitStateClosed},
+			},
+		},
+		{
+			name: "test_half_open_release",
+			steps: []step{
+				{err: errUnavailable, expectedAllow: true},
+				{err: errUnavailable, expectedAllow: true},
+				{err: errUnavailable, expectedAllow: true, expected: CircuitStateOpen},
+				{elapsed: time.Minute, release: true, expectedAllow: true, expected: CircuitStateOpen},
+				{elapsed: time.Minute, expectedAllow: true, expected: CircuitStateClosed},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b breaker
+			for i, s := range tc.steps {
+				now := start.Add(s.elapsed)
+
+				allowed, state, ticket := b.allow(now, time.Minute)
+				if diff := cmp.Diff(s.expectedAllow, allowed); diff != "" {
+					t.Fatalf("step %d: bad allow (-want, +got): %s", i, diff)
+				}
+
+				if allowed {
+					if s.release {
+						state = b.release()
+					} else {
+						state = b.done(s.err, ticket, now, 3)
+					}
+				}
+
+				if diff := cmp.Diff(s.expected, state); diff != "" {
+					t.Fatalf("step %d: bad state (-want, +got): %s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestBreaker_HalfOpenSingleProbe(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)
+
+	var b breaker
+	b.done(errors.New("unavailable"), 0, start, 1)
+
+	if ok, _, _ := b.allow(start.Add(time.Minute), time.Minute); !ok {
+		t.Fatalf("probe is not allowed after the cool-down")
+	}
+
+	if ok, state, _ := b.allow(start.Add(time.Minute), time.Minute); ok || state != CircuitStateHalfOpen {
+		t.Errorf("second request allowed while probing: %v, %s", ok, state)
+	}
+}
+
+func TestBreaker_ConcurrentFailures(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+	now := time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)
+
+	var b breaker
+
+	// the requests allowed together fail with the same outage
+	_, _, latest := b.allow(now, time.Minute)
+	_, _, on := b.allow(now, time.Minute)
+
+	b.done(errUnavailable, latest, now, 2)
+	if diff := cmp.Diff(CircuitStateClosed, b.done(errUnavailable, on, now, 2)); diff != "" {
+		t.Fatalf("bad state after the concurrent failures (-want, +got): %s", diff)
+	}
+
+	// the next request fails on its own
+	_, _, next := b.allow(now, time.Minute)
+	if diff := cmp.Diff(CircuitStateOpen, b.done(errUnavailable, next, now, 2)); diff != "" {
+		t.Errorf("bad state after the next failure (-want, +got): %s", diff)
+	}
+}
+
+// flakySource returns the USD/RUB rate unless err is set and counts the fetches
+type flakySource struct {
+	mtx   sync.Mutex
+	err   error
+	calls int
+}
+
+func (s *flakySource) GetExchangeable() []label.Symbol {
+	return []label.Symbol{label.USD, label.RUB}
+}
+
+func (s *flakySource) FetchLatest(context.Context) ([]provider.ExchangeRate, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return []provider.ExchangeRate{
+		ExchangeRate{
+			time: time.Now(),
+			from: label.Currencies[label.USD],
+			to:   label.Currencies[label.RUB],
+			rate: decimal.RequireFromString("72.9781"),
+		},
+	}, nil
+}
+
+func (s *flakySource) set(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.err = err
+}
+
+func (s *flakySource) count() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.calls
+}
+
+func TestExchanger_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+
+	type step struct {
+		elapsed time.Duration
+		err     error
+		// expected the state of the circuit reported in SourceInfo
+		expected      CircuitState
+		expectedCalls int
+		expectedError string
+	}
+
+	testCases := []struct {
+		name         string
+		providerOpts []ProviderOption
+		steps        []step
+	}{
+		{
+			name: "test_skip_open_provider",
+			steps: []step{
+				{err: errUnavailable, expected: CircuitStateClosed, expectedCalls: 1},
+				{err: errUnavailable, expected: CircuitStateOpen, expectedCalls: 2},
+				{err: errUnavailable, expected: CircuitStateOpen, expectedCalls: 2, expectedError: ErrCircuitOpen.Error()},
+				{elapsed: time.Minute, expected: CircuitStateClosed, expectedCalls: 3},
+			},
+		},
+		{
+			name:         "test_provider_threshold",
+			providerOpts: []ProviderOption{WithProviderCircuitBreaker(3, time.Minute)},
+			steps: []step{
+				{err: errUnavailable, expected: CircuitStateClosed, expectedCalls: 1},
+				{err: errUnavailable, expected: CircuitStateClosed, expectedCalls: 2},
+				{err: errUnavailable, expected: CircuitStateOpen, expectedCalls: 3},
+			},
+		},
+		{
+			name:         "test_provider_disabled",
+			providerOpts: []ProviderOption{WithProviderCircuitBreaker(0, 0)},
+			steps: []step{
+				{err: errUnavailable, expected: CircuitStateClosed, expectedCalls: 1},
+				{err: errUnavailable, expected: CircuitStateClosed, expectedCalls: 2},
+				{err: errUnavailable, expected: CircuitStateClosed, expectedCalls: 3},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mtx sync.Mutex
+			now := time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)
+			clock := func() time.Time {
+				mtx.Lock()
+				defer mtx.Unlock()
+
+				return now
+			}
+
+			source := &flakySource{}
+
+			e := New(http.DefaultClient, WithRetryNum(0), WithCircuitBreaker(2, time.Minute), WithClock(clock))
+			e.providers = make([]*Provider, 0)
+			e.Register("test_source", source, 0, tc.providerOpts...)
+
+			for i, s := range tc.steps {
+				mtx.Lock()
+				now = now.Add(s.elapsed)
+				mtx.Unlock()
+
+				source.set(s.err)
+
+				info := e.GetLatest(context.Background()).Info[0]
+				if diff := cmp.Diff(s.expected, info.Circuit); diff != "" {
+					t.Errorf("step %d: bad circuit (-want, +got): %s", i, diff)
+				}
+
+				if diff := cmp.Diff(s.expectedCalls, source.count()); diff != "" {
+					t.Errorf("step %d: bad calls (-want, +got): %s", i, diff)
+				}
+
+				if s.expectedError != "" {
+					if diff := cmp.Diff(s.expectedError, info.ErrorMessage); diff != "" {
+						t.Errorf("step %d: bad error (-want, +got): %s", i, diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExchanger_CircuitBreakerConcurrentFetches(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	errUnavailable := errors.New("unavailable")
+
+	// the latest and historical fetches wait for each other, so they fail with the same outage
+	var started sync.WaitGroup
+	started.Add(2)
+	fail := func() error {
+		started.Done()
+		started.Wait()
+
+		return errUnavailable
+	}
+
+	source := provider.NewMockHistoricalSource(ctrl)
+	source.EXPECT().GetExchangeable().Return([]label.Symbol{label.USD, label.RUB}).AnyTimes()
+	source.EXPECT().FetchLatest(gomock.Any()).DoAndReturn(
+		func(context.Context) ([]provider.ExchangeRate, error) {
+			return nil, fail()
+		},
+	)
+	source.EXPECT().FetchOn(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, time.Time) ([]provider.ExchangeRate, error) {
+			return nil, fail()
+		},
+	)
+
+	e := New(http.DefaultClient, WithRetryNum(0), WithCircuitBreaker(2, time.Minute))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", source, 0)
+
+	infos := make([]SourceInfo, 2)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		infos[0] = e.GetLatest(ctx).Info[0]
+	}()
+	go func() {
+		defer wg.Done()
+		infos[1] = e.GetOn(ctx, time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)).Info[0]
+	}()
+	wg.Wait()
+
+	for i, info := range infos {
+		if diff := cmp.Diff(CircuitStateClosed, info.Circuit); diff != "" {
+			t.Errorf("fetch %d: bad circuit (-want, +got): %s", i, diff)
+		}
+	}
+}
+
+func TestExchanger_CircuitBreakerIgnoresHistory(t *testing.T) {
+	t.Parallel()
+
+	e := New(http.DefaultClient, WithRetryNum(0), WithCircuitBreaker(1, time.Minute))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_latest_only", &flakySource{}, 0)
+
+	for i := 0; i < 3; i++ {
+		info := e.GetOn(context.Background(), time.Now()).Info[0]
+		if diff := cmp.Diff(CircuitStateClosed, info.Circuit); diff != "" {
+			t.Fatalf("attempt %d: bad circuit (-want, +got): %s", i, diff)
+		}
+
+		if diff := cmp.Diff(fmt.Sprintf("fetch: %s", ErrHistoricalNotSupported), info.ErrorMessage); diff != "" {
+			t.Errorf("attempt %d: bad error (-want, +got): %s", i, diff)
+		}
+	}
+}
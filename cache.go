@@ -0,0 +1,281 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+var (
+	ErrAutoRefreshNotEnabled = errors.New("auto refresh is not enabled")
+	ErrAlreadyStarted        = errors.New("auto refresh is already started")
+)
+
+// WithCache keep the last merged exchange rates in memory and serve GetLatest and Convert from them
+// until they are older than ttl. A zero ttl keeps the rates until the next refresh
+func WithCache(ttl time.Duration) Option {
+	return func(e *exchanger) {
+		if e.cache == nil {
+			e.cache = &cache{}
+		}
+
+		e.cache.ttl = ttl
+	}
+}
+
+// WithAutoRefresh enable the cache and refresh it in the background every interval after Start is called
+func WithAutoRefresh(interval time.Duration) Option {
+	return func(e *exchanger) {
+		if e.cache == nil {
+			e.cache = &cache{}
+		}
+
+		e.cache.interval = interval
+	}
+}
+
+// snapshot is an immutable merged response with the rates indexed by currency pair
+type snapshot struct {
+	resp         LatestResponse
+	rates        rateIndex
+	exchangeable map[label.Symbol]struct{}
+}
+
+func newSnapshot(resp LatestResponse) *snapshot {
+	s := &snapshot{
+		resp:         resp,
+		rates:        newRateIndex(resp.Result),
+		exchangeable: make(map[label.Symbol]struct{}, len(resp.Expected)),
+	}
+
+	for _, symbol := range resp.Expected {
+		s.exchangeable[symbol] = struct{}{}
+	}
+
+	return s
+}
+
+type cache struct {
+	ttl      time.Duration
+	interval time.Duration
+
+	// value holds *snapshot, reads never take a lock
+	value atomic.Value
+
+	// refreshMtx serializes the refreshes of the snapshot
+	refreshMtx sync.Mutex
+
+	mtx    sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (c *cache) load() *snapshot {
+	s, _ := c.value.Load().(*snapshot)
+	return s
+}
+
+func (c *cache) store(s *snapshot) {
+	c.value.Store(s)
+}
+
+// invalidate drops the cached snapshot
+func (c *cache) invalidate() {
+	c.store((*snapshot)(nil))
+}
+
+// fresh returns the snapshot if it is not older than ttl
+func (c *cache) fresh(now time.Time) (*snapshot, bool) {
+	s := c.load()
+	if s == nil {
+		return nil, false
+	}
+
+	if c.ttl > 0 && now.Sub(s.resp.FetchedAt) > c.ttl {
+		return nil, false
+	}
+
+	return s, true
+}
+
+// Start fetches the exchange rates and refreshes them in the background every interval set by WithAutoRefresh.
+// The refreshing stops when ctx is done or Stop is called, Start can be called again after that
+func (e *exchanger) Start(ctx context.Context) error {
+	if e.cache == nil || e.cache.interval <= 0 {
+		return ErrAutoRefreshNotEnabled
+	}
+
+	e.cache.mtx.Lock()
+	defer e.cache.mtx.Unlock()
+
+	if e.cache.done != nil {
+		select {
+		case <-e.cache.done:
+			// the refreshing stopped with its context
+			e.cache.cancel()
+			e.cache.cancel = nil
+			e.cache.done = nil
+		default:
+			return ErrAlreadyStarted
+		}
+	}
+
+	e.refresh(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	e.cache.cancel = cancel
+	e.cache.done = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(e.cache.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.refresh(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the background refreshing and waits for the running refresh to finish
+func (e *exchanger) Stop() {
+	if e.cache == nil {
+		return
+	}
+
+	e.cache.mtx.Lock()
+	defer e.cache.mtx.Unlock()
+
+	if e.cache.done == nil {
+		return
+	}
+
+	e.cache.cancel()
+	<-e.cache.done
+
+	e.cache.cancel = nil
+	e.cache.done = nil
+}
+
+// SnapshotAge returns the age of the cached exchange rates. False if the cache is disabled or empty
+func (e *exchanger) SnapshotAge() (time.Duration, bool) {
+	if e.cache == nil {
+		return 0, false
+	}
+
+	s := e.cache.load()
+	if s == nil {
+		return 0, false
+	}
+
+	return e.now().Sub(s.resp.FetchedAt), true
+}
+
+// convertCached converts the value with the cached exchange rates without taking the exchanger lock
+func (e *exchanger) convertCached(ctx context.Context, param ConvOpt, value decimal.Decimal) (ConversionResponse, error) {
+	var resp ConversionResponse
+
+	fromCurrency, ok := label.Currencies[param.From]
+	if !ok {
+		return resp, ErrCurrencyNotFound
+	}
+
+	toCurrency, ok := label.Currencies[param.To]
+	if !ok {
+		return resp, ErrCurrencyNotFound
+	}
+
+	s := e.cached(ctx)
+	if _, ok := s.exchangeable[param.From]; !ok {
+		return resp, fmt.Errorf("%w: %s", ErrCurrencyNotFound, param.From)
+	}
+
+	if _, ok := s.exchangeable[param.To]; !ok {
+		return resp, fmt.Errorf("%w: %s", ErrCurrencyNotFound, param.To)
+	}
+
+	path := s.rates.path(param.From, param.To, e.pivots, e.maxPathLen)
+
+	return conversion(value, fromCurrency, toCurrency, path, s.resp.Info)
+}
+
+// cached returns the fresh snapshot, fetching it if the cache is empty or expired.
+// The concurrent callers share the fetch, the caller giving up gets the previous snapshot
+func (e *exchanger) cached(ctx context.Context) *snapshot {
+	if s, ok := e.cache.fresh(e.now()); ok {
+		return s
+	}
+
+	v, err := e.flight.Do(ctx, snapshotKey, func(ctx context.Context) (interface{}, error) {
+		e.cache.refreshMtx.Lock()
+		defer e.cache.refreshMtx.Unlock()
+
+		// the snapshot could have been refreshed while waiting for the lock
+		if s, ok := e.cache.fresh(e.now()); ok {
+			return s, nil
+		}
+
+		return e.fetchSnapshot(ctx), nil
+	})
+	if err != nil {
+		if prev := e.cache.load(); prev != nil {
+			return prev
+		}
+
+		return newSnapshot(e.abandoned(e.state(), err))
+	}
+
+	return v.(*snapshot)
+}
+
+// refresh fetches the exchange rates and replaces the snapshot
+func (e *exchanger) refresh(ctx context.Context) {
+	e.cache.refreshMtx.Lock()
+	defer e.cache.refreshMtx.Unlock()
+
+	e.fetchSnapshot(ctx)
+}
+
+// fetchSnapshot fetches the exchange rates and stores them in the cache.
+// The previous snapshot is kept if no provider returned the rates
+func (e *exchanger) fetchSnapshot(ctx context.Context) *snapshot {
+	state := e.state()
+	resp := e.fetchLatest(ctx, state)
+
+	if prev := e.cache.load(); prev != nil && len(resp.Result) == 0 {
+		return prev
+	}
+
+	s := newSnapshot(resp)
+
+	// the lock keeps the providers from changing until the snapshot is stored.
+	// If they were changed during the fetch, the next read fetches the rates of the current providers
+	e.mtx.RLock()
+	if e.version != state.version {
+		e.mtx.RUnlock()
+		return s
+	}
+
+	e.cache.store(s)
+	e.mtx.RUnlock()
+
+	e.subs.publish(resp.Result, e.opts.DivisionPrecision)
+
+	return s
+}
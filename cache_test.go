@@ -0,0 +1,289 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+// countingSource returns the USD/RUB rate increased by one on every fetch
+type countingSource struct {
+	mtx   sync.Mutex
+	calls int
+	err   error
+}
+
+func (s *countingSource) GetExchangeable() []label.Symbol {
+	return []label.Symbol{label.USD, label.RUB}
+}
+
+func (s *countingSource) FetchLatest(context.Context) ([]provider.ExchangeRate, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	s.calls++
+
+	return []provider.ExchangeRate{
+		ExchangeRate{
+			time: time.Now(),
+			from: label.Currencies[label.USD],
+			to:   label.Currencies[label.RUB],
+			rate: decimal.New(int64(70+s.calls), 0),
+		},
+	}, nil
+}
+
+func (s *countingSource) count() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.calls
+}
+
+func (s *countingSource) fail(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.err = err
+}
+
+func newCachedExchanger(source provider.Source, opts ...Option) *exchanger {
+	e := New(http.DefaultClient, append(opts, WithRetryNum(0))...)
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", source, 0)
+
+	return e
+}
+
+func TestExchanger_WithCache(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		ttl           time.Duration
+		elapsed       time.Duration
+		expectedCalls int
+		expectedRate  string
+	}{
+		{
+			name:          "test_cache_hit",
+			ttl:           time.Minute,
+			elapsed:       30 * time.Second,
+			expectedCalls: 1,
+			expectedRate:  "71",
+		},
+		{
+			name:          "test_cache_expired",
+			ttl:           time.Minute,
+			elapsed:       2 * time.Minute,
+			expectedCalls: 2,
+			expectedRate:  "72",
+		},
+		{
+			name:          "test_cache_without_ttl",
+			ttl:           0,
+			elapsed:       24 * time.Hour,
+			expectedCalls: 1,
+			expectedRate:  "71",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			source := &countingSource{}
+			e := newCachedExchanger(source, WithCache(tc.ttl))
+
+			now := time.Date(2021, 7, 30, 12, 0, 0, 0, time.UTC)
+			e.now = func() time.Time { return now }
+
+			latest := e.GetLatest(ctx)
+			if diff := cmp.Diff(now, latest.FetchedAt); diff != "" {
+				t.Errorf("bad fetched at (-want, +got): %s", diff)
+			}
+
+			now = now.Add(tc.elapsed)
+
+			conv, err := e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: 1})
+			if err != nil {
+				t.Fatalf("convert: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedRate, conv.Rate.String()); diff != "" {
+				t.Errorf("bad rate (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(tc.expectedCalls, source.count()); diff != "" {
+				t.Errorf("bad fetch calls (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestExchanger_WithCacheKeepsSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source := &countingSource{}
+	e := newCachedExchanger(source, WithCache(time.Minute))
+
+	now := time.Date(2021, 7, 30, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	e.GetLatest(ctx)
+
+	source.fail(errors.New("unavailable"))
+	now = now.Add(2 * time.Minute)
+
+	latest := e.GetLatest(ctx)
+	if diff := cmp.Diff(1, len(latest.Result)); diff != "" {
+		t.Fatalf("bad result len (-want, +got): %s", diff)
+	}
+
+	age, ok := e.SnapshotAge()
+	if !ok {
+		t.Fatalf("snapshot not found")
+	}
+
+	if diff := cmp.Diff(2*time.Minute, age); diff != "" {
+		t.Errorf("bad snapshot age (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(2*time.Minute, latest.Age()); diff != "" {
+		t.Errorf("bad response age (-want, +got): %s", diff)
+	}
+}
+
+func TestExchanger_WithCacheInvalidate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	source := &countingSource{}
+	e := newCachedExchanger(source, WithCache(time.Hour))
+	e.GetLatest(ctx)
+
+	other := provider.NewMockSource(ctrl)
+	other.EXPECT().GetExchangeable().Return([]label.Symbol{label.GBP}).AnyTimes()
+	other.EXPECT().FetchLatest(gomock.Any()).Return(nil, nil).Times(1)
+
+	e.Register("other_source", other, 1)
+
+	if _, ok := e.SnapshotAge(); ok {
+		t.Errorf("snapshot is not invalidated")
+	}
+
+	e.GetLatest(ctx)
+
+	if diff := cmp.Diff(2, source.count()); diff != "" {
+		t.Errorf("bad fetch calls (-want, +got): %s", diff)
+	}
+}
+
+func TestExchanger_StartStop(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	if err := New(http.DefaultClient).Start(ctx); !errors.Is(err, ErrAutoRefreshNotEnabled) {
+		t.Fatalf("bad error, want %v, got %v", ErrAutoRefreshNotEnabled, err)
+	}
+
+	source := &countingSource{}
+	e := newCachedExchanger(source, WithAutoRefresh(10*time.Millisecond))
+
+	if err := e.Start(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if err := e.Start(ctx); !errors.Is(err, ErrAlreadyStarted) {
+		t.Fatalf("bad error, want %v, got %v", ErrAlreadyStarted, err)
+	}
+
+	if diff := cmp.Diff(1, source.count()); diff != "" {
+		t.Errorf("bad fetch calls after start (-want, +got): %s", diff)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for source.count() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("exchange rates are not refreshed")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: 1}); err != nil {
+				t.Errorf("convert: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	e.Stop()
+
+	calls := source.count()
+	time.Sleep(30 * time.Millisecond)
+
+	if diff := cmp.Diff(calls, source.count()); diff != "" {
+		t.Errorf("exchange rates are refreshed after stop (-want, +got): %s", diff)
+	}
+}
+
+func TestExchanger_StartAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	source := &countingSource{}
+	e := newCachedExchanger(source, WithAutoRefresh(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := e.Start(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	cancel()
+
+	// the refreshing stopped by its context is started again without Stop
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := e.Start(context.Background())
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, ErrAlreadyStarted) || time.Now().After(deadline) {
+			t.Fatalf("start after cancel: %v", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	defer e.Stop()
+
+	if diff := cmp.Diff(2, source.count()); diff != "" {
+		t.Errorf("bad fetch calls (-want, +got): %s", diff)
+	}
+}
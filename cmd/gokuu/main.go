@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/internal/logging"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider/file"
+	"github.com/robotomize/gokuu/provider/httputil"
+	"github.com/robotomize/gokuu/server"
+)
+
+const usage = `Usage: gokuu [flags] <command> [args]
+
+Commands:
+  latest [SYMBOL...]       latest exchange rates, optionally only from the symbols
+  convert VALUE FROM TO    convert the value, e.g. convert 100 USD RUB
+  providers                status of the providers
+  symbols                  supported currency symbols
+  countries [SYMBOL]       countries, optionally only using the currency
+  serve [-addr] [-refresh] serve the exchange rates over HTTP
+
+Flags:
+`
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+var (
+	ErrUnknownCommand  = errors.New("unknown command")
+	ErrUnknownProvider = errors.New("unknown provider")
+	ErrUnknownStrategy = errors.New("unknown merge strategy")
+	ErrUnknownFormat   = errors.New("unknown output format")
+	ErrInvalidArgs     = errors.New("invalid arguments")
+)
+
+// providerNameFile name of the provider reading the rates files from -rates-dir
+const providerNameFile = "file"
+
+var providerNames = []string{gokuu.ProviderNameECB, gokuu.ProviderNameRCB, gokuu.ProviderNameCAE}
+
+// refresher the exchanger refreshing its cache in the background
+type refresher interface {
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type config struct {
+	strategy     string
+	weights      string
+	maxDeviation float64
+	timeout      time.Duration
+	retries      uint64
+	providers    string
+	format       string
+	cacheDir     string
+	ratesDir     string
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx = logging.WithLogger(ctx, logging.NewLogger("Gokuu: ", log.Lmsgprefix))
+	logger := logging.FromContext(ctx)
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+
+		stop()
+		logger.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, args []string, w io.Writer) error {
+	var cfg config
+
+	flags := flag.NewFlagSet("gokuu", flag.ContinueOnError)
+	flags.StringVar(&cfg.strategy, "strategy", string(gokuu.MergeStrategyTypeRace),
+		"merge strategy: race, priority, average, median, weighted")
+	flags.StringVar(&cfg.weights, "weights", "", "provider weights for the weighted strategy, e.g. ecb=2,rcb=1")
+	flags.Float64Var(&cfg.maxDeviation, "max-deviation", 0, "drop quotes deviating from the median by more than percent")
+	flags.DurationVar(&cfg.timeout, "timeout", gokuu.DefaultRequestTimeout, "timeout of the provider requests")
+	flags.Uint64Var(&cfg.retries, "retries", gokuu.DefaultRetryNum, "number of retries of the failed provider requests")
+	flags.StringVar(&cfg.providers, "providers", strings.Join(providerNames, ","), "comma-separated list of providers")
+	flags.StringVar(&cfg.format, "format", formatTable, "output format: table, json, csv")
+	flags.StringVar(&cfg.cacheDir, "cache-dir", "", "directory caching the provider responses for conditional requests")
+	flags.StringVar(&cfg.ratesDir, "rates-dir", "", "directory with the rates files used as the lowest priority provider")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("%w: command is required", ErrInvalidArgs)
+	}
+
+	out, err := newPrinter(cfg.format, w)
+	if err != nil {
+		return err
+	}
+
+	cmd, cmdArgs := flags.Arg(0), flags.Args()[1:]
+	switch cmd {
+	case "symbols":
+		return out.symbols(label.GetSymbols())
+	case "countries":
+		return countries(out, cmdArgs)
+	case "serve":
+		return serve(ctx, cfg, cmdArgs)
+	case "latest", "convert", "providers":
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, cmd)
+	}
+
+	e, err := newExchanger(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "latest":
+		return latest(ctx, e, out, cmdArgs)
+	case "convert":
+		return convert(ctx, e, out, cmdArgs)
+	default:
+		return out.providers(e.GetLatest(ctx).Info)
+	}
+}
+
+// newExchanger creates the exchanger with the options from the flags
+func newExchanger(cfg config, extra ...gokuu.Option) (gokuu.Exchanger, error) {
+	if cfg.maxDeviation < 0 || math.IsNaN(cfg.maxDeviation) || math.IsInf(cfg.maxDeviation, 0) {
+		return nil, fmt.Errorf("%w: max deviation %v is not a finite non-negative number", ErrInvalidArgs, cfg.maxDeviation)
+	}
+
+	opts := []gokuu.Option{
+		gokuu.WithRequestTimeout(cfg.timeout),
+		gokuu.WithRetryNum(cfg.retries),
+		gokuu.WithMaxDeviation(cfg.maxDeviation),
+	}
+
+	switch gokuu.MergeStrategyType(cfg.strategy) {
+	case gokuu.MergeStrategyTypeRace:
+		opts = append(opts, gokuu.WithRaceMergeStrategy())
+	case gokuu.MergeStrategyTypePriority:
+		opts = append(opts, gokuu.WithPriorityMergeStrategy())
+	case gokuu.MergeStrategyTypeAverage:
+		opts = append(opts, gokuu.WithAverageMergeStrategy())
+	case gokuu.MergeStrategyTypeMedian:
+		opts = append(opts, gokuu.WithMedianMergeStrategy())
+	case gokuu.MergeStrategyTypeWeighted:
+		weights, err := parseWeights(cfg.weights)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, gokuu.WithWeightedMergeStrategy(weights))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, cfg.strategy)
+	}
+
+	if cfg.cacheDir != "" {
+		cache, err := httputil.NewDiskCache(cfg.cacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("response cache: %w", err)
+		}
+
+		opts = append(opts, gokuu.WithResponseCache(cache))
+	}
+
+	selected, err := parseProviders(cfg.providers)
+	if err != nil {
+		return nil, err
+	}
+
+	e := gokuu.New(http.DefaultClient, append(opts, extra...)...)
+	for _, name := range providerNames {
+		if _, ok := selected[name]; !ok {
+			e.Delete(name)
+		}
+	}
+
+	if cfg.ratesDir != "" {
+		e.Register(providerNameFile, file.NewDirSource(cfg.ratesDir), gokuu.Prior(len(providerNames)))
+	}
+
+	return e, nil
+}
+
+func parseProviders(s string) (map[string]struct{}, error) {
+	selected := make(map[string]struct{})
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		known := false
+		for _, n := range providerNames {
+			if n == name {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+		}
+
+		selected[name] = struct{}{}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: no providers selected", ErrInvalidArgs)
+	}
+
+	return selected, nil
+}
+
+func parseWeights(s string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%w: weight %q", ErrInvalidArgs, pair)
+		}
+
+		w, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: weight %q: %v", ErrInvalidArgs, pair, err)
+		}
+
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("%w: weight %q is not a finite non-negative number", ErrInvalidArgs, pair)
+		}
+
+		weights[strings.ToLower(strings.TrimSpace(kv[0]))] = w
+	}
+
+	return weights, nil
+}
+
+func parseSymbol(s string) (label.Symbol, error) {
+	symbol := label.Symbol(strings.ToUpper(s))
+	if _, ok := label.Currencies[symbol]; !ok {
+		return "", fmt.Errorf("%w: %s", gokuu.ErrCurrencyNotFound, s)
+	}
+
+	return symbol, nil
+}
+
+func latest(ctx context.Context, e gokuu.Exchanger, out *printer, args []string) error {
+	filter := make(map[label.Symbol]struct{}, len(args))
+	for _, arg := range args {
+		symbol, err := parseSymbol(arg)
+		if err != nil {
+			return err
+		}
+
+		filter[symbol] = struct{}{}
+	}
+
+	resp := e.GetLatest(ctx)
+
+	rates := make([]gokuu.ExchangeRate, 0, len(resp.Result))
+	for _, r := range resp.Result {
+		if _, ok := filter[r.From().Symbol]; ok || len(filter) == 0 {
+			rates = append(rates, r)
+		}
+	}
+
+	return out.rates(rates)
+}
+
+func convert(ctx context.Context, e gokuu.Exchanger, out *printer, args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("%w: usage: convert VALUE FROM TO", ErrInvalidArgs)
+	}
+
+	value, err := decimal.NewFromString(args[0])
+	if err != nil {
+		return fmt.Errorf("%w: value %q: %v", ErrInvalidArgs, args[0], err)
+	}
+
+	from, err := parseSymbol(args[1])
+	if err != nil {
+		return err
+	}
+
+	to, err := parseSymbol(args[2])
+	if err != nil {
+		return err
+	}
+
+	conv, err := e.Convert(ctx, gokuu.ConvOpt{From: from, To: to, DecimalValue: &value})
+	if err != nil {
+		return fmt.Errorf("convert: %w", err)
+	}
+
+	return out.conversion(conv)
+}
+
+func countries(out *printer, args []string) error {
+	if len(args) == 0 {
+		return out.countries(label.GetCountries())
+	}
+
+	symbol, err := parseSymbol(args[0])
+	if err != nil {
+		return err
+	}
+
+	return out.countries(label.GetCountriesUsingCurrency(symbol))
+}
+
+// serve serves the exchange rates refreshed in the background until ctx is done
+func serve(ctx context.Context, cfg config, args []string) error {
+	var (
+		addr    string
+		refresh time.Duration
+	)
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.StringVar(&addr, "addr", ":8080", "address to listen on")
+	flags.DurationVar(&refresh, "refresh", 10*time.Minute, "interval of refreshing the exchange rates")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if refresh <= 0 {
+		return fmt.Errorf("%w: refresh interval must be positive", ErrInvalidArgs)
+	}
+
+	e, err := newExchanger(cfg, gokuu.WithAutoRefresh(refresh))
+	if err != nil {
+		return err
+	}
+
+	r, ok := e.(refresher)
+	if !ok {
+		return errors.New("exchanger does not support refreshing")
+	}
+
+	logger := logging.FromContext(ctx)
+
+	if err := r.Start(ctx); err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	defer r.Stop()
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           server.NewHandler(e),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Printf("listening on %s", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("listen: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		args        []string
+		expectedErr error
+		expected    string
+	}{
+		{
+			name:     "test_countries_using_currency",
+			args:     []string{"countries", "kzt"},
+			expected: "COUNTRY\nKAZAKHSTAN\n",
+		},
+		{
+			name:     "test_countries_using_currency_csv",
+			args:     []string{"-format", "csv", "countries", "RUB"},
+			expected: "country\nRUSSIAN FEDERATION (THE)\n",
+		},
+		{
+			name:     "test_countries_using_currency_json",
+			args:     []string{"-format", "json", "countries", "KZT"},
+			expected: "[\n  \"KAZAKHSTAN\"\n]\n",
+		},
+		{
+			name:        "test_unknown_currency",
+			args:        []string{"countries", "ABC"},
+			expectedErr: gokuu.ErrCurrencyNotFound,
+		},
+		{
+			name:        "test_without_command",
+			args:        []string{},
+			expectedErr: ErrInvalidArgs,
+		},
+		{
+			name:        "test_unknown_command",
+			args:        []string{"rates"},
+			expectedErr: ErrUnknownCommand,
+		},
+		{
+			name:        "test_unknown_format",
+			args:        []string{"-format", "xml", "symbols"},
+			expectedErr: ErrUnknownFormat,
+		},
+		{
+			name:        "test_unknown_provider",
+			args:        []string{"-providers", "ecb,fed", "latest"},
+			expectedErr: ErrUnknownProvider,
+		},
+		{
+			name:        "test_unknown_strategy",
+			args:        []string{"-strategy", "mode", "latest"},
+			expectedErr: ErrUnknownStrategy,
+		},
+		{
+			name:        "test_invalid_weights",
+			args:        []string{"-strategy", "weighted", "-weights", "ecb:2", "latest"},
+			expectedErr: ErrInvalidArgs,
+		},
+		{
+			name:        "test_nan_weight",
+			args:        []string{"-strategy", "weighted", "-weights", "ecb=NaN", "latest"},
+			expectedErr: ErrInvalidArgs,
+		},
+		{
+			name:        "test_negative_weight",
+			args:        []string{"-strategy", "weighted", "-weights", "ecb=2,rcb=-1", "latest"},
+			expectedErr: ErrInvalidArgs,
+		},
+		{
+			name:        "test_nan_max_deviation",
+			args:        []string{"-max-deviation", "NaN", "latest"},
+			expectedErr: ErrInvalidArgs,
+		},
+		{
+			name:        "test_convert_without_target",
+			args:        []string{"convert", "100", "USD"},
+			expectedErr: ErrInvalidArgs,
+		},
+		{
+			name:        "test_serve_invalid_refresh",
+			args:        []string{"serve", "-refresh", "0s"},
+			expectedErr: ErrInvalidArgs,
+		},
+		{
+			name:        "test_convert_invalid_value",
+			args:        []string{"convert", "1O0", "USD", "RUB"},
+			expectedErr: ErrInvalidArgs,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			err := run(context.Background(), tc.args, &buf)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("bad error, want %v, got %v", tc.expectedErr, err)
+			}
+
+			if tc.expectedErr != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, buf.String()); diff != "" {
+				t.Errorf("bad output (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestRun_Symbols(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := run(context.Background(), []string{"symbols"}, &buf); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if diff := cmp.Diff([]string{"SYMBOL", "NAME"}, strings.Fields(lines[0])); diff != "" {
+		t.Errorf("bad header (-want, +got): %s", diff)
+	}
+
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "USD ") {
+			return
+		}
+	}
+
+	t.Errorf("USD not found in output")
+}
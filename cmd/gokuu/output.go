@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/label"
+)
+
+const dateLayout = "2006-01-02"
+
+// printer writes the command results in the table, JSON or CSV format
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return &printer{format: format, w: w}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+type rateRecord struct {
+	From      label.Symbol `json:"from"`
+	To        label.Symbol `json:"to"`
+	Rate      string       `json:"rate"`
+	Date      string       `json:"date"`
+	Providers []string     `json:"providers"`
+	Stale     bool         `json:"stale,omitempty"`
+}
+
+type conversionRecord struct {
+	Value  string         `json:"value"`
+	From   label.Symbol   `json:"from"`
+	To     label.Symbol   `json:"to"`
+	Rate   string         `json:"rate"`
+	Amount string         `json:"amount"`
+	Date   string         `json:"date"`
+	Path   []label.Symbol `json:"path"`
+}
+
+type providerRecord struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	PublishedAt string `json:"published_at,omitempty"`
+	Stale       bool   `json:"stale,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(dateLayout)
+}
+
+func (p *printer) rates(rates []gokuu.ExchangeRate) error {
+	records := make([]rateRecord, len(rates))
+	for i, r := range rates {
+		records[i] = rateRecord{
+			From:      r.From().Symbol,
+			To:        r.To().Symbol,
+			Rate:      r.Decimal().String(),
+			Date:      formatDate(r.Time()),
+			Providers: r.Providers(),
+			Stale:     r.Stale(),
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].From != records[j].From {
+			return records[i].From < records[j].From
+		}
+
+		return records[i].To < records[j].To
+	})
+
+	rows := make([][]string, len(records))
+	for i, r := range records {
+		rows[i] = []string{
+			r.From.String(), r.To.String(), r.Rate, r.Date, strings.Join(r.Providers, ";"), strconv.FormatBool(r.Stale),
+		}
+	}
+
+	return p.print(records, []string{"from", "to", "rate", "date", "providers", "stale"}, rows)
+}
+
+func (p *printer) conversion(conv gokuu.ConversionResponse) error {
+	record := conversionRecord{
+		Value:  conv.Value.String(),
+		From:   conv.From.Symbol,
+		To:     conv.To.Symbol,
+		Rate:   conv.Rate.String(),
+		Amount: conv.Amount.String(),
+		Date:   formatDate(conv.Date),
+		Path:   conv.Path,
+	}
+
+	path := make([]string, len(record.Path))
+	for i, symbol := range record.Path {
+		path[i] = symbol.String()
+	}
+
+	rows := [][]string{{
+		record.Value,
+		record.From.String(),
+		record.To.String(),
+		record.Rate,
+		record.Amount,
+		record.Date,
+		strings.Join(path, ">"),
+	}}
+
+	return p.print(record, []string{"value", "from", "to", "rate", "amount", "date", "path"}, rows)
+}
+
+func (p *printer) providers(info []gokuu.SourceInfo) error {
+	records := make([]providerRecord, len(info))
+	for i, s := range info {
+		status := "ok"
+		if s.Status == gokuu.ProviderRespStatusFailed {
+			status = "failed"
+		}
+
+		records[i] = providerRecord{
+			Name:        s.Name,
+			Status:      status,
+			PublishedAt: formatDate(s.PublishedAt),
+			Stale:       s.Stale,
+			Error:       s.ErrorMessage,
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+
+	rows := make([][]string, len(records))
+	for i, r := range records {
+		rows[i] = []string{r.Name, r.Status, r.PublishedAt, strconv.FormatBool(r.Stale), r.Error}
+	}
+
+	return p.print(records, []string{"name", "status", "published_at", "stale", "error"}, rows)
+}
+
+func (p *printer) symbols(symbols []label.Symbol) error {
+	type symbolRecord struct {
+		Symbol label.Symbol `json:"symbol"`
+		Name   string       `json:"name"`
+	}
+
+	records := make([]symbolRecord, len(symbols))
+	rows := make([][]string, len(symbols))
+	for i, symbol := range symbols {
+		records[i] = symbolRecord{Symbol: symbol, Name: label.Currencies[symbol].Name}
+		rows[i] = []string{symbol.String(), records[i].Name}
+	}
+
+	return p.print(records, []string{"symbol", "name"}, rows)
+}
+
+func (p *printer) countries(countries []label.CountryName) error {
+	rows := make([][]string, len(countries))
+	for i, country := range countries {
+		rows[i] = []string{string(country)}
+	}
+
+	return p.print(countries, []string{"country"}, rows)
+}
+
+// print writes v as JSON or the rows with the header as a table or CSV
+func (p *printer) print(v interface{}, header []string, rows [][]string) error {
+	switch p.format {
+	case formatJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("json encode: %w", err)
+		}
+	case formatCSV:
+		w := csv.NewWriter(p.w)
+		if err := w.Write(header); err != nil {
+			return fmt.Errorf("csv write: %w", err)
+		}
+
+		if err := w.WriteAll(rows); err != nil {
+			return fmt.Errorf("csv write: %w", err)
+		}
+	default:
+		w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("table write: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package gokuu
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+const (
+	latestKey   = "latest"
+	snapshotKey = "snapshot"
+)
+
+func onKey(date time.Time) string {
+	return "on/" + provider.Day(date).Format("2006-01-02")
+}
+
+// share runs fn once for the concurrent callers with the same key and the same providers.
+// The fetch goes on while at least one caller waits for it, the caller giving up gets the failed response
+func (e *exchanger) share(
+	ctx context.Context, state registry, key string, fn func(ctx context.Context) LatestResponse,
+) LatestResponse {
+	v, err := e.flight.Do(ctx, fmt.Sprintf("%s/%d", key, state.version), func(ctx context.Context) (interface{}, error) {
+		return fn(ctx), nil
+	})
+	if err != nil {
+		return e.abandoned(state, err)
+	}
+
+	return v.(LatestResponse)
+}
+
+// abandoned returns the response for the caller that gave up waiting for the exchange rates with err
+func (e *exchanger) abandoned(state registry, err error) LatestResponse {
+	resp := LatestResponse{
+		Expected:   make([]label.Symbol, len(state.exchangeable)),
+		Unreceived: make([]label.Symbol, len(state.exchangeable)),
+		Info:       make([]SourceInfo, 0, len(state.providers)),
+		Result:     make([]ExchangeRate, 0),
+		FetchedAt:  e.now(),
+		now:        e.now,
+	}
+
+	copy(resp.Expected, state.exchangeable)
+	copy(resp.Unreceived, state.exchangeable)
+
+	for _, p := range state.providers {
+		resp.Info = append(resp.Info, SourceInfo{
+			Name:         p.name,
+			Status:       ProviderRespStatusFailed,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	return resp
+}
+
+// share runs fetchFunc once for the concurrent requests of the provider with the same key.
+// The shared request is limited by timeout, as it outlives the callers giving up
+func (p *Provider) share(
+	ctx context.Context,
+	key string,
+	timeout time.Duration,
+	fetchFunc func(ctx context.Context, source *Provider) ([]provider.ExchangeRate, error),
+) ([]provider.ExchangeRate, error) {
+	if p.flight == nil {
+		return fetchFunc(ctx, p)
+	}
+
+	v, err := p.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return fetchFunc(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]provider.ExchangeRate), nil
+}
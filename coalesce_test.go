@@ -0,0 +1,218 @@
+package gokuu
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+// gateSource returns the USD/RUB rate once released and counts the fetches
+type gateSource struct {
+	release chan struct{}
+	called  chan struct{}
+
+	mtx    sync.Mutex
+	calls  int
+	ctxErr error
+}
+
+func newGateSource() *gateSource {
+	return &gateSource{
+		release: make(chan struct{}),
+		called:  make(chan struct{}, 1),
+	}
+}
+
+func (s *gateSource) GetExchangeable() []label.Symbol {
+	return []label.Symbol{label.USD, label.RUB}
+}
+
+func (s *gateSource) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, error) {
+	s.mtx.Lock()
+	s.calls++
+	s.mtx.Unlock()
+
+	select {
+	case s.called <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+	}
+
+	s.mtx.Lock()
+	s.ctxErr = ctx.Err()
+	s.mtx.Unlock()
+
+	return []provider.ExchangeRate{
+		ExchangeRate{
+			time: time.Now(),
+			from: label.Currencies[label.USD],
+			to:   label.Currencies[label.RUB],
+			rate: decimal.RequireFromString("72.9781"),
+		},
+	}, nil
+}
+
+func (s *gateSource) state() (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.calls, s.ctxErr
+}
+
+func TestExchanger_GetLatestCoalesced(t *testing.T) {
+	t.Parallel()
+
+	const callers = 20
+
+	for _, cached := range []bool{false, true} {
+		cached := cached
+		t.Run(fmt.Sprintf("test_cached_%t", cached), func(t *testing.T) {
+			t.Parallel()
+
+			opts := []Option{WithRetryNum(0)}
+			if cached {
+				opts = append(opts, WithCache(0))
+			}
+
+			source := newGateSource()
+
+			e := New(http.DefaultClient, opts...)
+			e.providers = make([]*Provider, 0)
+			e.Register("test_source", source, 0)
+
+			var wg sync.WaitGroup
+			results := make([]int, callers)
+			for i := 0; i < callers; i++ {
+				i := i
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					results[i] = len(e.GetLatest(context.Background()).Result)
+				}()
+			}
+
+			// the callers join the fetch started by the first of them
+			<-source.called
+			time.Sleep(50 * time.Millisecond)
+			close(source.release)
+			wg.Wait()
+
+			calls, _ := source.state()
+			if diff := cmp.Diff(1, calls); diff != "" {
+				t.Errorf("bad fetches (-want, +got): %s", diff)
+			}
+
+			for i, n := range results {
+				if diff := cmp.Diff(1, n); diff != "" {
+					t.Errorf("caller %d: bad result (-want, +got): %s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestExchanger_GetLatestLeaderGivesUp(t *testing.T) {
+	t.Parallel()
+
+	source := newGateSource()
+
+	e := New(http.DefaultClient, WithRetryNum(0))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", source, 0)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+
+	leader := make(chan LatestResponse, 1)
+	go func() {
+		leader <- e.GetLatest(leaderCtx)
+	}()
+
+	<-source.called
+
+	follower := make(chan LatestResponse, 1)
+	go func() {
+		follower <- e.GetLatest(context.Background())
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	resp := <-leader
+	if diff := cmp.Diff(0, len(resp.Result)); diff != "" {
+		t.Errorf("bad leader result (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(context.Canceled.Error(), resp.Info[0].ErrorMessage); diff != "" {
+		t.Errorf("bad leader error (-want, +got): %s", diff)
+	}
+
+	close(source.release)
+
+	resp = <-follower
+	if diff := cmp.Diff(1, len(resp.Result)); diff != "" {
+		t.Errorf("bad follower result (-want, +got): %s", diff)
+	}
+
+	calls, ctxErr := source.state()
+	if diff := cmp.Diff(1, calls); diff != "" {
+		t.Errorf("bad fetches (-want, +got): %s", diff)
+	}
+
+	if ctxErr != nil {
+		t.Errorf("fetch cancelled with the leader: %v", ctxErr)
+	}
+}
+
+func TestExchanger_ProviderFetchCoalesced(t *testing.T) {
+	t.Parallel()
+
+	source := newGateSource()
+
+	e := New(http.DefaultClient, WithRetryNum(0))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", source, 0)
+
+	first := make(chan LatestResponse, 1)
+	go func() {
+		first <- e.GetLatest(context.Background())
+	}()
+
+	<-source.called
+
+	// the change of the providers starts a new fetch that joins the running request of test_source
+	e.Register("other_source", newStaticSource(testRate(label.EUR, label.DKK, "7.4362", 0)), 0)
+
+	second := make(chan LatestResponse, 1)
+	go func() {
+		second <- e.GetLatest(context.Background())
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(source.release)
+
+	if diff := cmp.Diff([]string{"test_source"}, fetchedProviders(<-first)); diff != "" {
+		t.Errorf("bad first fetch (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"other_source", "test_source"}, fetchedProviders(<-second)); diff != "" {
+		t.Errorf("bad second fetch (-want, +got): %s", diff)
+	}
+
+	calls, _ := source.state()
+	if diff := cmp.Diff(1, calls); diff != "" {
+		t.Errorf("bad fetches (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,340 @@
+// Package decimal implements arbitrary-precision fixed-point decimal numbers for exchange rates and amounts.
+// A Decimal is a big integer coefficient with a decimal exponent, so rates parsed from provider strings
+// are kept exactly as published
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionPrecision is the default number of decimal places of the division results used by the providers
+// for the cross rates, see the WithDivisionPrecision options of the exchanger and the providers
+const DivisionPrecision int32 = 16
+
+var ErrSyntax = errors.New("decimal: invalid syntax")
+
+var ten = big.NewInt(10)
+
+// Decimal represents the number value * 10^exp. The zero value is 0
+type Decimal struct {
+	value *big.Int
+	exp   int32
+}
+
+// New returns value * 10^exp
+func New(value int64, exp int32) Decimal {
+	return Decimal{value: big.NewInt(value), exp: exp}
+}
+
+// NewFromBigInt returns value * 10^exp
+func NewFromBigInt(value *big.Int, exp int32) Decimal {
+	return Decimal{value: new(big.Int).Set(value), exp: exp}
+}
+
+// NewFromString parses the decimal in the plain (-123.45) or the scientific (1.2345e-2) notation
+func NewFromString(s string) (Decimal, error) {
+	token := s
+
+	var exp int64
+	if idx := strings.IndexAny(token, "eE"); idx >= 0 {
+		e, err := strconv.ParseInt(token[idx+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrSyntax, s)
+		}
+
+		exp = e
+		token = token[:idx]
+	}
+
+	neg := false
+	if token != "" && (token[0] == '-' || token[0] == '+') {
+		neg = token[0] == '-'
+		token = token[1:]
+	}
+
+	intPart, fracPart := token, ""
+	if idx := strings.IndexByte(token, '.'); idx >= 0 {
+		intPart, fracPart = token[:idx], token[idx+1:]
+	}
+
+	digits := intPart + fracPart
+	if digits == "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrSyntax, s)
+		}
+	}
+
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+
+	exp -= int64(len(fracPart))
+	if exp < math.MinInt32 || exp > math.MaxInt32 {
+		return Decimal{}, fmt.Errorf("%w: exponent out of range %q", ErrSyntax, s)
+	}
+
+	if neg {
+		value.Neg(value)
+	}
+
+	return Decimal{value: value, exp: int32(exp)}, nil
+}
+
+// RequireFromString is like NewFromString but panics if the string can not be parsed
+func RequireFromString(s string) Decimal {
+	d, err := NewFromString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// NewFromFloat converts the float to the shortest decimal that rounds to it. It panics on NaN and infinities
+func NewFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(fmt.Sprintf("decimal: can not convert %v to decimal", f))
+	}
+
+	return RequireFromString(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// Add returns d + d2
+func (d Decimal) Add(d2 Decimal) Decimal {
+	a, b, exp := align(d, d2)
+
+	return Decimal{value: a.Add(a, b), exp: exp}
+}
+
+// Sub returns d - d2
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	a, b, exp := align(d, d2)
+
+	return Decimal{value: a.Sub(a, b), exp: exp}
+}
+
+// Mul returns d * d2
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.coefficient(), d2.coefficient()), exp: d.exp + d2.exp}
+}
+
+// Div returns d / d2 rounded half away from zero to the precision decimal places. It panics if d2 is zero
+func (d Decimal) Div(d2 Decimal, precision int32) Decimal {
+	if d2.IsZero() {
+		panic("decimal: division by zero")
+	}
+
+	num := new(big.Int).Set(d.coefficient())
+	den := new(big.Int).Set(d2.coefficient())
+
+	// d / d2 = (num / den) * 10^(d.exp - d2.exp), the quotient is scaled to 10^-precision
+	shift := int64(precision) + int64(d.exp) - int64(d2.exp)
+	if shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	return Decimal{value: quoRound(num, den, false), exp: -precision}
+}
+
+// Inv returns 1 / d rounded to the precision decimal places
+func (d Decimal) Inv(precision int32) Decimal {
+	return New(1, 0).Div(d, precision)
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.coefficient()), exp: d.exp}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.coefficient()), exp: d.exp}
+}
+
+// Round rounds d half away from zero to the places decimal places
+func (d Decimal) Round(places int32) Decimal {
+	return d.round(places, false)
+}
+
+// RoundBank rounds d half to even to the places decimal places
+func (d Decimal) RoundBank(places int32) Decimal {
+	return d.round(places, true)
+}
+
+// Cmp compares d and d2 and returns -1, 0 or +1
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := align(d, d2)
+
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and d2 represent the same number
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	return d.coefficient().Sign()
+}
+
+// IsZero reports whether d is zero
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Rat returns d as an exact rational number
+func (d Decimal) Rat() *big.Rat {
+	if d.exp >= 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(d.coefficient(), pow10(int64(d.exp))))
+	}
+
+	return new(big.Rat).SetFrac(d.coefficient(), pow10(-int64(d.exp)))
+}
+
+// Float64 returns the nearest float64 value of d
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+
+	return f
+}
+
+// String returns d in the plain notation without trailing zeros
+func (d Decimal) String() string {
+	s := d.format(-d.exp)
+	if strings.IndexByte(s, '.') < 0 {
+		return s
+	}
+
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// StringFixed rounds d to the places decimal places and formats it with exactly the places digits after the point
+func (d Decimal) StringFixed(places int32) string {
+	if places < 0 {
+		places = 0
+	}
+
+	return d.Round(places).format(places)
+}
+
+// MarshalText implements encoding.TextMarshaler, decimals are encoded in the plain notation
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Decimal) UnmarshalText(b []byte) error {
+	v, err := NewFromString(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
+
+func (d Decimal) coefficient() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+
+	return d.value
+}
+
+// rescale returns the coefficient of d for the exponent exp. The exponent must not be greater than d.exp
+func (d Decimal) rescale(exp int32) *big.Int {
+	v := new(big.Int).Set(d.coefficient())
+	if exp < d.exp {
+		v.Mul(v, pow10(int64(d.exp)-int64(exp)))
+	}
+
+	return v
+}
+
+func (d Decimal) round(places int32, even bool) Decimal {
+	if -d.exp <= places {
+		return d
+	}
+
+	divisor := pow10(int64(-d.exp) - int64(places))
+
+	return Decimal{value: quoRound(new(big.Int).Set(d.coefficient()), divisor, even), exp: -places}
+}
+
+// format returns d with the places digits after the point, d must have no more than places decimal places
+func (d Decimal) format(places int32) string {
+	v := d.rescale(-places)
+	if places <= 0 {
+		if d.exp > 0 {
+			v = d.rescale(0)
+		}
+
+		return v.String()
+	}
+
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+		v.Abs(v)
+	}
+
+	digits := v.String()
+	if len(digits) <= int(places) {
+		digits = strings.Repeat("0", int(places)-len(digits)+1) + digits
+	}
+
+	point := len(digits) - int(places)
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func align(d, d2 Decimal) (*big.Int, *big.Int, int32) {
+	exp := d.exp
+	if d2.exp < exp {
+		exp = d2.exp
+	}
+
+	return d.rescale(exp), d2.rescale(exp), exp
+}
+
+// quoRound returns num / den rounded half away from zero, or half to even if even is set
+func quoRound(num, den *big.Int, even bool) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+
+	c := half.CmpAbs(den)
+	if c < 0 || (c == 0 && even && quo.Bit(0) == 0) {
+		return quo
+	}
+
+	if rem.Sign() != den.Sign() {
+		return quo.Sub(quo, big.NewInt(1))
+	}
+
+	return quo.Add(quo, big.NewInt(1))
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(n), nil)
+}
@@ -0,0 +1,192 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewFromString(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{name: "test_integer", input: "131", expected: "131"},
+		{name: "test_fraction", input: "54.1609", expected: "54.1609"},
+		{name: "test_negative", input: "-0.00762660", expected: "-0.0076266"},
+		{name: "test_leading_point", input: ".5", expected: "0.5"},
+		{name: "test_exponent", input: "1.2e-7", expected: "0.00000012"},
+		{name: "test_positive_exponent", input: "+12E3", expected: "12000"},
+		{name: "test_empty", input: "", err: ErrSyntax},
+		{name: "test_comma", input: "54,1609", err: ErrSyntax},
+		{name: "test_text", input: "N/A", err: ErrSyntax},
+		{name: "test_bad_exponent", input: "1e", err: ErrSyntax},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := NewFromString(tc.input)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got: %v, want: %v", err, tc.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, d.String()); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		result   Decimal
+		expected string
+	}{
+		{
+			name:     "test_add",
+			result:   RequireFromString("0.1").Add(RequireFromString("0.2")),
+			expected: "0.3",
+		},
+		{
+			name:     "test_sub",
+			result:   RequireFromString("1.1898").Sub(RequireFromString("2")),
+			expected: "-0.8102",
+		},
+		{
+			name:     "test_mul",
+			result:   RequireFromString("72.9781").Mul(RequireFromString("10")),
+			expected: "729.781",
+		},
+		{
+			name:     "test_div",
+			result:   RequireFromString("131.12").Div(RequireFromString("1.1898"), 16),
+			expected: "110.203395528660279",
+		},
+		{
+			name:     "test_div_round_up",
+			result:   RequireFromString("2").Div(RequireFromString("3"), 4),
+			expected: "0.6667",
+		},
+		{
+			name:     "test_div_negative",
+			result:   RequireFromString("-2").Div(RequireFromString("3"), 4),
+			expected: "-0.6667",
+		},
+		{
+			name:     "test_div_exponent",
+			result:   New(1, 3).Div(New(4, -2), 2),
+			expected: "25000",
+		},
+		{
+			name:     "test_inv",
+			result:   RequireFromString("1.1898").Inv(8),
+			expected: "0.84047739",
+		},
+		{
+			name:     "test_zero_value",
+			result:   Decimal{}.Add(RequireFromString("1.5")),
+			expected: "1.5",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.expected, tc.result.String()); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecimal_Round(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		places   int32
+		expected string
+		bank     string
+	}{
+		{name: "test_half", input: "2.345", places: 2, expected: "2.35", bank: "2.34"},
+		{name: "test_half_odd", input: "2.355", places: 2, expected: "2.36", bank: "2.36"},
+		{name: "test_negative_half", input: "-2.345", places: 2, expected: "-2.35", bank: "-2.34"},
+		{name: "test_below_half", input: "729.7849", places: 2, expected: "729.78", bank: "729.78"},
+		{name: "test_zero_places", input: "109.5", places: 0, expected: "110", bank: "110"},
+		{name: "test_no_rounding", input: "1.5", places: 3, expected: "1.500", bank: "1.500"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := RequireFromString(tc.input)
+			if diff := cmp.Diff(tc.expected, d.StringFixed(tc.places)); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.bank, d.RoundBank(tc.places).StringFixed(tc.places)); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecimal_Float64(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range []float64{1.1898, 0.00686264, 110.20339552866028, 1e-9, 123456789} {
+		if diff := cmp.Diff(f, NewFromFloat(f).Float64()); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+	}
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	t.Parallel()
+
+	in := struct {
+		Rate Decimal `json:"rate"`
+	}{Rate: RequireFromString("0.664738")}
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json marshal: %v", err)
+	}
+
+	if diff := cmp.Diff(`{"rate":"0.664738"}`, string(b)); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	var out struct {
+		Rate Decimal `json:"rate"`
+	}
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("json unmarshal: %v", err)
+	}
+
+	if !out.Rate.Equal(in.Rate) {
+		t.Errorf("got: %s, want: %s", out.Rate, in.Rate)
+	}
+}
@@ -0,0 +1,155 @@
+package gokuu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/robotomize/gokuu/label"
+	snap "github.com/robotomize/gokuu/provider/snapshot"
+)
+
+var (
+	_ json.Marshaler   = ExchangeRate{}
+	_ json.Unmarshaler = (*ExchangeRate)(nil)
+)
+
+// MarshalJSON encodes the exchange rate as the rate of the snapshot, see snapshot.Rate
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.snapshotRate())
+}
+
+// UnmarshalJSON decodes the exchange rate encoded by MarshalJSON. The quotes are restored from the providers
+func (r *ExchangeRate) UnmarshalJSON(b []byte) error {
+	var s snap.Rate
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	rate, err := fromSnapshot(s)
+	if err != nil {
+		return err
+	}
+
+	*r = rate
+
+	return nil
+}
+
+// Snapshot returns the exchange rates of the response in the snapshot format
+func (e LatestResponse) Snapshot() snap.Snapshot {
+	s := snap.Snapshot{
+		FetchedAt: e.FetchedAt,
+		Rates:     make([]snap.Rate, len(e.Result)),
+	}
+
+	for i, r := range e.Result {
+		s.Rates[i] = r.snapshotRate()
+	}
+
+	return s
+}
+
+// Export writes the exchange rates of the response in the format. Register the snapshot source
+// from the package github.com/robotomize/gokuu/provider/snapshot to replay the exported rates
+func (e LatestResponse) Export(w io.Writer, format snap.Format) error {
+	if err := snap.Encode(w, e.Snapshot(), format); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	return nil
+}
+
+// Import reads the response written by Export in any of the formats. Every source of the exchange rates
+// is reported in Info with the latest publication date of its rates
+func Import(r io.Reader) (LatestResponse, error) {
+	s, err := snap.Decode(r)
+	if err != nil {
+		return LatestResponse{}, fmt.Errorf("import: %w", err)
+	}
+
+	resp := LatestResponse{
+		Expected:  make([]label.Symbol, 0),
+		Info:      make([]SourceInfo, 0),
+		Result:    make([]ExchangeRate, 0, len(s.Rates)),
+		FetchedAt: s.FetchedAt,
+	}
+
+	symbols := make(map[label.Symbol]struct{})
+	sources := make(map[string]int)
+	for _, sr := range s.Rates {
+		rate, err := fromSnapshot(sr)
+		if err != nil {
+			return LatestResponse{}, fmt.Errorf("import: %w", err)
+		}
+
+		resp.Result = append(resp.Result, rate)
+
+		for _, symbol := range []label.Symbol{sr.From, sr.To} {
+			if _, ok := symbols[symbol]; !ok {
+				symbols[symbol] = struct{}{}
+				resp.Expected = append(resp.Expected, symbol)
+			}
+		}
+
+		i, ok := sources[rate.source]
+		if !ok {
+			i = len(resp.Info)
+			sources[rate.source] = i
+			resp.Info = append(resp.Info, SourceInfo{Name: rate.source, Status: ProviderRespStatusOK})
+		}
+
+		if rate.time.After(resp.Info[i].PublishedAt) {
+			resp.Info[i].PublishedAt = rate.time
+		}
+	}
+
+	sort.Slice(resp.Expected, func(i, j int) bool {
+		return resp.Expected[i] < resp.Expected[j]
+	})
+
+	resp.Unreceived = unreceived(resp.Expected, resp.Result)
+
+	return resp, nil
+}
+
+func (r ExchangeRate) snapshotRate() snap.Rate {
+	return snap.Rate{
+		From:      r.from.Symbol,
+		To:        r.to.Symbol,
+		Rate:      r.rate,
+		Date:      r.time,
+		Source:    r.source,
+		Providers: r.Providers(),
+		Stale:     r.stale,
+	}
+}
+
+func fromSnapshot(s snap.Rate) (ExchangeRate, error) {
+	from, ok := label.Currencies[s.From]
+	if !ok {
+		return ExchangeRate{}, fmt.Errorf("%w: %s", ErrCurrencyNotFound, s.From)
+	}
+
+	to, ok := label.Currencies[s.To]
+	if !ok {
+		return ExchangeRate{}, fmt.Errorf("%w: %s", ErrCurrencyNotFound, s.To)
+	}
+
+	r := ExchangeRate{
+		time:   s.Date,
+		from:   from,
+		to:     to,
+		rate:   s.Rate,
+		source: s.Source,
+		stale:  s.Stale,
+		quotes: make([]Quote, len(s.Providers)),
+	}
+
+	for i, name := range s.Providers {
+		r.quotes[i] = Quote{Provider: name, Rate: s.Rate, Time: s.Date, Stale: s.Stale, Used: true}
+	}
+
+	return r, nil
+}
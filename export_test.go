@@ -0,0 +1,221 @@
+package gokuu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+	snap "github.com/robotomize/gokuu/provider/snapshot"
+)
+
+// exportedRate the exported fields of the exchange rate
+type exportedRate struct {
+	From      label.Symbol
+	To        label.Symbol
+	Rate      string
+	Date      time.Time
+	Providers []string
+	Stale     bool
+}
+
+func exportedRates(rates []ExchangeRate) []exportedRate {
+	list := make([]exportedRate, len(rates))
+	for i, r := range rates {
+		list[i] = exportedRate{
+			From:      r.From().Symbol,
+			To:        r.To().Symbol,
+			Rate:      r.Decimal().String(),
+			Date:      r.Time(),
+			Providers: r.Providers(),
+			Stale:     r.Stale(),
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].To < list[j].To
+	})
+
+	return list
+}
+
+func TestExchangeRate_JSON(t *testing.T) {
+	t.Parallel()
+
+	r := testRate(label.EUR, label.USD, "1.1593", 0)
+	r.time = time.Date(2021, 10, 5, 0, 0, 0, 0, time.UTC)
+	r.source = ProviderNameECB
+	r.strategy = MergeStrategyTypeAverage
+	r.stale = true
+	r.quotes = []Quote{
+		{Provider: ProviderNameECB, Rate: r.rate, Time: r.time, Used: true},
+		{Provider: ProviderNameCAE, Rate: r.rate, Time: r.time, Rejected: true},
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	expectedJSON := `{"from":"EUR","to":"USD","rate":"1.1593","date":"2021-10-05T00:00:00Z",` +
+		`"source":"ecb","providers":["ecb"],"stale":true}`
+	if diff := cmp.Diff(expectedJSON, string(b)); diff != "" {
+		t.Errorf("bad json (-want, +got): %s", diff)
+	}
+
+	var got ExchangeRate
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if diff := cmp.Diff(exportedRates([]ExchangeRate{r}), exportedRates([]ExchangeRate{got})); diff != "" {
+		t.Errorf("bad exchange rate (-want, +got): %s", diff)
+	}
+
+	if err := json.Unmarshal([]byte(`{"from":"ZZZ","to":"USD","rate":"1"}`), &got); err == nil {
+		t.Errorf("unknown currency unmarshalled")
+	}
+}
+
+func TestLatestResponse_Export(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	e := New(http.DefaultClient, WithRetryNum(0))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", newStaticSource(
+		testRate(label.EUR, label.USD, "1.1593", 0),
+		testRate(label.EUR, label.RUB, "84.2476", 0),
+	), 0)
+
+	resp := e.GetLatest(ctx)
+	if len(resp.Result) != 2 {
+		t.Fatalf("bad latest result: %v", resp.Result)
+	}
+
+	// the publication dates are kept as days by the gesmes document
+	days := make([]ExchangeRate, len(resp.Result))
+	for i, r := range resp.Result {
+		r.time = provider.Day(r.time)
+		r.quotes = nil
+		days[i] = r
+	}
+
+	testCases := []struct {
+		name     string
+		format   snap.Format
+		expected []exportedRate
+	}{
+		{
+			name:     "test_json",
+			format:   snap.FormatJSON,
+			expected: exportedRates(resp.Result),
+		},
+		{
+			name:     "test_csv",
+			format:   snap.FormatCSV,
+			expected: exportedRates(resp.Result),
+		},
+		{
+			name:     "test_xml",
+			format:   snap.FormatXML,
+			expected: exportedRates(days),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := resp.Export(&buf, tc.format); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+
+			path := filepath.Join(t.TempDir(), "snapshot")
+			if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			imported, err := Import(&buf)
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, exportedRates(imported.Result)); diff != "" {
+				t.Errorf("bad imported rates (-want, +got): %s", diff)
+			}
+
+			// the snapshot is replayed as a provider
+			source, err := snap.Open(path)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+
+			replay := New(http.DefaultClient, WithRetryNum(0))
+			replay.providers = make([]*Provider, 0)
+			replay.Register("test_source", source, 0)
+
+			replayed := replay.GetLatest(ctx)
+
+			expected := make([]exportedRate, len(tc.expected))
+			for i, r := range tc.expected {
+				r.Providers = []string{"test_source"}
+				r.Stale = false
+				expected[i] = r
+			}
+
+			if diff := cmp.Diff(expected, exportedRates(replayed.Result)); diff != "" {
+				t.Errorf("bad replayed rates (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestLatestResponse_ExportXMLCrossRates(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// the rates from RUB as the Central Bank of Russia publishes them
+	e := New(http.DefaultClient, WithRetryNum(0))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", newStaticSource(
+		testRate(label.RUB, label.EUR, "0.0125", 0),
+		testRate(label.RUB, label.USD, "0.0145", 0),
+	), 0)
+
+	resp := e.GetLatest(ctx)
+	if len(resp.Result) != 2 {
+		t.Fatalf("bad latest result: %v", resp.Result)
+	}
+
+	var buf bytes.Buffer
+	if err := resp.Export(&buf, snap.FormatXML); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	imported, err := Import(&buf)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	day := provider.Day(resp.Result[0].Time())
+	expected := []exportedRate{
+		{From: label.EUR, To: label.RUB, Rate: "80", Date: day, Providers: []string{}},
+		{From: label.EUR, To: label.USD, Rate: "1.16", Date: day, Providers: []string{}},
+	}
+	if diff := cmp.Diff(expected, exportedRates(imported.Result)); diff != "" {
+		t.Errorf("bad imported rates (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,186 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robotomize/gokuu/internal/logging"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/store"
+)
+
+// WithFallback serve the last known good exchange rates of the pairs the failed providers did not return,
+// as long as they were fetched no more than maxAge ago. The fallback rates are flagged stale, see
+// ExchangeRate.Fallback. The rates fetched by the exchanger are kept in memory, the older ones are read from
+// the store, see WithStore
+func WithFallback(maxAge time.Duration) Option {
+	return func(e *exchanger) {
+		e.opts.FallbackMaxAge = maxAge
+	}
+}
+
+// goodRate the merged exchange rate and the time it was fetched
+type goodRate struct {
+	rate      ExchangeRate
+	fetchedAt time.Time
+}
+
+// lastGood the latest merged exchange rates by currency pair
+type lastGood struct {
+	mtx   sync.RWMutex
+	rates map[pair]goodRate
+}
+
+// remember keeps the exchange rates unless newer ones were fetched
+func (g *lastGood) remember(rates []ExchangeRate, fetchedAt time.Time) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.rates == nil {
+		g.rates = make(map[pair]goodRate, len(rates))
+	}
+
+	for _, r := range rates {
+		key := pair{from: r.from.Symbol, to: r.to.Symbol}
+		if cur, ok := g.rates[key]; ok && cur.fetchedAt.After(fetchedAt) {
+			continue
+		}
+
+		g.rates[key] = goodRate{rate: r, fetchedAt: fetchedAt}
+	}
+}
+
+func (g *lastGood) load() map[pair]goodRate {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+
+	rates := make(map[pair]goodRate, len(g.rates))
+	for key, r := range g.rates {
+		rates[key] = r
+	}
+
+	return rates
+}
+
+// fallback remembers the fetched exchange rates and adds the last known good ones for the pairs missing
+// in the response if any provider failed
+func (e *exchanger) fallback(ctx context.Context, resp LatestResponse) LatestResponse {
+	if e.opts.FallbackMaxAge <= 0 {
+		return resp
+	}
+
+	e.good.remember(resp.Result, resp.FetchedAt)
+
+	if !failed(resp.Info) {
+		return resp
+	}
+
+	candidates := e.good.load()
+	for key, r := range e.storedGood(ctx, resp.FetchedAt) {
+		if cur, ok := candidates[key]; !ok || r.fetchedAt.After(cur.fetchedAt) {
+			candidates[key] = r
+		}
+	}
+
+	expected := make(map[label.Symbol]struct{}, len(resp.Expected))
+	for _, symbol := range resp.Expected {
+		expected[symbol] = struct{}{}
+	}
+
+	received := make(map[pair]struct{}, len(resp.Result))
+	for _, r := range resp.Result {
+		received[pair{from: r.from.Symbol, to: r.to.Symbol}] = struct{}{}
+	}
+
+	keys := make([]pair, 0)
+	for key, r := range candidates {
+		if _, ok := received[key]; ok {
+			continue
+		}
+
+		// the pairs of the removed providers are not served
+		_, fromOK := expected[key.from]
+		_, toOK := expected[key.to]
+		if !fromOK || !toOK || resp.FetchedAt.Sub(r.fetchedAt) > e.opts.FallbackMaxAge {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return resp
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+
+		return keys[i].to < keys[j].to
+	})
+
+	for _, key := range keys {
+		good := candidates[key]
+
+		r := good.rate
+		r.stale = true
+		r.fallback = true
+		r.age = resp.FetchedAt.Sub(good.fetchedAt)
+
+		resp.Result = append(resp.Result, r)
+	}
+
+	resp.Unreceived = unreceived(resp.Expected, resp.Result)
+
+	return resp
+}
+
+// storedGood returns the stored exchange rates in effect at the time by pair
+func (e *exchanger) storedGood(ctx context.Context, at time.Time) map[pair]goodRate {
+	if e.store == nil {
+		return nil
+	}
+
+	rates, err := e.store.On(ctx, at)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			logging.FromContext(ctx).Printf("gokuu: read stored exchange rates: %v", err)
+		}
+
+		return nil
+	}
+
+	merged, _ := e.merge(fromStore(rates))
+
+	good := make(map[pair]goodRate, len(merged))
+	for _, r := range merged {
+		// the stored rate keeps the time of its first fetch, the age counts from the last fetch of the pair
+		fetchedAt, err := e.store.FetchedAt(ctx, r.from.Symbol, r.to.Symbol)
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				logging.FromContext(ctx).Printf("gokuu: read fetch time of stored exchange rate: %v", err)
+			}
+
+			continue
+		}
+
+		good[pair{from: r.from.Symbol, to: r.to.Symbol}] = goodRate{rate: r, fetchedAt: fetchedAt}
+	}
+
+	return good
+}
+
+// failed reports whether any provider failed to return the exchange rates
+func failed(info []SourceInfo) bool {
+	for _, s := range info {
+		if s.Status == ProviderRespStatusFailed {
+			return true
+		}
+	}
+
+	return false
+}
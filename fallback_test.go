@@ -0,0 +1,198 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/store"
+)
+
+// testClock the clock moved by the test
+type testClock struct {
+	mtx sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) add(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestExchanger_Fallback(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+	fetchedAt := time.Date(2021, 10, 5, 16, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name string
+		opts []Option
+		// stored the rates saved to the store before the exchanger started
+		stored []store.Rate
+		// storedAgo the stored rates were first saved this long before and saved again unchanged
+		storedAgo time.Duration
+		// fetch the latest rates are fetched successfully before the provider fails
+		fetch           bool
+		after           time.Duration
+		expectedAge     time.Duration
+		expectedSource  string
+		expectedResults int
+	}{
+		{
+			name:  "test_disabled",
+			fetch: true,
+			after: time.Minute,
+		},
+		{
+			name:            "test_last_fetched",
+			opts:            []Option{WithFallback(time.Hour)},
+			fetch:           true,
+			after:           30 * time.Minute,
+			expectedAge:     30 * time.Minute,
+			expectedSource:  "test_source",
+			expectedResults: 1,
+		},
+		{
+			name:  "test_too_old",
+			opts:  []Option{WithFallback(time.Hour)},
+			fetch: true,
+			after: 2 * time.Hour,
+		},
+		{
+			name: "test_stored",
+			opts: []Option{WithFallback(time.Hour)},
+			stored: []store.Rate{
+				{
+					Date: fetchedAt,
+					From: label.USD,
+					To:   label.RUB,
+					Rate: decimal.RequireFromString("72.9781"),
+				},
+			},
+			after:           45 * time.Minute,
+			expectedAge:     45 * time.Minute,
+			expectedSource:  ProviderNameStore,
+			expectedResults: 1,
+		},
+		{
+			name: "test_stored_refetched",
+			opts: []Option{WithFallback(time.Hour)},
+			stored: []store.Rate{
+				{
+					Date: fetchedAt,
+					From: label.USD,
+					To:   label.RUB,
+					Rate: decimal.RequireFromString("72.9781"),
+				},
+			},
+			storedAgo:       3 * time.Hour,
+			after:           45 * time.Minute,
+			expectedAge:     45 * time.Minute,
+			expectedSource:  ProviderNameStore,
+			expectedResults: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			clock := &testClock{now: fetchedAt}
+
+			s := store.NewMemoryStore()
+			if tc.storedAgo > 0 {
+				snapshot := store.Snapshot{FetchedAt: fetchedAt.Add(-tc.storedAgo), Rates: tc.stored}
+				if err := s.Save(ctx, snapshot); err != nil {
+					t.Fatalf("save: %v", err)
+				}
+			}
+
+			if err := s.Save(ctx, store.Snapshot{FetchedAt: fetchedAt, Rates: tc.stored}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			opts := append([]Option{WithRetryNum(0), WithClock(clock.Now)}, tc.opts...)
+			if tc.stored != nil {
+				opts = append(opts, WithStore(s))
+			}
+
+			source := &flakySource{}
+
+			e := New(http.DefaultClient, opts...)
+			e.providers = make([]*Provider, 0)
+			e.Register("test_source", source, 0)
+
+			if tc.fetch {
+				if resp := e.GetLatest(ctx); len(resp.Result) != 1 {
+					t.Fatalf("bad latest result: %v", resp.Result)
+				}
+			}
+
+			source.set(errUnavailable)
+			clock.add(tc.after)
+
+			resp := e.GetLatest(ctx)
+			if diff := cmp.Diff(tc.expectedResults, len(resp.Result)); diff != "" {
+				t.Fatalf("bad results (-want, +got): %s", diff)
+			}
+
+			if tc.expectedResults == 0 {
+				if _, err := e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: 1}); err == nil {
+					t.Errorf("converted without exchange rates")
+				}
+
+				return
+			}
+
+			r := resp.Result[0]
+
+			age, ok := r.Fallback()
+			if !ok {
+				t.Fatalf("exchange rate is not fallback")
+			}
+
+			if diff := cmp.Diff(tc.expectedAge, age); diff != "" {
+				t.Errorf("bad age (-want, +got): %s", diff)
+			}
+
+			if !r.Stale() {
+				t.Errorf("fallback exchange rate is not stale")
+			}
+
+			if diff := cmp.Diff([]string{tc.expectedSource}, r.Providers()); diff != "" {
+				t.Errorf("bad providers (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff([]label.Symbol{label.RUB}, resp.Unreceived); diff != "" {
+				t.Errorf("bad unreceived (-want, +got): %s", diff)
+			}
+
+			conv, err := e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: 2})
+			if err != nil {
+				t.Fatalf("convert: %v", err)
+			}
+
+			if diff := cmp.Diff("72.9781", conv.Rate.String()); diff != "" {
+				t.Errorf("bad conversion rate (-want, +got): %s", diff)
+			}
+		})
+	}
+}
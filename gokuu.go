@@ -4,22 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"sort"
+	"net/url"
 	"sync"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/internal/singleflight"
 	"github.com/robotomize/gokuu/label"
 	"github.com/robotomize/gokuu/provider"
 	"github.com/robotomize/gokuu/provider/cae"
 	"github.com/robotomize/gokuu/provider/ecb"
+	"github.com/robotomize/gokuu/provider/httputil"
 	"github.com/robotomize/gokuu/provider/rcb"
+	"github.com/robotomize/gokuu/store"
 	"github.com/sethvargo/go-retry"
 )
 
 var (
-	ErrConversionRate   = errors.New("can not convert")
-	ErrCurrencyNotFound = errors.New("currency symbol is not supported")
+	ErrConversionRate         = errors.New("can not convert")
+	ErrCurrencyNotFound       = errors.New("currency symbol is not supported")
+	ErrHistoricalNotSupported = errors.New("provider does not support historical exchange rates")
+	ErrInvalidValue           = errors.New("invalid conversion value")
 )
 
 const (
@@ -37,10 +44,14 @@ const (
 	ProviderNameCAE = "cae"
 )
 
+var _ Exchanger = (*exchanger)(nil)
+
+// Exchanger provides the merged exchange rates of all providers
 type Exchanger interface {
 	GetExchangeable() []label.Symbol
-	GetLatest() LatestResponse
-	Convert(ctx context.Context, from, to label.Symbol, value float64) (ConversionResponse, error)
+	GetLatest(ctx context.Context) LatestResponse
+	GetOn(ctx context.Context, date time.Time) LatestResponse
+	Convert(ctx context.Context, param ConvOpt) (ConversionResponse, error)
 }
 
 type Option func(*exchanger)
@@ -50,6 +61,23 @@ type Options struct {
 	RetryDuration  time.Duration
 	RequestTimeout time.Duration
 	MergeStrategy  MergeStrategyType
+	// Weights of the providers by name for the weighted merge strategy
+	Weights map[string]float64
+	// MaxDeviation of a quote from the consensus in percent, zero disables the filter
+	MaxDeviation float64
+	// MaxRateAge of the exchange rates from their publication date, zero disables the check
+	MaxRateAge  time.Duration
+	StalePolicy StalePolicy
+	// PreferFreshest the race and priority strategies choose from the quotes published on the latest date
+	PreferFreshest bool
+	// BreakerThreshold the number of consecutive failed fetches that open the circuit breaker, zero disables it
+	BreakerThreshold int
+	// BreakerCoolDown the time the provider is skipped for after the circuit breaker opened
+	BreakerCoolDown time.Duration
+	// FallbackMaxAge the max age of the last known good exchange rates served when the providers fail
+	FallbackMaxAge time.Duration
+	// DivisionPrecision the number of decimal places of the division results
+	DivisionPrecision int32
 }
 
 type LatestResponse struct {
@@ -57,12 +85,28 @@ type LatestResponse struct {
 	Unreceived []label.Symbol
 	Info       []SourceInfo
 	Result     []ExchangeRate
+	// Decisions how the exchange rates quoted by several providers were merged
+	Decisions []MergeDecision
+	// FetchedAt time when the exchange rates were fetched from the providers
+	FetchedAt time.Time
+
+	// now the clock of the exchanger that fetched the exchange rates
+	now func() time.Time
 }
 
 func (e LatestResponse) Verify() bool {
 	return len(e.Expected) == len(e.Result)
 }
 
+// Age returns the time elapsed since the exchange rates were fetched by the clock of the exchanger
+func (e LatestResponse) Age() time.Duration {
+	if e.now == nil {
+		return time.Since(e.FetchedAt)
+	}
+
+	return e.now().Sub(e.FetchedAt)
+}
+
 type ProviderRespStatus byte
 
 const (
@@ -74,6 +118,12 @@ type SourceInfo struct {
 	Name         string
 	Status       ProviderRespStatus
 	ErrorMessage string
+	// PublishedAt the latest publication date of the exchange rates of the source
+	PublishedAt time.Time
+	// Stale the latest exchange rates of the source are older than the max rate age
+	Stale bool
+	// Circuit the state of the circuit breaker of the source after the fetch, see WithCircuitBreaker
+	Circuit CircuitState
 }
 
 type MergeStrategyType string
@@ -82,18 +132,14 @@ const (
 	MergeStrategyTypeRace     MergeStrategyType = "race"
 	MergeStrategyTypeAverage  MergeStrategyType = "average"
 	MergeStrategyTypePriority MergeStrategyType = "priority"
+	MergeStrategyTypeMedian   MergeStrategyType = "median"
+	MergeStrategyTypeWeighted MergeStrategyType = "weighted"
+	// MergeStrategyTypeCustom the rates were merged by the function set with WithMergeFunc
+	MergeStrategyTypeCustom MergeStrategyType = "custom"
 )
 
-type Prior int32
-
-type Provider struct {
-	name  string
-	prior Prior
-	provider.Source
-}
-
-// WithAverageMergeStrategy use the merge strategy to calculate the average Value of exchange rates
-// with a large number of providers
+// WithAverageMergeStrategy use the merge strategy to calculate the arithmetic mean of exchange rates
+// quoted by all providers
 func WithAverageMergeStrategy() Option {
 	return func(g *exchanger) {
 		g.opts.MergeStrategy = MergeStrategyTypeAverage
@@ -115,13 +161,65 @@ func WithPriorityMergeStrategy() Option {
 	}
 }
 
-// WithMergeFunc set the custom currency merge function
+// WithMedianMergeStrategy use the median of exchange rates quoted by all providers
+func WithMedianMergeStrategy() Option {
+	return func(g *exchanger) {
+		g.opts.MergeStrategy = MergeStrategyTypeMedian
+	}
+}
+
+// WithWeightedMergeStrategy use the weighted mean of exchange rates quoted by all providers.
+// The weights are set by provider name, providers without a weight weigh 1.
+// It panics if a weight is negative, NaN or infinite
+func WithWeightedMergeStrategy(weights map[string]float64) Option {
+	for name, w := range weights {
+		if w < 0 || !finite(w) {
+			panic(fmt.Sprintf("gokuu: invalid weight %v of provider %s", w, name))
+		}
+	}
+
+	return func(g *exchanger) {
+		g.opts.MergeStrategy = MergeStrategyTypeWeighted
+		g.opts.Weights = weights
+	}
+}
+
+// WithMaxDeviation drop the quotes that differ from the median of all quotes of the currency pair by more than
+// percent. The filter needs at least three quotes to reach a consensus, zero disables it.
+// It panics if percent is negative, NaN or infinite
+func WithMaxDeviation(percent float64) Option {
+	if percent < 0 || !finite(percent) {
+		panic(fmt.Sprintf("gokuu: invalid max deviation %v", percent))
+	}
+
+	return func(g *exchanger) {
+		g.opts.MaxDeviation = percent
+	}
+}
+
+// WithMergeFunc set the custom currency merge function. It replaces the merge strategy
 func WithMergeFunc(f MergeFunc) Option {
 	return func(g *exchanger) {
 		g.merger = f
 	}
 }
 
+// WithDivisionPrecision set the number of decimal places of the division results: the cross rates of the built-in
+// providers, the merged means, the deviations and the changes of the exchange rates. decimal.DivisionPrecision
+// by default. It panics if places is negative
+func WithDivisionPrecision(places int32) Option {
+	if places < 0 {
+		panic(fmt.Sprintf("gokuu: negative division precision %d", places))
+	}
+
+	return func(e *exchanger) {
+		e.opts.DivisionPrecision = places
+		e.sourceOpts.ecb = append(e.sourceOpts.ecb, ecb.WithDivisionPrecision(places))
+		e.sourceOpts.rcb = append(e.sourceOpts.rcb, rcb.WithDivisionPrecision(places))
+		e.sourceOpts.cae = append(e.sourceOpts.cae, cae.WithDivisionPrecision(places))
+	}
+}
+
 // WithRetryNum set number of repeated requests for data retrieval errors from the source
 func WithRetryNum(n uint64) Option {
 	return func(e *exchanger) {
@@ -136,6 +234,37 @@ func WithRetryDuration(t time.Duration) Option {
 	}
 }
 
+// WithResponseCache send the conditional requests from the built-in providers and keep the responses in the cache,
+// e.g. httputil.NewMemoryCache or httputil.NewDiskCache
+func WithResponseCache(cache httputil.ResponseCache) Option {
+	return func(e *exchanger) {
+		e.sourceOpts.ecb = append(e.sourceOpts.ecb, ecb.WithResponseCache(cache))
+		e.sourceOpts.rcb = append(e.sourceOpts.rcb, rcb.WithResponseCache(cache))
+		e.sourceOpts.cae = append(e.sourceOpts.cae, cae.WithResponseCache(cache))
+	}
+}
+
+// WithECBBaseURL request the resources of the European Central Bank from the mirror or the caching proxy
+func WithECBBaseURL(u url.URL) Option {
+	return func(e *exchanger) {
+		e.sourceOpts.ecb = append(e.sourceOpts.ecb, ecb.WithBaseURL(u))
+	}
+}
+
+// WithRCBEndpoint request the exchange rates of the Central Bank of Russia from the endpoint
+func WithRCBEndpoint(u url.URL) Option {
+	return func(e *exchanger) {
+		e.sourceOpts.rcb = append(e.sourceOpts.rcb, rcb.WithEndpoint(u))
+	}
+}
+
+// WithCAEEndpoint request the exchange rates of the Central Bank of the UAE from the endpoint
+func WithCAEEndpoint(u url.URL) Option {
+	return func(e *exchanger) {
+		e.sourceOpts.cae = append(e.sourceOpts.cae, cae.WithEndpoint(u))
+	}
+}
+
 // WithRequestTimeout set a timeout for source requests
 func WithRequestTimeout(t time.Duration) Option {
 	return func(e *exchanger) {
@@ -147,37 +276,50 @@ func WithRequestTimeout(t time.Duration) Option {
 func New(client *http.Client, opts ...Option) *exchanger {
 	e := &exchanger{
 		opts: Options{
-			RetryNum:       DefaultRetryNum,
-			RetryDuration:  DefaultRetryDuration,
-			RequestTimeout: DefaultRequestTimeout,
-			MergeStrategy:  MergeStrategyTypeRace,
-		},
-		providers: []*Provider{
-			{
-				name:   ProviderNameECB,
-				prior:  0,
-				Source: ecb.NewSource(client),
-			},
-			{
-				name:   ProviderNameRCB,
-				prior:  1,
-				Source: rcb.NewSource(client),
-			},
-			{
-				name:   ProviderNameCAE,
-				prior:  2,
-				Source: cae.NewSource(client),
-			},
+			RetryNum:          DefaultRetryNum,
+			RetryDuration:     DefaultRetryDuration,
+			RequestTimeout:    DefaultRequestTimeout,
+			MergeStrategy:     MergeStrategyTypeRace,
+			DivisionPrecision: decimal.DivisionPrecision,
 		},
+		now:        time.Now,
+		subs:       newHub(),
+		pivots:     DefaultPivots,
+		maxPathLen: DefaultMaxPathLen,
 	}
 
 	for _, opt := range opts {
 		opt(e)
 	}
 
-	sort.Slice(e.providers, func(i, j int) bool {
-		return e.providers[i].prior > e.providers[j].prior
-	})
+	e.providers = []*Provider{
+		{
+			name:    ProviderNameECB,
+			policy:  e.newFetchPolicy(ProviderNameECB),
+			breaker: &breaker{},
+			flight:  &singleflight.Group{},
+			prior:   0,
+			Source:  ecb.NewSource(client, e.sourceOpts.ecb...),
+		},
+		{
+			name:    ProviderNameRCB,
+			policy:  e.newFetchPolicy(ProviderNameRCB),
+			breaker: &breaker{},
+			flight:  &singleflight.Group{},
+			prior:   1,
+			Source:  rcb.NewSource(client, e.sourceOpts.rcb...),
+		},
+		{
+			name:    ProviderNameCAE,
+			policy:  e.newFetchPolicy(ProviderNameCAE),
+			breaker: &breaker{},
+			flight:  &singleflight.Group{},
+			prior:   2,
+			Source:  cae.NewSource(client, e.sourceOpts.cae...),
+		},
+	}
+
+	sortProviders(e.providers)
 
 	return e
 }
@@ -188,20 +330,73 @@ type exchanger struct {
 	mtx          sync.RWMutex
 	providers    []*Provider
 	exchangeable []label.Symbol
-	merger       MergeFunc
+	version      uint64
+	// sourcesVersion the sum of the versions of the changing sources the exchangeable currencies were read at
+	sourcesVersion uint64
+	merger         MergeFunc
+	pivots         []label.Symbol
+	maxPathLen     int
+
+	// providerOpts the fetch options of the providers by name, see WithProviderOptions
+	providerOpts map[string][]ProviderOption
+
+	// flight shares the fetches between the concurrent callers
+	flight singleflight.Group
+
+	cache *cache
+	store store.Store
+	now   func() time.Time
+	subs  *hub
+
+	// good the last known good exchange rates, see WithFallback
+	good lastGood
+
+	// sourceOpts options of the built-in providers
+	sourceOpts struct {
+		ecb []ecb.Option
+		rcb []rcb.Option
+		cae []cae.Option
+	}
 }
 
 type FetchFunc func(ctx context.Context) LatestResponse
 
 type ConvOpt struct {
-	From    label.Symbol
-	To      label.Symbol
-	Value   float64
+	From  label.Symbol
+	To    label.Symbol
+	Value float64
+	// DecimalValue the exact value to convert, takes precedence over Value if set
+	DecimalValue *decimal.Decimal
+	// Date of the exchange rate. If set, the conversion uses the rates in effect on that date
+	Date    time.Time
 	CacheFn FetchFunc
 }
 
+// value returns the value to convert, the float value must be finite
+func (o ConvOpt) value() (decimal.Decimal, error) {
+	if o.DecimalValue != nil {
+		return *o.DecimalValue, nil
+	}
+
+	if !finite(o.Value) {
+		return decimal.Decimal{}, fmt.Errorf("%w: %v", ErrInvalidValue, o.Value)
+	}
+
+	return decimal.NewFromFloat(o.Value), nil
+}
+
+// finite reports whether f is neither NaN nor an infinity
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
 // Convert returns an object with currency conversion data.
-// The CacheFn option allows you to define your own data delivery function for caching
+// The CacheFn option allows you to define your own data delivery function for caching.
+// With WithCache or WithAutoRefresh the latest rates are taken from the built-in cache.
+// Set the Date option to convert at the exchange rate of a past date.
+// If there is no direct exchange rate, the value is converted through the pivot currencies, see WithPivots.
+// The amount is rounded half away from zero to the minor units of the target currency.
+// A Value of NaN or infinity fails with ErrInvalidValue
 //
 //	ctx := context.Background()
 //	g := gokuu.New()
@@ -219,10 +414,14 @@ type ConvOpt struct {
 func (e *exchanger) Convert(ctx context.Context, param ConvOpt) (ConversionResponse, error) {
 	var resp ConversionResponse
 
-	e.verifyExchangeable()
+	value, err := param.value()
+	if err != nil {
+		return resp, err
+	}
 
-	e.mtx.RLock()
-	defer e.mtx.RUnlock()
+	if e.cache != nil && param.CacheFn == nil && param.Date.IsZero() {
+		return e.convertCached(ctx, param, value)
+	}
 
 	fromCurrency, ok := label.Currencies[param.From]
 	if !ok {
@@ -234,7 +433,9 @@ func (e *exchanger) Convert(ctx context.Context, param ConvOpt) (ConversionRespo
 		return resp, ErrCurrencyNotFound
 	}
 
-	res := e.isExchangeable(param.From, param.To)
+	state := e.state()
+
+	res := state.isExchangeable(param.From, param.To)
 	if !res.from {
 		return resp, fmt.Errorf("%w: %s", ErrCurrencyNotFound, param.From)
 	}
@@ -244,48 +445,66 @@ func (e *exchanger) Convert(ctx context.Context, param ConvOpt) (ConversionRespo
 	}
 
 	if param.CacheFn == nil {
-		param.CacheFn = e.getLatest
+		param.CacheFn = func(ctx context.Context) LatestResponse {
+			return e.getLatest(ctx, state)
+		}
+
+		if !param.Date.IsZero() {
+			param.CacheFn = func(ctx context.Context) LatestResponse {
+				return e.getOn(ctx, state, param.Date)
+			}
+		}
 	}
 
 	latest := param.CacheFn(ctx)
 
-	var r *ExchangeRate
+	path := newRateIndex(latest.Result).path(param.From, param.To, e.pivots, e.maxPathLen)
 
-	for _, rate := range latest.Result {
-		rate := rate
-		if rate.from.Symbol == param.From && rate.to.Symbol == param.To {
-			r = &rate
-			break
-		}
-	}
+	return conversion(value, fromCurrency, toCurrency, path, latest.Info)
+}
 
-	if r == nil {
+// conversion converts the value with the exchange rates of the path, ErrConversionRate if there is no path
+func conversion(
+	value decimal.Decimal, from, to label.Currency, path []ExchangeRate, info []SourceInfo,
+) (ConversionResponse, error) {
+	if len(path) == 0 {
 		return ConversionResponse{
-			Value: param.Value,
-			From:  fromCurrency,
-			To:    toCurrency,
-			Info:  latest.Info,
+			Value: value,
+			From:  from,
+			To:    to,
+			Info:  info,
 		}, ErrConversionRate
 	}
 
+	r := pathRate(path)
+
 	return ConversionResponse{
-		Value:  param.Value,
+		Date:   r.time,
+		Value:  value,
 		From:   r.from,
 		To:     r.to,
 		Rate:   r.rate,
-		Amount: r.rate * param.Value,
-		Info:   latest.Info,
+		Amount: value.Mul(r.rate).Round(int32(r.to.MinRateUnits)),
+		Path:   pathSymbols(path),
+		Rates:  path,
+		Info:   info,
 	}, nil
 }
 
-// GetLatest returns the current exchange rate for multiple currencies
+// GetLatest returns the current exchange rate for multiple currencies.
+// With WithCache or WithAutoRefresh the rates are served from the built-in cache
 func (e *exchanger) GetLatest(ctx context.Context) LatestResponse {
-	e.verifyExchangeable()
+	if e.cache != nil {
+		return e.cached(ctx).resp
+	}
 
-	e.mtx.RLock()
-	defer e.mtx.RUnlock()
+	return e.getLatest(ctx, e.state())
+}
 
-	return e.getLatest(ctx)
+// GetOn returns the exchange rates in effect on the date for multiple currencies.
+// Only providers that implement provider.HistoricalSource take part, the rest are reported as failed in Info
+func (e *exchanger) GetOn(ctx context.Context, date time.Time) LatestResponse {
+	return e.getOn(ctx, e.state(), date)
 }
 
 // GetExchangeable returns a list of all available exchange rates in gokuu
@@ -296,69 +515,29 @@ func (e *exchanger) GetExchangeable() []label.Symbol {
 	e.mtx.RLock()
 	defer e.mtx.RUnlock()
 
-	return e.exchangeable
-}
-
-// Delete providers by name
-func (e *exchanger) Delete(names ...string) {
-	for _, name := range names {
-		for idx, source := range e.providers {
-			if source.name == name {
-				source = nil
-				e.providers = append(e.providers[:idx], e.providers[idx+1:]...)
-				break
-			}
-		}
-	}
-}
-
-// ChangePrior change provider priority
-func (e *exchanger) ChangePrior(name string, prior Prior) {
-	e.mtx.Lock()
-	defer e.mtx.Unlock()
-
-	for _, p := range e.providers {
-		if p.name == name {
-			p.prior = prior
-		}
-	}
+	exchangeable := make([]label.Symbol, len(e.exchangeable))
+	copy(exchangeable, e.exchangeable)
 
-	sort.Slice(e.providers, func(i, j int) bool {
-		return e.providers[i].prior > e.providers[j].prior
-	})
-}
-
-// Register allows you to add your own provider of exchange rate data
-func (e *exchanger) Register(name string, source provider.Source, prior Prior) {
-	e.mtx.Lock()
-	defer e.mtx.Unlock()
-
-	e.providers = append(e.providers, &Provider{
-		name:   name,
-		Source: source,
-		prior:  prior,
-	})
-
-	sort.Slice(e.providers, func(i, j int) bool {
-		return e.providers[i].prior > e.providers[j].prior
-	})
-
-	e.updateExchangeable()
+	return exchangeable
 }
 
 type ConversionResponse struct {
 	Date   time.Time
-	Value  float64
+	Value  decimal.Decimal
 	From   label.Currency
 	To     label.Currency
-	Rate   float64
-	Amount float64
-	Info   []SourceInfo
+	Rate   decimal.Decimal
+	Amount decimal.Decimal
+	// Path currencies through which the value was converted, from the source to the target
+	Path []label.Symbol
+	// Rates exchange rates along the path with the quotes of the providers they came from
+	Rates []ExchangeRate
+	Info  []SourceInfo
 }
 
 func (e ConversionResponse) String() string {
 	return fmt.Sprintf(
-		"Value: %f, From: %s, To: %s, Rate: %f, Amount: %f",
+		"Value: %s, From: %s, To: %s, Rate: %s, Amount: %s",
 		e.Value,
 		e.From.Symbol,
 		e.To.Symbol,
@@ -367,14 +546,22 @@ func (e ConversionResponse) String() string {
 	)
 }
 
-var _ provider.ExchangeRate = (*ExchangeRate)(nil)
+var _ provider.DecimalExchangeRate = (*ExchangeRate)(nil)
 
 type ExchangeRate struct {
 	priority Prior
 	time     time.Time
 	from     label.Currency
 	to       label.Currency
-	rate     float64
+	rate     decimal.Decimal
+	source   string
+	strategy MergeStrategyType
+	quotes   []Quote
+	stale    bool
+	// fallback the last known good exchange rate served instead of the failed fetch, see WithFallback
+	fallback bool
+	// age the time elapsed since the fallback exchange rate was fetched
+	age time.Duration
 }
 
 func (r ExchangeRate) Time() time.Time {
@@ -389,84 +576,54 @@ func (r ExchangeRate) To() label.Currency {
 	return r.to
 }
 
+// Rate returns the nearest float64 value of the exchange rate
 func (r ExchangeRate) Rate() float64 {
-	return r.rate
+	return r.rate.Float64()
 }
 
-type MergeFunc func(*BatchExchanges, []ExchangeRate)
-
-func mergerFor(strategy MergeStrategyType) MergeFunc {
-	var f MergeFunc
-	switch strategy {
-	case MergeStrategyTypeRace:
-		f = mergeRaceFunc()
-	case MergeStrategyTypeAverage:
-		f = mergeAverageFunc()
-	case MergeStrategyTypePriority:
-		f = mergePriorFunc()
-	default:
-		f = mergeRaceFunc()
-	}
-
-	return f
+// Decimal returns the exact exchange rate
+func (r ExchangeRate) Decimal() decimal.Decimal {
+	return r.rate
 }
 
-func mergeRaceFunc() MergeFunc {
-	return func(batch *BatchExchanges, rates []ExchangeRate) {
-		batch.walk(rates, func(curr, next ExchangeRate) (ExchangeRate, error) {
-			if curr.from.Symbol != "" {
-				return curr, nil
-			}
-
-			if next.to.Symbol != "" {
-				return next, nil
-			}
-
-			return ExchangeRate{}, errors.New("d1 and d2 equals nil")
-		})
-	}
+// Stale reports whether the exchange rate is older than the max rate age, see WithMaxRateAge
+func (r ExchangeRate) Stale() bool {
+	return r.stale
 }
 
-// mergeAverageFunc calculates the average of two exchange rates from different suppliers
-func mergeAverageFunc() MergeFunc {
-	return func(batch *BatchExchanges, rates []ExchangeRate) {
-		batch.walk(rates, func(curr, next ExchangeRate) (ExchangeRate, error) {
-			if curr.from.Symbol == "" || next.to.Symbol == "" {
-				return ExchangeRate{}, errors.New("d1 or d2 equals nil")
-			}
+// Fallback returns the age of the last known good exchange rate served because the providers failed to return
+// the pair. False if the exchange rate was fetched by the request, see WithFallback
+func (r ExchangeRate) Fallback() (time.Duration, bool) {
+	return r.age, r.fallback
+}
 
-			return ExchangeRate{
-				priority: curr.priority,
-				time:     curr.time,
-				from:     curr.from,
-				to:       curr.to,
-				rate:     (curr.rate + next.rate) / 2,
-			}, nil
-		})
-	}
+// Strategy returns the merge strategy that combined the quotes of the providers into the exchange rate
+func (r ExchangeRate) Strategy() MergeStrategyType {
+	return r.strategy
 }
 
-func mergePriorFunc() MergeFunc {
-	return func(batch *BatchExchanges, rates []ExchangeRate) {
-		batch.walk(rates, func(curr, next ExchangeRate) (ExchangeRate, error) {
-			if curr.priority < next.priority {
-				return next, nil
-			}
+// Quotes returns the raw exchange rates quoted by the providers for the currency pair with their publication dates
+func (r ExchangeRate) Quotes() []Quote {
+	quotes := make([]Quote, len(r.quotes))
+	copy(quotes, r.quotes)
 
-			return curr, nil
-		})
-	}
+	return quotes
 }
 
-func (e *exchanger) merge(batch *BatchExchanges, rates []ExchangeRate) {
-	batch.mtx.Lock()
-	defer batch.mtx.Unlock()
-
-	mergeStrategyFn := mergerFor(e.opts.MergeStrategy)
+// Providers returns the names of the providers whose quotes took part in the exchange rate
+func (r ExchangeRate) Providers() []string {
+	names := make([]string, 0, len(r.quotes))
+	for _, q := range r.quotes {
+		if q.Used || r.strategy == MergeStrategyTypeCustom {
+			names = append(names, q.Provider)
+		}
+	}
 
-	mergeStrategyFn(batch, rates)
+	return names
 }
 
+type MergeFunc func(*BatchExchanges, []ExchangeRate)
+
 func (e *exchanger) expandRates(source *Provider, rates []provider.ExchangeRate) []ExchangeRate {
 	list := make([]ExchangeRate, len(rates))
 	for i := range rates {
@@ -475,54 +632,87 @@ func (e *exchanger) expandRates(source *Provider, rates []provider.ExchangeRate)
 			time:     rates[i].Time(),
 			from:     rates[i].From(),
 			to:       rates[i].To(),
-			rate:     rates[i].Rate(),
+			rate:     provider.RateDecimal(rates[i]),
+			source:   source.name,
 		}
 	}
 
 	return list
 }
 
-func (e *exchanger) getLatest(ctx context.Context) LatestResponse {
+// getLatest shares the fetch of the latest exchange rates between the concurrent callers
+func (e *exchanger) getLatest(ctx context.Context, state registry) LatestResponse {
+	return e.share(ctx, state, latestKey, func(ctx context.Context) LatestResponse {
+		return e.fetchLatest(ctx, state)
+	})
+}
+
+func (e *exchanger) fetchLatest(ctx context.Context, state registry) LatestResponse {
+	resp := e.fetch(
+		ctx, state, e.now(), latestKey,
+		func(ctx context.Context, source *Provider) ([]provider.ExchangeRate, error) {
+			return source.FetchLatest(ctx)
+		},
+	)
+
+	e.persist(ctx, resp)
+
+	return e.fallback(ctx, resp)
+}
+
+// getOn shares the fetch of the exchange rates on the date between the concurrent callers.
+// The exchange rates held by the store are served without the fetch, see WithStore
+func (e *exchanger) getOn(ctx context.Context, state registry, date time.Time) LatestResponse {
+	if resp, ok := e.stored(ctx, state, date); ok {
+		return resp
+	}
+
+	key := onKey(date)
+
+	return e.share(ctx, state, key, func(ctx context.Context) LatestResponse {
+		return e.fetch(
+			ctx, state, provider.Day(date), key,
+			func(ctx context.Context, source *Provider) ([]provider.ExchangeRate, error) {
+				historical, ok := source.Source.(provider.HistoricalSource)
+				if !ok {
+					return nil, ErrHistoricalNotSupported
+				}
+
+				return historical.FetchOn(ctx, date)
+			},
+		)
+	})
+}
+
+// fetch requests the exchange rates from the providers of the registry with the fetchFunc and merges them.
+// The age of the rates is measured relative to ref, the concurrent requests with the same key share the fetch
+// of each provider
+func (e *exchanger) fetch(
+	ctx context.Context,
+	state registry,
+	ref time.Time,
+	key string,
+	fetchFunc func(ctx context.Context, source *Provider) ([]provider.ExchangeRate, error),
+) LatestResponse {
 	var wg sync.WaitGroup
 	var mtx sync.RWMutex
 
-	ctx, cancel := context.WithTimeout(ctx, e.opts.RequestTimeout)
-	defer cancel()
-
-	batch := &BatchExchanges{}
+	book := &quoteBook{}
 	resp := LatestResponse{
-		Expected:   make([]label.Symbol, len(e.exchangeable)),
+		Expected:   make([]label.Symbol, len(state.exchangeable)),
 		Unreceived: make([]label.Symbol, 0),
 		Info:       make([]SourceInfo, 0),
 		Result:     make([]ExchangeRate, 0),
+		FetchedAt:  e.now(),
+		now:        e.now,
 	}
 
-	for _, source := range e.providers {
+	for _, source := range state.providers {
 		source := source
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			report := SourceInfo{Name: source.name}
-
-			b, _ := retry.NewConstant(e.opts.RetryDuration)
-
-			b = retry.WithMaxRetries(e.opts.RetryNum, b)
-
-			if err := retry.Do(ctx, b, func(ctx context.Context) error {
-				rates, err := source.FetchLatest(ctx)
-				if err != nil {
-					return retry.RetryableError(fmt.Errorf("fetch latest: %w", err))
-				}
-
-				report.Status = ProviderRespStatusOK
-				expanded := e.expandRates(source, rates)
-				e.merge(batch, expanded)
-
-				return nil
-			}); err != nil {
-				report.ErrorMessage = err.Error()
-				report.Status = ProviderRespStatusFailed
-			}
+			report := e.fetchSource(ctx, source, ref, key, book, fetchFunc)
 
 			mtx.Lock()
 			defer mtx.Unlock()
@@ -533,52 +723,117 @@ func (e *exchanger) getLatest(ctx context.Context) LatestResponse {
 
 	wg.Wait()
 
-	copy(resp.Expected, e.exchangeable)
+	copy(resp.Expected, state.exchangeable)
 
-	for _, symbol := range e.exchangeable {
-		if _, ok := batch.Items[symbol]; !ok {
-			resp.Unreceived = append(resp.Unreceived, symbol)
+	result, decisions := e.merge(book.rates)
+
+	resp.Unreceived = unreceived(state.exchangeable, result)
+	resp.Result = append(resp.Result, result...)
+	resp.Decisions = decisions
+
+	return resp
+}
+
+// fetchSource requests the exchange rates from the provider with the retries of its policy and adds them to the book.
+// The provider is skipped while its circuit breaker is open
+func (e *exchanger) fetchSource(
+	ctx context.Context,
+	source *Provider,
+	ref time.Time,
+	key string,
+	book *quoteBook,
+	fetchFunc func(ctx context.Context, source *Provider) ([]provider.ExchangeRate, error),
+) SourceInfo {
+	report := SourceInfo{Name: source.name}
+	policy := source.policy.withDefaults(e.opts)
+
+	cb := source.breaker
+	if policy.breakerThreshold <= 0 {
+		cb = nil
+	}
+
+	var ticket uint64
+	if cb != nil {
+		var ok bool
+		var state CircuitState
+		ok, state, ticket = cb.allow(e.now(), policy.breakerCoolDown)
+		if !ok {
+			report.Status = ProviderRespStatusFailed
+			report.ErrorMessage = ErrCircuitOpen.Error()
+			report.Circuit = state
+
+			return report
 		}
 	}
 
-	if batch.Items == nil {
-		return resp
-	}
+	fetchCtx, cancel := context.WithTimeout(ctx, policy.timeout)
+	defer cancel()
+
+	// lastErr the error of the last attempt without the retryable mark of the retry package
+	var lastErr error
+	err := retry.Do(fetchCtx, policy.backoff(), func(ctx context.Context) error {
+		rates, err := source.share(ctx, key, policy.timeout, fetchFunc)
+		if err != nil {
+			err = fmt.Errorf("fetch: %w", err)
+			if !policy.retryable(err) {
+				return err
+			}
 
-	resp.Result = append(resp.Result, batch.flatten()...)
+			lastErr = err
 
-	return resp
-}
+			return retry.RetryableError(err)
+		}
 
-func (e *exchanger) isExchangeable(from, to label.Symbol) struct {
-	from bool
-	to   bool
-} {
-	res := struct {
-		from bool
-		to   bool
-	}{}
+		report.Status = ProviderRespStatusOK
+		expanded, published, stale := e.checkStale(e.expandRates(source, rates), ref)
+		report.PublishedAt = published
+		report.Stale = stale
+		book.add(expanded)
 
-	for _, symbol := range e.exchangeable {
-		if symbol == from {
-			res.from = true
+		return nil
+	})
+	if err != nil {
+		if lastErr != nil && errors.Unwrap(err) == lastErr {
+			err = lastErr
 		}
 
-		if symbol == to {
-			res.to = true
+		report.ErrorMessage = err.Error()
+		report.Status = ProviderRespStatusFailed
+	}
+
+	if cb != nil {
+		if err != nil && !isProviderFailure(ctx, err) {
+			report.Circuit = cb.release()
+		} else {
+			report.Circuit = cb.done(err, ticket, e.now(), policy.breakerThreshold)
 		}
+	}
 
-		if res.from && res.to {
-			return res
+	return report
+}
+
+// unreceived returns the expected symbols missing in the exchange rates
+func unreceived(expected []label.Symbol, rates []ExchangeRate) []label.Symbol {
+	received := make(map[label.Symbol]struct{}, len(expected))
+	for _, r := range rates {
+		received[r.from.Symbol] = struct{}{}
+	}
+
+	list := make([]label.Symbol, 0)
+	for _, symbol := range expected {
+		if _, ok := received[symbol]; !ok {
+			list = append(list, symbol)
 		}
 	}
 
-	return res
+	return list
 }
 
+// verifyExchangeable reads the exchangeable currencies again if none were read or a changing source changed them.
+// A change of the sources drops the cached rates like a change of the providers
 func (e *exchanger) verifyExchangeable() {
 	e.mtx.RLock()
-	if len(e.exchangeable) > 0 {
+	if len(e.exchangeable) > 0 && e.sourcesVersion == sourcesVersion(e.providers) {
 		e.mtx.RUnlock()
 		return
 	}
@@ -587,13 +842,39 @@ func (e *exchanger) verifyExchangeable() {
 	e.mtx.Lock()
 	defer e.mtx.Unlock()
 
+	if len(e.exchangeable) > 0 && e.sourcesVersion != sourcesVersion(e.providers) {
+		e.version++
+		if e.cache != nil {
+			e.cache.invalidate()
+		}
+	}
+
 	e.updateExchangeable()
 }
 
+// sourcesVersion returns the sum of the versions of the changing sources, see provider.ChangingSource
+func sourcesVersion(providers []*Provider) uint64 {
+	var version uint64
+	for _, p := range providers {
+		if source, ok := p.Source.(provider.ChangingSource); ok {
+			version += source.ExchangeableVersion()
+		}
+	}
+
+	return version
+}
+
 func (e *exchanger) updateExchangeable() {
+	// the version is taken first, the change made while the currencies are read is not missed
+	e.sourcesVersion = sourcesVersion(e.providers)
+
 	uniqLabels := make(map[label.Symbol]struct{})
 
 	for _, source := range e.providers {
+		if source.disabled {
+			continue
+		}
+
 		for _, symbol := range source.GetExchangeable() {
 			if _, ok := uniqLabels[symbol]; !ok {
 				uniqLabels[symbol] = struct{}{}
@@ -644,6 +925,12 @@ func (b *BatchExchanges) walk(rates []ExchangeRate, fn func(cur, next ExchangeRa
 	}
 }
 
+// Walk merges the rates into the batch, fn merges the rate of a currency pair already in the batch with the next one.
+// Use it in the custom merge function
+func (b *BatchExchanges) Walk(rates []ExchangeRate, fn func(cur, next ExchangeRate) (ExchangeRate, error)) {
+	b.walk(rates, fn)
+}
+
 // convert batch exchanges map to exchange rate list
 func (b *BatchExchanges) flatten() []ExchangeRate {
 	b.mtx.RLock()
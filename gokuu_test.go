@@ -2,12 +2,15 @@ package gokuu
 
 import (
 	"context"
+	"errors"
+	"math"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 	"github.com/robotomize/gokuu/provider"
 )
@@ -184,7 +187,6 @@ func TestExchanger_GetLatest(t *testing.T) {
 
 	testCases := []struct {
 		name        string
-		mergeFunc   MergeFunc
 		mergeTyp    MergeStrategyType
 		labels      []label.Symbol
 		expectedLen int
@@ -203,7 +205,6 @@ func TestExchanger_GetLatest(t *testing.T) {
 	}{
 		{
 			name:        "test_latest_merge_race_0",
-			mergeFunc:   mergeRaceFunc(),
 			mergeTyp:    MergeStrategyTypeRace,
 			expectedLen: 2,
 			labels:      []label.Symbol{label.GBP, label.USD},
@@ -254,7 +255,6 @@ func TestExchanger_GetLatest(t *testing.T) {
 		},
 		{
 			name:        "test_latest_merge_race_1",
-			mergeFunc:   mergeRaceFunc(),
 			mergeTyp:    MergeStrategyTypeRace,
 			expectedLen: 1,
 			labels:      []label.Symbol{label.USD, label.USD},
@@ -294,7 +294,6 @@ func TestExchanger_GetLatest(t *testing.T) {
 		},
 		{
 			name:        "test_latest_merge_priority_0",
-			mergeFunc:   mergePriorFunc(),
 			mergeTyp:    MergeStrategyTypePriority,
 			expectedLen: 2,
 			labels:      []label.Symbol{label.USD, label.GBP},
@@ -363,7 +362,6 @@ func TestExchanger_GetLatest(t *testing.T) {
 		},
 		{
 			name:        "test_latest_merge_average_0",
-			mergeFunc:   mergeAverageFunc(),
 			mergeTyp:    MergeStrategyTypeAverage,
 			expectedLen: 2,
 			labels:      []label.Symbol{label.USD, label.GBP},
@@ -451,7 +449,6 @@ func TestExchanger_GetLatest(t *testing.T) {
 			}
 
 			e := New(http.DefaultClient, opts...)
-			e.merger = tc.mergeFunc
 			e.providers = make([]*Provider, 0)
 			for _, s := range tc.sources {
 				var rates []provider.ExchangeRate
@@ -505,3 +502,186 @@ func TestExchanger_GetLatest(t *testing.T) {
 		})
 	}
 }
+
+func TestExchanger_GetOn(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	date := time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)
+
+	rate := provider.NewMockExchangeRate(ctrl)
+	rate.EXPECT().From().Return(label.Currencies[label.USD]).AnyTimes()
+	rate.EXPECT().To().Return(label.Currencies[label.RUB]).AnyTimes()
+	rate.EXPECT().Rate().Return(72.9781).AnyTimes()
+	rate.EXPECT().Time().Return(date).AnyTimes()
+
+	historical := provider.NewMockHistoricalSource(ctrl)
+	historical.EXPECT().GetExchangeable().Return([]label.Symbol{label.USD, label.RUB}).AnyTimes()
+	historical.EXPECT().FetchOn(gomock.Any(), date).Return([]provider.ExchangeRate{rate}, nil).AnyTimes()
+
+	latestOnly := provider.NewMockSource(ctrl)
+	latestOnly.EXPECT().GetExchangeable().Return([]label.Symbol{label.USD, label.EUR}).AnyTimes()
+
+	e := New(http.DefaultClient)
+	e.providers = make([]*Provider, 0)
+	e.Register("test_historical", historical, 1)
+	e.Register("test_latest_only", latestOnly, 0)
+
+	resp := e.GetOn(ctx, date)
+	if diff := cmp.Diff(1, len(resp.Result)); diff != "" {
+		t.Fatalf("bad result (-want, +got): %s", diff)
+	}
+
+	for _, info := range resp.Info {
+		if info.Name == "test_latest_only" && info.Status != ProviderRespStatusFailed {
+			t.Errorf("source without history reported as ok")
+		}
+	}
+
+	conv, err := e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: 10, Date: date})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+
+	if diff := cmp.Diff(date, conv.Date); diff != "" {
+		t.Errorf("bad conversion date (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff("729.78", conv.Amount.String()); diff != "" {
+		t.Errorf("bad conversion amount (-want, +got): %s", diff)
+	}
+}
+
+func decimalPtr(s string) *decimal.Decimal {
+	d := decimal.RequireFromString(s)
+	return &d
+}
+
+func TestExchanger_ConvertDecimal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		from, to label.Symbol
+		rate     string
+		value    float64
+		exact    *decimal.Decimal
+		expected string
+	}{
+		{
+			name:     "test_round_to_minor_units",
+			from:     label.USD,
+			to:       label.RUB,
+			rate:     "72.9781",
+			value:    0.1,
+			expected: "7.3",
+		},
+		{
+			name:     "test_round_half_away_from_zero",
+			from:     label.USD,
+			to:       label.RUB,
+			rate:     "72.9785",
+			value:    1,
+			expected: "72.98",
+		},
+		{
+			name:     "test_zero_minor_units",
+			from:     label.USD,
+			to:       label.JPY,
+			rate:     "109.7847512554",
+			value:    10,
+			expected: "1098",
+		},
+		{
+			name:     "test_three_minor_units",
+			from:     label.USD,
+			to:       label.KWD,
+			rate:     "0.30085",
+			exact:    decimalPtr("1000000.01"),
+			expected: "300850.003",
+		},
+		{
+			name:     "test_exact_zero",
+			from:     label.USD,
+			to:       label.RUB,
+			rate:     "72.9781",
+			value:    10,
+			exact:    decimalPtr("0"),
+			expected: "0",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			rate := provider.NewMockDecimalExchangeRate(ctrl)
+			rate.EXPECT().From().Return(label.Currencies[tc.from]).AnyTimes()
+			rate.EXPECT().To().Return(label.Currencies[tc.to]).AnyTimes()
+			rate.EXPECT().Decimal().Return(decimal.RequireFromString(tc.rate)).AnyTimes()
+			rate.EXPECT().Time().Return(time.Now()).AnyTimes()
+
+			source := provider.NewMockSource(ctrl)
+			source.EXPECT().GetExchangeable().Return([]label.Symbol{tc.from, tc.to}).AnyTimes()
+			source.EXPECT().FetchLatest(gomock.Any()).Return([]provider.ExchangeRate{rate}, nil).AnyTimes()
+
+			e := New(http.DefaultClient)
+			e.providers = make([]*Provider, 0)
+			e.Register("test_source", source, 0)
+
+			conv, err := e.Convert(ctx, ConvOpt{From: tc.from, To: tc.to, Value: tc.value, DecimalValue: tc.exact})
+			if err != nil {
+				t.Fatalf("convert: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, conv.Amount.String()); diff != "" {
+				t.Errorf("bad amount (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(tc.rate, conv.Rate.String()); diff != "" {
+				t.Errorf("bad rate (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestExchanger_ConvertInvalidValue(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		name  string
+		opts  []Option
+		value float64
+	}{
+		{
+			name:  "test_nan",
+			value: math.NaN(),
+		},
+		{
+			name:  "test_inf",
+			value: math.Inf(1),
+		},
+		{
+			name:  "test_negative_inf_cached",
+			opts:  []Option{WithCache(time.Minute)},
+			value: math.Inf(-1),
+		},
+	}
+
+	for _, tc := range testCases {
+		e := New(http.DefaultClient, append([]Option{WithRetryNum(0)}, tc.opts...)...)
+		e.providers = make([]*Provider, 0)
+		e.Register("test_source", newStaticSource(testRate(label.USD, label.RUB, "72.9781", 0)), 0)
+
+		if _, err := e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: tc.value}); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("%s: bad error, want %v, got %v", tc.name, ErrInvalidValue, err)
+		}
+	}
+}
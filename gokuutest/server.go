@@ -0,0 +1,304 @@
+// Package gokuutest provides a local stand-in of the central banks for testing the code using gokuu
+// without network access. The server serves the recorded responses of the banks at the real paths
+// and injects latency, errors, malformed bodies and not modified responses
+package gokuutest
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/internal/fixtures"
+)
+
+// The paths of the resources served by the central banks
+const (
+	PathECBDailyXML  = "/stats/eurofxref/eurofxref-daily.xml"
+	PathECBDailyZIP  = "/stats/eurofxref/eurofxref.zip"
+	PathECBRecentXML = "/stats/eurofxref/eurofxref-hist-90d.xml"
+	PathECBHistZIP   = "/stats/eurofxref/eurofxref-hist.zip"
+	PathRCBDaily     = "/scripts/XML_daily.asp"
+	PathCAERates     = "/en/fx-rates"
+)
+
+// ProviderPaths returns the paths of the resources of the built-in provider
+func ProviderPaths(name string) []string {
+	switch name {
+	case gokuu.ProviderNameECB:
+		return []string{PathECBDailyXML, PathECBDailyZIP, PathECBRecentXML, PathECBHistZIP}
+	case gokuu.ProviderNameRCB:
+		return []string{PathRCBDaily}
+	case gokuu.ProviderNameCAE:
+		return []string{PathCAERates}
+	default:
+		return nil
+	}
+}
+
+// Fault changes the responses of the resource
+type Fault struct {
+	// Latency delays the response, the wait ends if the client cancels the request
+	Latency time.Duration
+	// StatusCode responds with the status code instead of the fixture
+	StatusCode int
+	// NotModified responds with 304 whether or not the client has the resource
+	NotModified bool
+	// Malformed serves the first half of the fixture
+	Malformed bool
+	// Body replaces the fixture
+	Body []byte
+	// Times the number of requests the fault applies to, 0 applies it to all requests
+	Times int
+}
+
+type resource struct {
+	contentType string
+	body        []byte
+}
+
+// Server the stand-in of the European Central Bank, the Central Bank of Russia and the Central Bank of the UAE.
+// The ECB CSV archives are built from the CSV fixture, the 90-day history serves the daily XML fixture.
+// The responses carry an ETag and the matching If-None-Match requests get 304
+type Server struct {
+	srv *httptest.Server
+
+	mtx      sync.Mutex
+	faults   map[string]Fault
+	requests map[string]int
+}
+
+// NewServer starts the server, it is closed when the test and all its subtests complete
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+
+	resources, err := loadFixtures()
+	if err != nil {
+		tb.Fatalf("gokuutest: load fixtures: %v", err)
+	}
+
+	s := &Server{
+		faults:   make(map[string]Fault),
+		requests: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	for path, res := range resources {
+		mux.HandleFunc(path, s.handle(path, res))
+	}
+
+	s.srv = httptest.NewServer(mux)
+	tb.Cleanup(s.srv.Close)
+
+	return s
+}
+
+// URL returns the base URL of the server
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client returns the HTTP client configured for the server
+func (s *Server) Client() *http.Client {
+	return s.srv.Client()
+}
+
+// Options returns the options pointing the built-in providers to the server. Use them with gokuu.New
+// when the concrete exchanger is needed, e.g. to register providers or to start the auto refresh
+func (s *Server) Options() []gokuu.Option {
+	base, _ := url.Parse(s.srv.URL)
+
+	endpoint := func(path string) url.URL {
+		u := *base
+		u.Path = path
+		return u
+	}
+
+	return []gokuu.Option{
+		gokuu.WithECBBaseURL(*base),
+		gokuu.WithRCBEndpoint(endpoint(PathRCBDaily)),
+		gokuu.WithCAEEndpoint(endpoint(PathCAERates)),
+	}
+}
+
+// NewExchanger returns the exchanger with the built-in providers requesting the server
+func (s *Server) NewExchanger(opts ...gokuu.Option) gokuu.Exchanger {
+	return gokuu.New(s.Client(), append(s.Options(), opts...)...)
+}
+
+// SetFault applies the fault to the resources, it replaces the previous fault of the resources
+func (s *Server) SetFault(f Fault, paths ...string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, path := range paths {
+		s.faults[path] = f
+	}
+}
+
+// ClearFaults serves the fixtures on all paths again
+func (s *Server) ClearFaults() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.faults = make(map[string]Fault)
+}
+
+// Requests returns the number of requests of the resource
+func (s *Server) Requests(path string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.requests[path]
+}
+
+// hit counts the request and returns the fault applying to it
+func (s *Server) hit(path string) (Fault, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.requests[path]++
+
+	f, ok := s.faults[path]
+	if !ok {
+		return f, false
+	}
+
+	switch {
+	case f.Times == 1:
+		delete(s.faults, path)
+	case f.Times > 1:
+		next := f
+		next.Times--
+		s.faults[path] = next
+	}
+
+	return f, true
+}
+
+func (s *Server) handle(path string, res resource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := res.body
+
+		if f, ok := s.hit(path); ok {
+			if f.Latency > 0 {
+				timer := time.NewTimer(f.Latency)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+
+			switch {
+			case f.NotModified:
+				w.WriteHeader(http.StatusNotModified)
+				return
+			case f.StatusCode != 0:
+				http.Error(w, http.StatusText(f.StatusCode), f.StatusCode)
+				return
+			case f.Body != nil:
+				body = f.Body
+			case f.Malformed:
+				body = body[:len(body)/2]
+			}
+		}
+
+		etag := etagOf(body)
+		w.Header().Set("ETag", etag)
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", res.contentType)
+		_, _ = w.Write(body)
+	}
+}
+
+// loadFixtures returns the resources served at the paths
+func loadFixtures() (map[string]resource, error) {
+	read := func(name string) ([]byte, error) {
+		b, err := fixtures.FS.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+
+		return b, nil
+	}
+
+	ecbXML, err := read("ecb/data.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	ecbCSV, err := read("ecb/data.csv")
+	if err != nil {
+		return nil, err
+	}
+
+	rcbXML, err := read("rcb/data.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	caeHTML, err := read("aed/index.html")
+	if err != nil {
+		return nil, err
+	}
+
+	ecbDailyZIP, err := archive("eurofxref.csv", ecbCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	ecbHistZIP, err := archive("eurofxref-hist.csv", ecbCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]resource{
+		PathECBDailyXML:  {contentType: "text/xml", body: ecbXML},
+		PathECBDailyZIP:  {contentType: "application/zip", body: ecbDailyZIP},
+		PathECBRecentXML: {contentType: "text/xml", body: ecbXML},
+		PathECBHistZIP:   {contentType: "application/zip", body: ecbHistZIP},
+		PathRCBDaily:     {contentType: "application/xml", body: rcbXML},
+		PathCAERates:     {contentType: "text/html; charset=utf-8", body: caeHTML},
+	}, nil
+}
+
+// archive returns the ZIP archive with the single file like the ECB publishes
+func archive(name string, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", name, err)
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return nil, fmt.Errorf("write %s: %w", name, err)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("close archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func etagOf(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
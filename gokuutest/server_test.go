@@ -0,0 +1,155 @@
+package gokuutest
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/provider/httputil"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		faults           map[string]Fault
+		opts             []gokuu.Option
+		calls            int
+		expectedFailed   []string
+		expectedRequests map[string]int
+	}{
+		{
+			name:             "test_fixtures",
+			calls:            1,
+			expectedRequests: map[string]int{PathRCBDaily: 1, PathCAERates: 1},
+		},
+		{
+			name:   "test_retry_after_error",
+			faults: map[string]Fault{PathRCBDaily: {StatusCode: http.StatusServiceUnavailable, Times: 1}},
+			opts: []gokuu.Option{
+				gokuu.WithRetryNum(1),
+				gokuu.WithRetryDuration(10 * time.Millisecond),
+			},
+			calls:            1,
+			expectedRequests: map[string]int{PathRCBDaily: 2, PathCAERates: 1},
+		},
+		{
+			name:             "test_error_without_retries",
+			faults:           map[string]Fault{PathRCBDaily: {StatusCode: http.StatusServiceUnavailable}},
+			calls:            1,
+			expectedFailed:   []string{gokuu.ProviderNameRCB},
+			expectedRequests: map[string]int{PathRCBDaily: 1, PathCAERates: 1},
+		},
+		{
+			name:             "test_latency_timeout",
+			faults:           map[string]Fault{PathCAERates: {Latency: time.Minute}},
+			opts:             []gokuu.Option{gokuu.WithRequestTimeout(100 * time.Millisecond)},
+			calls:            1,
+			expectedFailed:   []string{gokuu.ProviderNameCAE},
+			expectedRequests: map[string]int{PathRCBDaily: 1, PathCAERates: 1},
+		},
+		{
+			name:             "test_malformed_body",
+			faults:           map[string]Fault{PathRCBDaily: {Malformed: true}},
+			calls:            1,
+			expectedFailed:   []string{gokuu.ProviderNameRCB},
+			expectedRequests: map[string]int{PathRCBDaily: 1, PathCAERates: 1},
+		},
+		{
+			name:             "test_replaced_body",
+			faults:           map[string]Fault{PathCAERates: {Body: []byte("<html></html>")}},
+			calls:            1,
+			expectedFailed:   []string{gokuu.ProviderNameCAE},
+			expectedRequests: map[string]int{PathRCBDaily: 1, PathCAERates: 1},
+		},
+		{
+			name:             "test_conditional_requests",
+			opts:             []gokuu.Option{gokuu.WithResponseCache(httputil.NewMemoryCache())},
+			calls:            2,
+			expectedRequests: map[string]int{PathRCBDaily: 2, PathCAERates: 2},
+		},
+		{
+			name:             "test_not_modified_without_cache",
+			faults:           map[string]Fault{PathRCBDaily: {NotModified: true}},
+			calls:            1,
+			expectedFailed:   []string{gokuu.ProviderNameRCB},
+			expectedRequests: map[string]int{PathRCBDaily: 1, PathCAERates: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := NewServer(t)
+			for path, f := range tc.faults {
+				srv.SetFault(f, path)
+			}
+
+			opts := append([]gokuu.Option{gokuu.WithRetryNum(0)}, tc.opts...)
+			e := srv.NewExchanger(opts...)
+
+			var resp gokuu.LatestResponse
+			for i := 0; i < tc.calls; i++ {
+				resp = e.GetLatest(context.Background())
+			}
+
+			var failed []string
+			for _, info := range resp.Info {
+				if info.Status != gokuu.ProviderRespStatusOK {
+					failed = append(failed, info.Name)
+				}
+			}
+
+			sort.Strings(failed)
+
+			if diff := cmp.Diff(tc.expectedFailed, failed); diff != "" {
+				t.Errorf("bad failed providers (-want, +got): %s", diff)
+			}
+
+			requests := map[string]int{
+				PathRCBDaily: srv.Requests(PathRCBDaily),
+				PathCAERates: srv.Requests(PathCAERates),
+			}
+
+			if diff := cmp.Diff(tc.expectedRequests, requests); diff != "" {
+				t.Errorf("bad requests (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestServer_ClearFaults(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(t)
+	srv.SetFault(Fault{StatusCode: http.StatusInternalServerError}, ProviderPaths(gokuu.ProviderNameECB)...)
+
+	e := srv.NewExchanger(gokuu.WithRetryNum(0))
+
+	status := func() gokuu.ProviderRespStatus {
+		for _, info := range e.GetLatest(context.Background()).Info {
+			if info.Name == gokuu.ProviderNameECB {
+				return info.Status
+			}
+		}
+
+		return gokuu.ProviderRespStatusFailed
+	}
+
+	if diff := cmp.Diff(gokuu.ProviderRespStatusFailed, status()); diff != "" {
+		t.Errorf("bad status with faults (-want, +got): %s", diff)
+	}
+
+	srv.ClearFaults()
+
+	if diff := cmp.Diff(gokuu.ProviderRespStatusOK, status()); diff != "" {
+		t.Errorf("bad status without faults (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,130 @@
+package integration_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/gokuutest"
+	"github.com/robotomize/gokuu/label"
+)
+
+type quote struct {
+	provider string
+	from     label.Symbol
+	to       label.Symbol
+	rate     string
+}
+
+func TestExchanger_GetLatest(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		faults    map[string]gokuutest.Fault
+		published map[string]time.Time
+		failed    []string
+		quotes    []quote
+	}{
+		{
+			name: "test_all_providers",
+			faults: map[string]gokuutest.Fault{
+				gokuutest.PathECBDailyZIP: {StatusCode: http.StatusNotFound},
+			},
+			published: map[string]time.Time{
+				gokuu.ProviderNameECB: time.Date(2021, 6, 18, 0, 0, 0, 0, time.UTC),
+				gokuu.ProviderNameRCB: time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC),
+				gokuu.ProviderNameCAE: time.Date(2021, 8, 12, 0, 0, 0, 0, time.UTC),
+			},
+			quotes: []quote{
+				{provider: gokuu.ProviderNameECB, from: label.EUR, to: label.USD, rate: "1.1898"},
+				{provider: gokuu.ProviderNameRCB, from: label.USD, to: label.RUB, rate: "73.1904"},
+				{provider: gokuu.ProviderNameCAE, from: label.USD, to: label.AED, rate: "3.6725"},
+			},
+		},
+		{
+			name: "test_ecb_csv_archive",
+			faults: map[string]gokuutest.Fault{
+				gokuutest.PathECBDailyXML: {StatusCode: http.StatusServiceUnavailable},
+			},
+			published: map[string]time.Time{
+				gokuu.ProviderNameECB: time.Date(2021, 7, 2, 0, 0, 0, 0, time.UTC),
+				gokuu.ProviderNameRCB: time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC),
+				gokuu.ProviderNameCAE: time.Date(2021, 8, 12, 0, 0, 0, 0, time.UTC),
+			},
+			quotes: []quote{
+				{provider: gokuu.ProviderNameECB, from: label.EUR, to: label.USD, rate: "1.1823"},
+			},
+		},
+		{
+			name: "test_provider_unavailable",
+			faults: map[string]gokuutest.Fault{
+				gokuutest.PathECBDailyZIP: {StatusCode: http.StatusNotFound},
+				gokuutest.PathRCBDaily:    {StatusCode: http.StatusInternalServerError},
+			},
+			published: map[string]time.Time{
+				gokuu.ProviderNameECB: time.Date(2021, 6, 18, 0, 0, 0, 0, time.UTC),
+				gokuu.ProviderNameCAE: time.Date(2021, 8, 12, 0, 0, 0, 0, time.UTC),
+			},
+			failed: []string{gokuu.ProviderNameRCB},
+			quotes: []quote{
+				{provider: gokuu.ProviderNameCAE, from: label.USD, to: label.AED, rate: "3.6725"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := gokuutest.NewServer(t)
+			for path, f := range tc.faults {
+				srv.SetFault(f, path)
+			}
+
+			resp := srv.NewExchanger(gokuu.WithRetryNum(0)).GetLatest(context.Background())
+
+			published := make(map[string]time.Time)
+			var failed []string
+			for _, info := range resp.Info {
+				if info.Status != gokuu.ProviderRespStatusOK {
+					failed = append(failed, info.Name)
+					continue
+				}
+
+				published[info.Name] = info.PublishedAt
+			}
+
+			if diff := cmp.Diff(tc.published, published); diff != "" {
+				t.Errorf("bad publication dates (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(tc.failed, failed); diff != "" {
+				t.Errorf("bad failed providers (-want, +got): %s", diff)
+			}
+
+			for _, q := range tc.quotes {
+				var got []string
+				for _, r := range resp.Result {
+					if r.From().Symbol != q.from || r.To().Symbol != q.to {
+						continue
+					}
+
+					for _, rq := range r.Quotes() {
+						if rq.Provider == q.provider {
+							got = append(got, rq.Rate.String())
+						}
+					}
+				}
+
+				if diff := cmp.Diff([]string{q.rate}, got); diff != "" {
+					t.Errorf("bad %s quote %s-%s (-want, +got): %s", q.provider, q.from, q.to, diff)
+				}
+			}
+		})
+	}
+}
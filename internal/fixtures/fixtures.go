@@ -0,0 +1,10 @@
+// Package fixtures holds the snapshots of the central bank responses served by the gokuutest server
+package fixtures
+
+import "embed"
+
+// FS the responses of the European Central Bank in ecb, the Central Bank of Russia in rcb
+// and the Central Bank of the UAE in aed
+//
+//go:embed aed ecb rcb
+var FS embed.FS
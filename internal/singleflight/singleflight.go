@@ -0,0 +1,115 @@
+// Package singleflight deduplicates the concurrent calls of a function. Unlike golang.org/x/sync/singleflight,
+// the callers give up on their own context, and the call is cancelled only when all of them gave up
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group runs one call of the function per key at a time. The zero value is ready to use
+type Group struct {
+	mtx   sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+
+	// waiters the number of callers waiting for the result
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do calls fn once for the concurrent callers with the same key and returns its result to all of them.
+// fn gets the context with the values of the first caller, it is cancelled when all callers gave up.
+// Do returns ctx.Err() if ctx is done before fn returns
+func (g *Group) Do(
+	ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	g.mtx.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	c, ok := g.calls[key]
+	if ok {
+		c.waiters++
+		g.mtx.Unlock()
+
+		return g.wait(ctx, key, c)
+	}
+
+	callCtx, cancel := context.WithCancel(detached{ctx})
+	c = &call{
+		done:    make(chan struct{}),
+		waiters: 1,
+		cancel:  cancel,
+	}
+	g.calls[key] = c
+	g.mtx.Unlock()
+
+	go func() {
+		defer cancel()
+
+		c.val, c.err = fn(callCtx)
+
+		g.mtx.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mtx.Unlock()
+
+		close(c.done)
+	}()
+
+	return g.wait(ctx, key, c)
+}
+
+// wait returns the result of the call or ctx.Err(). The last caller giving up cancels the call
+func (g *Group) wait(ctx context.Context, key string, c *call) (interface{}, error) {
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	c.waiters--
+	if c.waiters == 0 {
+		c.cancel()
+
+		// the next caller starts a new call instead of joining the cancelled one
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+	}
+
+	return nil, ctx.Err()
+}
+
+// detached keeps the values of the context without its deadline and cancellation
+type detached struct {
+	parent context.Context
+}
+
+func (d detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (d detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (d detached) Err() error {
+	return nil
+}
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
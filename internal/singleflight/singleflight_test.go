@@ -0,0 +1,194 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type ctxKey struct{}
+
+// waitForWaiters blocks until n callers wait for the call with the key
+func waitForWaiters(t *testing.T, g *Group, key string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		g.mtx.Lock()
+		c, ok := g.calls[key]
+		waiters := 0
+		if ok {
+			waiters = c.waiters
+		}
+		g.mtx.Unlock()
+
+		if waiters == n {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("no %d waiters for %s", n, key)
+}
+
+func TestGroup_Do(t *testing.T) {
+	t.Parallel()
+
+	const callers = 10
+
+	var g Group
+	var calls int32
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+
+		return ctx.Value(ctxKey{}), nil
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, callers)
+	for i := 0; i < callers; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err := g.Do(ctx, "key", fn)
+			if err != nil {
+				t.Errorf("do: %v", err)
+			}
+
+			results[i] = v
+		}()
+	}
+
+	waitForWaiters(t, &g, "key", callers)
+	close(release)
+	wg.Wait()
+
+	if diff := cmp.Diff(int32(1), atomic.LoadInt32(&calls)); diff != "" {
+		t.Errorf("bad calls (-want, +got): %s", diff)
+	}
+
+	for _, v := range results {
+		if diff := cmp.Diff("value", v); diff != "" {
+			t.Errorf("bad result (-want, +got): %s", diff)
+		}
+	}
+
+	// the finished call is not shared with the next callers
+	if _, err := g.Do(ctx, "key", func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	if diff := cmp.Diff(int32(2), atomic.LoadInt32(&calls)); diff != "" {
+		t.Errorf("bad calls (-want, +got): %s", diff)
+	}
+}
+
+func TestGroup_DoError(t *testing.T) {
+	t.Parallel()
+
+	errCall := errors.New("call failed")
+
+	var g Group
+	if _, err := g.Do(context.Background(), "key", func(context.Context) (interface{}, error) {
+		return nil, errCall
+	}); !errors.Is(err, errCall) {
+		t.Errorf("bad error, want %v, got %v", errCall, err)
+	}
+}
+
+func TestGroup_DoCancel(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// cancelAll cancels the contexts of all callers, otherwise only the first one
+		cancelAll   bool
+		expectedErr error
+	}{
+		{
+			name: "test_leader_gives_up",
+		},
+		{
+			name:        "test_all_give_up",
+			cancelAll:   true,
+			expectedErr: context.Canceled,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var g Group
+
+			release := make(chan struct{})
+			callErr := make(chan error, 1)
+			fn := func(ctx context.Context) (interface{}, error) {
+				select {
+				case <-release:
+				case <-ctx.Done():
+				}
+
+				callErr <- ctx.Err()
+
+				return "value", nil
+			}
+
+			leaderCtx, cancelLeader := context.WithCancel(context.Background())
+			followerCtx, cancelFollower := context.WithCancel(context.Background())
+			defer cancelFollower()
+
+			leaderErr := make(chan error, 1)
+			go func() {
+				_, err := g.Do(leaderCtx, "key", fn)
+				leaderErr <- err
+			}()
+
+			waitForWaiters(t, &g, "key", 1)
+
+			followerErr := make(chan error, 1)
+			go func() {
+				_, err := g.Do(followerCtx, "key", fn)
+				followerErr <- err
+			}()
+
+			waitForWaiters(t, &g, "key", 2)
+
+			cancelLeader()
+			if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+				t.Errorf("bad leader error, want %v, got %v", context.Canceled, err)
+			}
+
+			if tc.cancelAll {
+				cancelFollower()
+			} else {
+				close(release)
+			}
+
+			if err := <-followerErr; !errors.Is(err, tc.expectedErr) {
+				t.Errorf("bad follower error, want %v, got %v", tc.expectedErr, err)
+			}
+
+			if err := <-callErr; !errors.Is(err, tc.expectedErr) {
+				t.Errorf("bad call context error, want %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
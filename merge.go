@@ -0,0 +1,316 @@
+package gokuu
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+// MergeDecision describes how the exchange rates of a currency pair quoted by several providers were merged
+type MergeDecision struct {
+	From     label.Symbol
+	To       label.Symbol
+	Strategy MergeStrategyType
+	Quotes   []Quote
+	// Rate the merged exchange rate
+	Rate decimal.Decimal
+}
+
+// Quote exchange rate of a currency pair quoted by a provider
+type Quote struct {
+	Provider string
+	Rate     decimal.Decimal
+	// Time publication date of the exchange rate by the provider
+	Time time.Time
+	// Deviation from the median of all quotes in percent, set only if the deviation filter is enabled
+	Deviation decimal.Decimal
+	// Rejected the quote was dropped by the deviation filter
+	Rejected bool
+	// Stale the quote is older than the max rate age
+	Stale bool
+	// Used the quote took part in the merged exchange rate. Not reported for the custom merge function
+	Used bool
+}
+
+func newQuote(r ExchangeRate) Quote {
+	return Quote{Provider: r.source, Rate: r.rate, Time: r.time, Stale: r.stale}
+}
+
+// quoteBook collects the exchange rates of all providers in the order of arrival
+type quoteBook struct {
+	mtx   sync.Mutex
+	rates []ExchangeRate
+}
+
+func (b *quoteBook) add(rates []ExchangeRate) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.rates = append(b.rates, rates...)
+}
+
+type pair struct {
+	from, to label.Symbol
+}
+
+// group groups the exchange rates by currency pair keeping the order of arrival
+func group(rates []ExchangeRate) ([]pair, map[pair][]ExchangeRate) {
+	keys := make([]pair, 0, len(rates))
+	quotes := make(map[pair][]ExchangeRate, len(rates))
+
+	for _, r := range rates {
+		key := pair{from: r.from.Symbol, to: r.to.Symbol}
+		if _, ok := quotes[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		quotes[key] = append(quotes[key], r)
+	}
+
+	return keys, quotes
+}
+
+// reduceFunc merges the quotes of a currency pair and reports which of them were used
+type reduceFunc func(quotes []ExchangeRate) (ExchangeRate, []bool)
+
+// reducerFor returns the reduce function of the strategy, the means are rounded to the precision decimal places
+func reducerFor(strategy MergeStrategyType, weights map[string]float64, precision int32) reduceFunc {
+	switch strategy {
+	case MergeStrategyTypeAverage:
+		return reduceMeanFunc(precision)
+	case MergeStrategyTypePriority:
+		return reducePrior
+	case MergeStrategyTypeMedian:
+		return reduceMedian
+	case MergeStrategyTypeWeighted:
+		return reduceWeightedFunc(weights, precision)
+	default:
+		return reduceRace
+	}
+}
+
+// reduceRace selects the quote that came first
+func reduceRace(quotes []ExchangeRate) (ExchangeRate, []bool) {
+	used := make([]bool, len(quotes))
+	used[0] = true
+
+	return quotes[0], used
+}
+
+// reducePrior selects the quote of the provider with the highest priority, the first one of equal priorities
+func reducePrior(quotes []ExchangeRate) (ExchangeRate, []bool) {
+	idx := 0
+	for i, q := range quotes {
+		if q.priority > quotes[idx].priority {
+			idx = i
+		}
+	}
+
+	used := make([]bool, len(quotes))
+	used[idx] = true
+
+	return quotes[idx], used
+}
+
+// reduceMeanFunc calculates the arithmetic mean of all quotes
+func reduceMeanFunc(precision int32) reduceFunc {
+	return func(quotes []ExchangeRate) (ExchangeRate, []bool) {
+		sum := decimal.Decimal{}
+		for _, q := range quotes {
+			sum = sum.Add(q.rate)
+		}
+
+		return combined(quotes, sum.Div(decimal.New(int64(len(quotes)), 0), precision))
+	}
+}
+
+// reduceMedian calculates the median of all quotes
+func reduceMedian(quotes []ExchangeRate) (ExchangeRate, []bool) {
+	return combined(quotes, median(quotes))
+}
+
+// reduceWeightedFunc calculates the mean of all quotes weighted by provider.
+// If the weights of all quotes are zero it falls back to the arithmetic mean
+func reduceWeightedFunc(weights map[string]float64, precision int32) reduceFunc {
+	return func(quotes []ExchangeRate) (ExchangeRate, []bool) {
+		sum, total := decimal.Decimal{}, decimal.Decimal{}
+		for _, q := range quotes {
+			w := decimal.New(1, 0)
+			if v, ok := weights[q.source]; ok {
+				w = decimal.NewFromFloat(v)
+			}
+
+			sum = sum.Add(q.rate.Mul(w))
+			total = total.Add(w)
+		}
+
+		if total.Sign() <= 0 {
+			return reduceMeanFunc(precision)(quotes)
+		}
+
+		return combined(quotes, sum.Div(total, precision))
+	}
+}
+
+// combined returns the exchange rate calculated from all quotes with the time of the most recent one
+func combined(quotes []ExchangeRate, rate decimal.Decimal) (ExchangeRate, []bool) {
+	r := quotes[0]
+	used := make([]bool, len(quotes))
+	for i, q := range quotes {
+		used[i] = true
+		if q.time.After(r.time) {
+			r.time = q.time
+		}
+
+		if q.priority > r.priority {
+			r.priority = q.priority
+		}
+	}
+
+	r.rate = rate
+	if len(quotes) > 1 {
+		r.source = ""
+	}
+
+	return r, used
+}
+
+func median(quotes []ExchangeRate) decimal.Decimal {
+	rates := make([]decimal.Decimal, len(quotes))
+	for i, q := range quotes {
+		rates[i] = q.rate
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		return rates[i].Cmp(rates[j]) < 0
+	})
+
+	mid := len(rates) / 2
+	if len(rates)%2 == 1 {
+		return rates[mid]
+	}
+
+	return rates[mid-1].Add(rates[mid]).Mul(decimal.New(5, -1))
+}
+
+// deviations calculates the deviation of each quote from the median in percent
+// rounded to the precision decimal places
+func deviations(quotes []ExchangeRate, precision int32) []decimal.Decimal {
+	m := median(quotes)
+	if m.IsZero() {
+		return nil
+	}
+
+	list := make([]decimal.Decimal, len(quotes))
+	for i, q := range quotes {
+		list[i] = q.rate.Sub(m).Abs().Mul(decimal.New(100, 0)).Div(m.Abs(), precision)
+	}
+
+	return list
+}
+
+// merge merges the exchange rates of all providers by currency pair.
+// The custom merge function replaces the merge strategy and records no decisions
+func (e *exchanger) merge(rates []ExchangeRate) ([]ExchangeRate, []MergeDecision) {
+	keys, quotes := group(rates)
+
+	if e.merger != nil {
+		batch := &BatchExchanges{}
+		batch.mtx.Lock()
+		e.merger(batch, rates)
+		batch.mtx.Unlock()
+
+		result := batch.flatten()
+		for i, r := range result {
+			list := quotes[pair{from: r.from.Symbol, to: r.to.Symbol}]
+			result[i].strategy = MergeStrategyTypeCustom
+			result[i].quotes = make([]Quote, len(list))
+			for j, q := range list {
+				result[i].quotes[j] = newQuote(q)
+			}
+		}
+
+		return result, nil
+	}
+
+	reduce := reducerFor(e.opts.MergeStrategy, e.opts.Weights, e.opts.DivisionPrecision)
+	if e.opts.PreferFreshest &&
+		(e.opts.MergeStrategy == MergeStrategyTypeRace || e.opts.MergeStrategy == MergeStrategyTypePriority) {
+		reduce = freshest(reduce)
+	}
+
+	maxDeviation := decimal.NewFromFloat(e.opts.MaxDeviation)
+
+	result := make([]ExchangeRate, 0, len(keys))
+	decisions := make([]MergeDecision, 0)
+
+	for _, key := range keys {
+		list := quotes[key]
+		if len(list) == 1 {
+			r := list[0]
+			r.strategy = e.opts.MergeStrategy
+			r.quotes = []Quote{newQuote(r)}
+			r.quotes[0].Used = true
+			result = append(result, r)
+			continue
+		}
+
+		decision := MergeDecision{
+			From:     key.from,
+			To:       key.to,
+			Strategy: e.opts.MergeStrategy,
+			Quotes:   make([]Quote, len(list)),
+		}
+
+		accepted := make([]ExchangeRate, 0, len(list))
+		idx := make([]int, 0, len(list))
+
+		var devs []decimal.Decimal
+		if maxDeviation.Sign() > 0 && len(list) >= 3 {
+			devs = deviations(list, e.opts.DivisionPrecision)
+		}
+
+		for i, q := range list {
+			decision.Quotes[i] = newQuote(q)
+			if devs != nil {
+				decision.Quotes[i].Deviation = devs[i]
+				if devs[i].Cmp(maxDeviation) > 0 {
+					decision.Quotes[i].Rejected = true
+					continue
+				}
+			}
+
+			accepted = append(accepted, q)
+			idx = append(idx, i)
+		}
+
+		// the quotes are too scattered to reach a consensus
+		if len(accepted) == 0 {
+			accepted = list
+			for i := range list {
+				decision.Quotes[i].Rejected = false
+				idx = append(idx, i)
+			}
+		}
+
+		r, used := reduce(accepted)
+		r.stale = false
+		for i, ok := range used {
+			decision.Quotes[idx[i]].Used = ok
+			if ok && accepted[i].stale {
+				r.stale = true
+			}
+		}
+
+		decision.Rate = r.rate
+		r.strategy = e.opts.MergeStrategy
+		r.quotes = decision.Quotes
+		result = append(result, r)
+		decisions = append(decisions, decision)
+	}
+
+	return result, decisions
+}
@@ -0,0 +1,304 @@
+package gokuu
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+func sourceRate(source string, rate string, priority Prior) ExchangeRate {
+	r := testRate(label.USD, label.RUB, rate, priority)
+	r.source = source
+
+	return r
+}
+
+func TestExchanger_Merge(t *testing.T) {
+	t.Parallel()
+
+	quotes := []ExchangeRate{
+		sourceRate(ProviderNameRCB, "73", 1),
+		sourceRate(ProviderNameECB, "74", 0),
+		sourceRate(ProviderNameCAE, "79", 2),
+	}
+
+	testCases := []struct {
+		name         string
+		opts         []Option
+		quotes       []ExchangeRate
+		expected     string
+		expectedUsed []bool
+		rejected     []bool
+	}{
+		{
+			name:         "test_race",
+			opts:         []Option{WithRaceMergeStrategy()},
+			quotes:       quotes,
+			expected:     "73",
+			expectedUsed: []bool{true, false, false},
+		},
+		{
+			name:         "test_priority",
+			opts:         []Option{WithPriorityMergeStrategy()},
+			quotes:       quotes,
+			expected:     "79",
+			expectedUsed: []bool{false, false, true},
+		},
+		{
+			name:         "test_mean",
+			opts:         []Option{WithAverageMergeStrategy()},
+			quotes:       quotes,
+			expected:     "75.3333333333333333",
+			expectedUsed: []bool{true, true, true},
+		},
+		{
+			name:         "test_mean_precision",
+			opts:         []Option{WithAverageMergeStrategy(), WithDivisionPrecision(4)},
+			quotes:       quotes,
+			expected:     "75.3333",
+			expectedUsed: []bool{true, true, true},
+		},
+		{
+			name:         "test_median_odd",
+			opts:         []Option{WithMedianMergeStrategy()},
+			quotes:       quotes,
+			expected:     "74",
+			expectedUsed: []bool{true, true, true},
+		},
+		{
+			name:         "test_median_even",
+			opts:         []Option{WithMedianMergeStrategy()},
+			quotes:       quotes[:2],
+			expected:     "73.5",
+			expectedUsed: []bool{true, true},
+		},
+		{
+			name: "test_weighted",
+			opts: []Option{WithWeightedMergeStrategy(map[string]float64{
+				ProviderNameRCB: 2,
+				ProviderNameCAE: 0.5,
+			})},
+			quotes:       quotes,
+			expected:     "74.1428571428571429",
+			expectedUsed: []bool{true, true, true},
+		},
+		{
+			name: "test_weighted_zero",
+			opts: []Option{WithWeightedMergeStrategy(map[string]float64{
+				ProviderNameRCB: 0,
+				ProviderNameECB: 0,
+			})},
+			quotes:       quotes[:2],
+			expected:     "73.5",
+			expectedUsed: []bool{true, true},
+		},
+		{
+			name:     "test_single_quote",
+			opts:     []Option{WithMedianMergeStrategy()},
+			quotes:   quotes[:1],
+			expected: "73",
+		},
+		{
+			name:         "test_deviation_filter",
+			opts:         []Option{WithAverageMergeStrategy(), WithMaxDeviation(5)},
+			quotes:       quotes,
+			expected:     "73.5",
+			expectedUsed: []bool{true, true, false},
+			rejected:     []bool{false, false, true},
+		},
+		{
+			name:         "test_deviation_filter_two_quotes",
+			opts:         []Option{WithAverageMergeStrategy(), WithMaxDeviation(0.1)},
+			quotes:       quotes[:2],
+			expected:     "73.5",
+			expectedUsed: []bool{true, true},
+		},
+		{
+			name: "test_deviation_filter_without_consensus",
+			opts: []Option{WithMedianMergeStrategy(), WithMaxDeviation(1)},
+			quotes: []ExchangeRate{
+				sourceRate(ProviderNameRCB, "1", 0),
+				sourceRate(ProviderNameECB, "2", 0),
+				sourceRate(ProviderNameCAE, "100", 0),
+				sourceRate("test_source", "101", 0),
+			},
+			expected:     "51",
+			expectedUsed: []bool{true, true, true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := New(http.DefaultClient, tc.opts...)
+			result, decisions := e.merge(tc.quotes)
+
+			if diff := cmp.Diff(1, len(result)); diff != "" {
+				t.Fatalf("bad result len (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(tc.expected, result[0].rate.String()); diff != "" {
+				t.Errorf("bad rate (-want, +got): %s", diff)
+			}
+
+			providers := make([]string, 0, len(tc.quotes))
+			for i, q := range tc.quotes {
+				if tc.expectedUsed == nil || tc.expectedUsed[i] {
+					providers = append(providers, q.source)
+				}
+			}
+
+			if diff := cmp.Diff(providers, result[0].Providers()); diff != "" {
+				t.Errorf("bad providers (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(len(tc.quotes), len(result[0].Quotes())); diff != "" {
+				t.Errorf("bad quotes len (-want, +got): %s", diff)
+			}
+
+			if tc.expectedUsed == nil {
+				if diff := cmp.Diff(0, len(decisions)); diff != "" {
+					t.Errorf("bad decisions len (-want, +got): %s", diff)
+				}
+
+				return
+			}
+
+			if diff := cmp.Diff(1, len(decisions)); diff != "" {
+				t.Fatalf("bad decisions len (-want, +got): %s", diff)
+			}
+
+			decision := decisions[0]
+			if diff := cmp.Diff(tc.expected, decision.Rate.String()); diff != "" {
+				t.Errorf("bad decision rate (-want, +got): %s", diff)
+			}
+
+			used := make([]bool, len(decision.Quotes))
+			rejected := make([]bool, len(decision.Quotes))
+			for i, q := range decision.Quotes {
+				used[i] = q.Used
+				rejected[i] = q.Rejected
+
+				if diff := cmp.Diff(tc.quotes[i].source, q.Provider); diff != "" {
+					t.Errorf("bad quote provider (-want, +got): %s", diff)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expectedUsed, used); diff != "" {
+				t.Errorf("bad used quotes (-want, +got): %s", diff)
+			}
+
+			if tc.rejected == nil {
+				tc.rejected = make([]bool, len(decision.Quotes))
+			}
+
+			if diff := cmp.Diff(tc.rejected, rejected); diff != "" {
+				t.Errorf("bad rejected quotes (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestWithWeightedMergeStrategy_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, w := range []float64{-1, math.NaN(), math.Inf(1)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("weight %v accepted", w)
+				}
+			}()
+
+			WithWeightedMergeStrategy(map[string]float64{ProviderNameECB: w})
+		}()
+	}
+}
+
+func TestWithMaxDeviation_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, percent := range []float64{-1, math.NaN(), math.Inf(1)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("max deviation %v accepted", percent)
+				}
+			}()
+
+			WithMaxDeviation(percent)
+		}()
+	}
+}
+
+func TestExchanger_GetLatestProvenance(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	published := map[string]time.Time{
+		ProviderNameECB: time.Date(2021, 7, 29, 0, 0, 0, 0, time.UTC),
+		ProviderNameRCB: time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC),
+	}
+
+	e := New(http.DefaultClient, WithAverageMergeStrategy())
+	e.providers = make([]*Provider, 0)
+
+	for name, rate := range map[string]string{ProviderNameECB: "73", ProviderNameRCB: "74"} {
+		r := provider.NewMockDecimalExchangeRate(ctrl)
+		r.EXPECT().From().Return(label.Currencies[label.USD]).AnyTimes()
+		r.EXPECT().To().Return(label.Currencies[label.RUB]).AnyTimes()
+		r.EXPECT().Decimal().Return(decimal.RequireFromString(rate)).AnyTimes()
+		r.EXPECT().Time().Return(published[name]).AnyTimes()
+
+		source := provider.NewMockSource(ctrl)
+		source.EXPECT().GetExchangeable().Return([]label.Symbol{label.USD, label.RUB}).AnyTimes()
+		source.EXPECT().FetchLatest(gomock.Any()).Return([]provider.ExchangeRate{r}, nil).AnyTimes()
+
+		e.Register(name, source, 0)
+	}
+
+	conv, err := e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: 1})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+
+	if diff := cmp.Diff(1, len(conv.Rates)); diff != "" {
+		t.Fatalf("bad rates len (-want, +got): %s", diff)
+	}
+
+	rate := conv.Rates[0]
+	if diff := cmp.Diff(MergeStrategyTypeAverage, rate.Strategy()); diff != "" {
+		t.Errorf("bad strategy (-want, +got): %s", diff)
+	}
+
+	quotes := rate.Quotes()
+	sort.Slice(quotes, func(i, j int) bool {
+		return quotes[i].Provider < quotes[j].Provider
+	})
+
+	expected := []Quote{
+		{Provider: ProviderNameECB, Rate: decimal.RequireFromString("73"), Time: published[ProviderNameECB], Used: true},
+		{Provider: ProviderNameRCB, Rate: decimal.RequireFromString("74"), Time: published[ProviderNameRCB], Used: true},
+	}
+
+	if diff := cmp.Diff(expected, quotes, cmp.Comparer(decimal.Decimal.Equal)); diff != "" {
+		t.Errorf("bad quotes (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(published[ProviderNameRCB], conv.Date); diff != "" {
+		t.Errorf("bad date (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,134 @@
+package gokuu
+
+import (
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+const DefaultMaxPathLen = 3
+
+// DefaultPivots the base currencies of the built-in providers that link their exchange rates
+var DefaultPivots = []label.Symbol{label.USD, label.EUR, label.RUB, label.AED}
+
+// WithPivots set the intermediate currencies for the conversion when there is no direct exchange rate.
+// Earlier pivots are preferred for the paths of the same length and trust. Without pivots the triangulation is disabled
+func WithPivots(symbols ...label.Symbol) Option {
+	return func(e *exchanger) {
+		e.pivots = symbols
+	}
+}
+
+// WithMaxPathLen set the maximum number of exchange rates in the conversion path
+func WithMaxPathLen(n int) Option {
+	return func(e *exchanger) {
+		e.maxPathLen = n
+	}
+}
+
+// rateIndex exchange rates indexed by currency pair
+type rateIndex map[label.Symbol]map[label.Symbol]ExchangeRate
+
+func newRateIndex(rates []ExchangeRate) rateIndex {
+	idx := make(rateIndex)
+	for _, r := range rates {
+		if _, ok := idx[r.from.Symbol]; !ok {
+			idx[r.from.Symbol] = make(map[label.Symbol]ExchangeRate)
+		}
+
+		idx[r.from.Symbol][r.to.Symbol] = r
+	}
+
+	return idx
+}
+
+// path finds the exchange rates to convert from one currency to another.
+// The direct rate is used if it exists, otherwise the shortest path through the pivots.
+// Of the paths of the same length the most trusted one wins, the one whose least prioritized rate has the highest priority
+func (idx rateIndex) path(from, to label.Symbol, pivots []label.Symbol, maxLen int) []ExchangeRate {
+	if r, ok := idx[from][to]; ok {
+		return []ExchangeRate{r}
+	}
+
+	var best []ExchangeRate
+
+	visited := map[label.Symbol]bool{from: true, to: true}
+
+	var walk func(curr label.Symbol, legs []ExchangeRate)
+	walk = func(curr label.Symbol, legs []ExchangeRate) {
+		// the path continued from here is at least two rates longer
+		if len(legs)+2 > maxLen || (best != nil && len(legs)+2 > len(best)) {
+			return
+		}
+
+		for _, pivot := range pivots {
+			if visited[pivot] {
+				continue
+			}
+
+			r, ok := idx[curr][pivot]
+			if !ok {
+				continue
+			}
+
+			next := append(legs[:len(legs):len(legs)], r)
+			if last, ok := idx[pivot][to]; ok {
+				candidate := append(next[:len(next):len(next)], last)
+				if best == nil || len(candidate) < len(best) ||
+					(len(candidate) == len(best) && trust(candidate) > trust(best)) {
+					best = candidate
+				}
+			}
+
+			visited[pivot] = true
+			walk(pivot, next)
+			visited[pivot] = false
+		}
+	}
+
+	walk(from, nil)
+
+	return best
+}
+
+// trust returns the lowest priority of the exchange rates in the path
+func trust(path []ExchangeRate) Prior {
+	p := path[0].priority
+	for _, r := range path[1:] {
+		if r.priority < p {
+			p = r.priority
+		}
+	}
+
+	return p
+}
+
+// pathRate multiplies the exchange rates of the path. The time is the time of the oldest rate
+func pathRate(path []ExchangeRate) ExchangeRate {
+	rate := ExchangeRate{
+		priority: trust(path),
+		time:     path[0].time,
+		from:     path[0].from,
+		to:       path[len(path)-1].to,
+		rate:     decimal.New(1, 0),
+	}
+
+	for _, r := range path {
+		rate.rate = rate.rate.Mul(r.rate)
+		if r.time.Before(rate.time) {
+			rate.time = r.time
+		}
+	}
+
+	return rate
+}
+
+// pathSymbols returns the currencies of the path from the source to the target
+func pathSymbols(path []ExchangeRate) []label.Symbol {
+	symbols := make([]label.Symbol, 0, len(path)+1)
+	symbols = append(symbols, path[0].from.Symbol)
+	for _, r := range path {
+		symbols = append(symbols, r.to.Symbol)
+	}
+
+	return symbols
+}
@@ -0,0 +1,183 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+func testRate(from, to label.Symbol, rate string, priority Prior) ExchangeRate {
+	return ExchangeRate{
+		priority: priority,
+		from:     label.Currencies[from],
+		to:       label.Currencies[to],
+		rate:     decimal.RequireFromString(rate),
+	}
+}
+
+func TestRateIndex_Path(t *testing.T) {
+	t.Parallel()
+
+	rates := []ExchangeRate{
+		testRate(label.SAR, label.USD, "0.2666", 2),
+		testRate(label.SAR, label.AED, "0.9793", 2),
+		testRate(label.AED, label.USD, "0.2722", 2),
+		testRate(label.USD, label.KZT, "426.11", 1),
+		testRate(label.USD, label.RUB, "73.5", 1),
+		testRate(label.AED, label.RUB, "20.01", 0),
+		testRate(label.RUB, label.KZT, "5.79", 1),
+		testRate(label.EUR, label.GBP, "0.85", 0),
+	}
+
+	testCases := []struct {
+		name     string
+		from, to label.Symbol
+		pivots   []label.Symbol
+		maxLen   int
+		expected []label.Symbol
+	}{
+		{
+			name:     "test_direct",
+			from:     label.USD,
+			to:       label.KZT,
+			pivots:   DefaultPivots,
+			maxLen:   DefaultMaxPathLen,
+			expected: []label.Symbol{label.USD, label.KZT},
+		},
+		{
+			name:     "test_shortest",
+			from:     label.SAR,
+			to:       label.KZT,
+			pivots:   DefaultPivots,
+			maxLen:   DefaultMaxPathLen,
+			expected: []label.Symbol{label.SAR, label.USD, label.KZT},
+		},
+		{
+			name:     "test_most_trusted",
+			from:     label.AED,
+			to:       label.KZT,
+			pivots:   []label.Symbol{label.RUB, label.USD},
+			maxLen:   DefaultMaxPathLen,
+			expected: []label.Symbol{label.AED, label.USD, label.KZT},
+		},
+		{
+			name:     "test_most_trusted_over_pivot_order",
+			from:     label.SAR,
+			to:       label.RUB,
+			pivots:   []label.Symbol{label.AED, label.USD},
+			maxLen:   DefaultMaxPathLen,
+			expected: []label.Symbol{label.SAR, label.USD, label.RUB},
+		},
+		{
+			name:     "test_longer_path",
+			from:     label.SAR,
+			to:       label.KZT,
+			pivots:   []label.Symbol{label.AED, label.RUB},
+			maxLen:   DefaultMaxPathLen,
+			expected: []label.Symbol{label.SAR, label.AED, label.RUB, label.KZT},
+		},
+		{
+			name:   "test_max_path_len",
+			from:   label.SAR,
+			to:     label.KZT,
+			pivots: []label.Symbol{label.AED, label.RUB},
+			maxLen: 2,
+		},
+		{
+			name:   "test_without_pivots",
+			from:   label.SAR,
+			to:     label.KZT,
+			pivots: nil,
+			maxLen: DefaultMaxPathLen,
+		},
+		{
+			name:   "test_unreachable",
+			from:   label.SAR,
+			to:     label.GBP,
+			pivots: DefaultPivots,
+			maxLen: DefaultMaxPathLen,
+		},
+	}
+
+	idx := newRateIndex(rates)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var symbols []label.Symbol
+			if path := idx.path(tc.from, tc.to, tc.pivots, tc.maxLen); len(path) > 0 {
+				symbols = pathSymbols(path)
+			}
+
+			if diff := cmp.Diff(tc.expected, symbols); diff != "" {
+				t.Errorf("bad path (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestExchanger_ConvertTriangulated(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	now := time.Now()
+
+	newSource := func(rates ...ExchangeRate) provider.Source {
+		symbols := make([]label.Symbol, 0, len(rates)*2)
+		list := make([]provider.ExchangeRate, 0, len(rates))
+		for _, r := range rates {
+			r.time = now
+			symbols = append(symbols, r.from.Symbol, r.to.Symbol)
+			list = append(list, r)
+		}
+
+		source := provider.NewMockSource(ctrl)
+		source.EXPECT().GetExchangeable().Return(symbols).AnyTimes()
+		source.EXPECT().FetchLatest(gomock.Any()).Return(list, nil).AnyTimes()
+
+		return source
+	}
+
+	e := New(http.DefaultClient)
+	e.providers = make([]*Provider, 0)
+	e.Register(ProviderNameCAE, newSource(testRate(label.SAR, label.USD, "0.2666", 0)), 0)
+	e.Register(ProviderNameRCB, newSource(testRate(label.USD, label.KZT, "426.11", 0)), 0)
+
+	conv, err := e.Convert(ctx, ConvOpt{From: label.SAR, To: label.KZT, Value: 100})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+
+	if diff := cmp.Diff([]label.Symbol{label.SAR, label.USD, label.KZT}, conv.Path); diff != "" {
+		t.Errorf("bad path (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff("113.600926", conv.Rate.String()); diff != "" {
+		t.Errorf("bad rate (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff("11360.09", conv.Amount.String()); diff != "" {
+		t.Errorf("bad amount (-want, +got): %s", diff)
+	}
+
+	e = New(http.DefaultClient, WithPivots())
+	e.providers = make([]*Provider, 0)
+	e.Register(ProviderNameCAE, newSource(testRate(label.SAR, label.USD, "0.2666", 0)), 0)
+	e.Register(ProviderNameRCB, newSource(testRate(label.USD, label.KZT, "426.11", 0)), 0)
+
+	if _, err := e.Convert(ctx, ConvOpt{From: label.SAR, To: label.KZT, Value: 100}); !errors.Is(err, ErrConversionRate) {
+		t.Errorf("bad error, want %v, got %v", ErrConversionRate, err)
+	}
+}
@@ -0,0 +1,161 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robotomize/gokuu/internal/logging"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+	"github.com/robotomize/gokuu/store"
+)
+
+// ProviderNameStore the name of the source of the exchange rates served from the store, see WithStore
+const ProviderNameStore = "store"
+
+// WithStore saves every successful fetch of the latest exchange rates to the store. GetOn answers from the store
+// when it holds the exchange rates of every stored pair published on the date and goes to the providers otherwise
+func WithStore(s store.Store) Option {
+	return func(e *exchanger) {
+		e.store = s
+	}
+}
+
+// persist saves the latest exchange rates to the store. The failed save is logged, the response is not affected
+func (e *exchanger) persist(ctx context.Context, resp LatestResponse) {
+	if e.store == nil || len(resp.Result) == 0 {
+		return
+	}
+
+	snapshot := store.Snapshot{
+		FetchedAt: resp.FetchedAt,
+		Rates:     make([]store.Rate, len(resp.Result)),
+	}
+
+	for i, r := range resp.Result {
+		snapshot.Rates[i] = store.Rate{
+			Date: provider.Day(r.time),
+			From: r.from.Symbol,
+			To:   r.to.Symbol,
+			Rate: r.rate,
+		}
+	}
+
+	if err := e.store.Save(ctx, snapshot); err != nil {
+		logging.FromContext(ctx).Printf("gokuu: store latest exchange rates: %v", err)
+	}
+}
+
+// stored returns the exchange rates in effect on the date from the store.
+// It reports false if the store does not hold the exchange rates of every pair published on the day
+func (e *exchanger) stored(ctx context.Context, state registry, date time.Time) (LatestResponse, bool) {
+	if e.store == nil {
+		return LatestResponse{}, false
+	}
+
+	day := provider.Day(date)
+
+	rates, err := e.storedOn(ctx, day)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			logging.FromContext(ctx).Printf("gokuu: read stored exchange rates: %v", err)
+		}
+
+		return LatestResponse{}, false
+	}
+
+	list := fromStore(rates)
+
+	expanded, published, stale := e.checkStale(list, day)
+	result, decisions := e.merge(expanded)
+
+	resp := LatestResponse{
+		Expected:   make([]label.Symbol, len(state.exchangeable)),
+		Unreceived: unreceived(state.exchangeable, result),
+		Info: []SourceInfo{
+			{
+				Name:        ProviderNameStore,
+				Status:      ProviderRespStatusOK,
+				PublishedAt: published,
+				Stale:       stale,
+			},
+		},
+		Result:    result,
+		Decisions: decisions,
+		FetchedAt: e.now(),
+		now:       e.now,
+	}
+
+	copy(resp.Expected, state.exchangeable)
+
+	return resp, true
+}
+
+// fromStore returns the exchange rates of the stored rates of the known currencies
+func fromStore(rates []store.Rate) []ExchangeRate {
+	list := make([]ExchangeRate, 0, len(rates))
+	for _, r := range rates {
+		from, ok := label.Currencies[r.From]
+		if !ok {
+			continue
+		}
+
+		to, ok := label.Currencies[r.To]
+		if !ok {
+			continue
+		}
+
+		list = append(list, ExchangeRate{
+			time:   r.Date,
+			from:   from,
+			to:     to,
+			rate:   r.Rate,
+			source: ProviderNameStore,
+		})
+	}
+
+	return list
+}
+
+// storedOn returns the stored exchange rates of the day if the store covers the day: every pair known
+// to the store was saved for the day itself. The rates carried over from the earlier days and the pairs
+// saved only for other days are left to the providers
+func (e *exchanger) storedOn(ctx context.Context, day time.Time) ([]store.Rate, error) {
+	last, err := e.store.LastDate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("last date: %w", err)
+	}
+
+	// the exchange rates published after the last stored date are unknown
+	if last.Before(day) {
+		return nil, store.ErrNotFound
+	}
+
+	rates, err := e.store.On(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("rates on %s: %w", day.Format("2006-01-02"), err)
+	}
+
+	for _, r := range rates {
+		if !r.Date.Equal(day) {
+			return nil, fmt.Errorf("%w: %s-%s on %s", store.ErrNotFound, r.From, r.To, day.Format("2006-01-02"))
+		}
+	}
+
+	if last.Equal(day) {
+		return rates, nil
+	}
+
+	known, err := e.store.On(ctx, last)
+	if err != nil {
+		return nil, fmt.Errorf("rates on %s: %w", last.Format("2006-01-02"), err)
+	}
+
+	if len(known) > len(rates) {
+		return nil, fmt.Errorf("%w: pairs on %s", store.ErrNotFound, day.Format("2006-01-02"))
+	}
+
+	return rates, nil
+}
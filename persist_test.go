@@ -0,0 +1,150 @@
+package gokuu
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+	"github.com/robotomize/gokuu/store"
+)
+
+func TestExchanger_Store(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+
+	e := New(http.DefaultClient, WithRetryNum(0), WithStore(s))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", newStaticSource(
+		testRate(label.EUR, label.USD, "1.1579", 0),
+		testRate(label.EUR, label.RUB, "84.2476", 0),
+	), 0)
+
+	now := time.Now()
+
+	// the source does not support historical exchange rates, the store is empty
+	resp := e.GetOn(ctx, now)
+	if diff := cmp.Diff([]string{"test_source"}, fetchedProviders(resp)); diff != "" {
+		t.Errorf("bad providers before save (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(ProviderRespStatusFailed, resp.Info[0].Status); diff != "" {
+		t.Errorf("bad status before save (-want, +got): %s", diff)
+	}
+
+	if resp = e.GetLatest(ctx); len(resp.Result) != 2 {
+		t.Fatalf("bad latest result: %v", resp.Result)
+	}
+
+	stored, err := s.On(ctx, now)
+	if err != nil {
+		t.Fatalf("on: %v", err)
+	}
+
+	if diff := cmp.Diff(2, len(stored)); diff != "" {
+		t.Errorf("bad stored rates (-want, +got): %s", diff)
+	}
+
+	// the next day is after the last stored date, the store does not cover it
+	resp = e.GetOn(ctx, now.AddDate(0, 0, 1))
+	if diff := cmp.Diff([]string{"test_source"}, fetchedProviders(resp)); diff != "" {
+		t.Errorf("bad providers after the last date (-want, +got): %s", diff)
+	}
+
+	resp = e.GetOn(ctx, now)
+	if diff := cmp.Diff([]string{ProviderNameStore}, fetchedProviders(resp)); diff != "" {
+		t.Fatalf("bad providers (-want, +got): %s", diff)
+	}
+
+	info := resp.Info[0]
+	if diff := cmp.Diff(ProviderRespStatusOK, info.Status); diff != "" {
+		t.Errorf("bad status (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(provider.Day(now), info.PublishedAt); diff != "" {
+		t.Errorf("bad publication date (-want, +got): %s", diff)
+	}
+
+	rates := make(map[label.Symbol]string, len(resp.Result))
+	for _, r := range resp.Result {
+		if diff := cmp.Diff([]string{ProviderNameStore}, r.Providers()); diff != "" {
+			t.Errorf("bad rate providers (-want, +got): %s", diff)
+		}
+
+		rates[r.To().Symbol] = r.Decimal().String()
+	}
+
+	expected := map[label.Symbol]string{label.USD: "1.1579", label.RUB: "84.2476"}
+	if diff := cmp.Diff(expected, rates); diff != "" {
+		t.Errorf("bad rates (-want, +got): %s", diff)
+	}
+}
+
+func TestExchanger_StoreCoverage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	day := provider.Day(time.Now())
+	rate := func(date time.Time, to label.Symbol, value string) store.Rate {
+		return store.Rate{Date: date, From: label.EUR, To: to, Rate: decimal.RequireFromString(value)}
+	}
+
+	s := store.NewMemoryStore()
+	if err := s.Save(ctx, store.Snapshot{
+		FetchedAt: time.Now(),
+		Rates: []store.Rate{
+			rate(day.AddDate(0, 0, -3), label.USD, "1.1579"),
+			rate(day.AddDate(0, 0, -3), label.RUB, "84.2476"),
+			rate(day.AddDate(0, 0, -1), label.USD, "1.1581"),
+			rate(day, label.USD, "1.1583"),
+			rate(day, label.RUB, "84.1201"),
+		},
+	}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	e := New(http.DefaultClient, WithRetryNum(0), WithStore(s))
+	e.providers = make([]*Provider, 0)
+	e.Register("test_source", newStaticSource(testRate(label.EUR, label.USD, "1.1579", 0)), 0)
+
+	testCases := []struct {
+		name      string
+		date      time.Time
+		providers []string
+	}{
+		{
+			name:      "test_covered",
+			date:      day,
+			providers: []string{ProviderNameStore},
+		},
+		{
+			name:      "test_covered_before",
+			date:      day.AddDate(0, 0, -3),
+			providers: []string{ProviderNameStore},
+		},
+		{
+			name:      "test_day_not_saved",
+			date:      day.AddDate(0, 0, -2),
+			providers: []string{"test_source"},
+		},
+		{
+			name:      "test_partial_day",
+			date:      day.AddDate(0, 0, -1),
+			providers: []string{"test_source"},
+		},
+	}
+
+	for _, tc := range testCases {
+		resp := e.GetOn(ctx, tc.date)
+		if diff := cmp.Diff(tc.providers, fetchedProviders(resp)); diff != "" {
+			t.Errorf("%s: bad providers (-want, +got): %s", tc.name, diff)
+		}
+	}
+}
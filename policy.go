@@ -0,0 +1,164 @@
+package gokuu
+
+import (
+	"errors"
+	"time"
+
+	"github.com/robotomize/gokuu/provider"
+	"github.com/sethvargo/go-retry"
+)
+
+type BackoffStrategy string
+
+const (
+	// BackoffStrategyConstant wait the base duration between the attempts
+	BackoffStrategyConstant BackoffStrategy = "constant"
+	// BackoffStrategyExponential double the wait after every attempt starting from the base duration
+	BackoffStrategyExponential BackoffStrategy = "exponential"
+	// BackoffStrategyFibonacci grow the wait as the Fibonacci sequence of the base duration
+	BackoffStrategyFibonacci BackoffStrategy = "fibonacci"
+)
+
+// ProviderOption overrides the fetch options of the exchanger for a single provider
+type ProviderOption func(*fetchPolicy)
+
+// fetchPolicy how the exchange rates are requested from a provider, the zero fields take the exchanger options
+type fetchPolicy struct {
+	timeout     time.Duration
+	strategy    BackoffStrategy
+	base        time.Duration
+	maxBackoff  time.Duration
+	jitter      uint64
+	maxAttempts uint64
+	retryable   func(err error) bool
+
+	// breakerSet the circuit breaker of the exchanger is overridden
+	breakerSet       bool
+	breakerThreshold int
+	breakerCoolDown  time.Duration
+}
+
+// WithProviderTimeout set the time the provider must return the exchange rates in, including the retries
+func WithProviderTimeout(d time.Duration) ProviderOption {
+	return func(p *fetchPolicy) {
+		p.timeout = d
+	}
+}
+
+// WithProviderBackoff set the strategy of the waits between the attempts, base is the first wait
+func WithProviderBackoff(strategy BackoffStrategy, base time.Duration) ProviderOption {
+	return func(p *fetchPolicy) {
+		p.strategy = strategy
+		p.base = base
+	}
+}
+
+// WithProviderMaxBackoff cap the wait between the attempts
+func WithProviderMaxBackoff(d time.Duration) ProviderOption {
+	return func(p *fetchPolicy) {
+		p.maxBackoff = d
+	}
+}
+
+// WithProviderJitter randomize every wait by up to percent of it, so the retries of many exchangers do not align
+func WithProviderJitter(percent uint64) ProviderOption {
+	return func(p *fetchPolicy) {
+		p.jitter = percent
+	}
+}
+
+// WithProviderMaxAttempts set the number of requests including the first one, 1 disables the retries
+func WithProviderMaxAttempts(n uint64) ProviderOption {
+	return func(p *fetchPolicy) {
+		p.maxAttempts = n
+	}
+}
+
+// WithProviderRetryable set the function deciding whether the failed request is repeated, DefaultRetryable by default
+func WithProviderRetryable(fn func(err error) bool) ProviderOption {
+	return func(p *fetchPolicy) {
+		p.retryable = fn
+	}
+}
+
+// WithProviderOptions override the fetch options of the provider with the name, e.g. of the built-in ones.
+// The options passed to Register are applied after them
+func WithProviderOptions(name string, opts ...ProviderOption) Option {
+	return func(e *exchanger) {
+		if e.providerOpts == nil {
+			e.providerOpts = make(map[string][]ProviderOption)
+		}
+
+		e.providerOpts[name] = append(e.providerOpts[name], opts...)
+	}
+}
+
+// DefaultRetryable reports whether the error of a provider may go away on the next request.
+// The undecodable documents, the missing history and the sources without history are not retried
+func DefaultRetryable(err error) bool {
+	return !provider.IsDecodeError(err) &&
+		!errors.Is(err, provider.ErrHistoryNotFound) &&
+		!errors.Is(err, ErrHistoricalNotSupported)
+}
+
+// withDefaults fills the fields not overridden by the provider options from the exchanger options
+func (p fetchPolicy) withDefaults(o Options) fetchPolicy {
+	if p.timeout <= 0 {
+		p.timeout = o.RequestTimeout
+	}
+
+	if p.strategy == "" {
+		p.strategy = BackoffStrategyConstant
+	}
+
+	if p.base <= 0 {
+		p.base = o.RetryDuration
+	}
+
+	if p.maxAttempts == 0 {
+		p.maxAttempts = o.RetryNum + 1
+	}
+
+	if p.retryable == nil {
+		p.retryable = DefaultRetryable
+	}
+
+	if !p.breakerSet {
+		p.breakerThreshold = o.BreakerThreshold
+		p.breakerCoolDown = o.BreakerCoolDown
+	}
+
+	return p
+}
+
+// backoff returns the new backoff for the attempts of a single fetch, the defaults must be filled
+func (p fetchPolicy) backoff() retry.Backoff {
+	var b retry.Backoff
+	var err error
+
+	switch p.strategy {
+	case BackoffStrategyExponential:
+		b, err = retry.NewExponential(p.base)
+	case BackoffStrategyFibonacci:
+		b, err = retry.NewFibonacci(p.base)
+	default:
+		b, err = retry.NewConstant(p.base)
+	}
+
+	// the backoffs need a positive base, without it the attempts are repeated at once
+	if err != nil {
+		b = retry.BackoffFunc(func() (time.Duration, bool) {
+			return 0, false
+		})
+	}
+
+	if p.jitter > 0 {
+		b = retry.WithJitterPercent(p.jitter, b)
+	}
+
+	if p.maxBackoff > 0 {
+		b = retry.WithCappedDuration(p.maxBackoff, b)
+	}
+
+	return retry.WithMaxRetries(p.maxAttempts-1, b)
+}
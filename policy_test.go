@@ -0,0 +1,216 @@
+package gokuu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+func TestFetchPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	ms := time.Millisecond
+	defaults := Options{RetryNum: 2, RetryDuration: 10 * ms}
+
+	testCases := []struct {
+		name     string
+		opts     []ProviderOption
+		expected []time.Duration
+	}{
+		{
+			name:     "test_defaults",
+			expected: []time.Duration{10 * ms, 10 * ms},
+		},
+		{
+			name:     "test_exponential",
+			opts:     []ProviderOption{WithProviderBackoff(BackoffStrategyExponential, 10*ms), WithProviderMaxAttempts(4)},
+			expected: []time.Duration{10 * ms, 20 * ms, 40 * ms},
+		},
+		{
+			name:     "test_fibonacci",
+			opts:     []ProviderOption{WithProviderBackoff(BackoffStrategyFibonacci, 10*ms), WithProviderMaxAttempts(5)},
+			expected: []time.Duration{10 * ms, 20 * ms, 30 * ms, 50 * ms},
+		},
+		{
+			name: "test_max_backoff",
+			opts: []ProviderOption{
+				WithProviderBackoff(BackoffStrategyExponential, 10*ms),
+				WithProviderMaxAttempts(4),
+				WithProviderMaxBackoff(25 * ms),
+			},
+			expected: []time.Duration{10 * ms, 20 * ms, 25 * ms},
+		},
+		{
+			name: "test_no_retries",
+			opts: []ProviderOption{WithProviderMaxAttempts(1)},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var p fetchPolicy
+			for _, opt := range tc.opts {
+				opt(&p)
+			}
+
+			b := p.withDefaults(defaults).backoff()
+
+			var waits []time.Duration
+			for {
+				next, stop := b.Next()
+				if stop {
+					break
+				}
+
+				waits = append(waits, next)
+			}
+
+			if diff := cmp.Diff(tc.expected, waits); diff != "" {
+				t.Errorf("bad waits (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchPolicy_Jitter(t *testing.T) {
+	t.Parallel()
+
+	var p fetchPolicy
+	WithProviderJitter(50)(&p)
+	WithProviderMaxAttempts(100)(&p)
+
+	b := p.withDefaults(Options{RetryDuration: 100 * time.Millisecond}).backoff()
+	for i := 0; i < 99; i++ {
+		next, _ := b.Next()
+		if next < 50*time.Millisecond || next > 150*time.Millisecond {
+			t.Fatalf("wait %s out of the jitter range", next)
+		}
+	}
+}
+
+// failingSource fails every fetch with err and counts the attempts
+type failingSource struct {
+	err error
+
+	mtx   sync.Mutex
+	calls int
+}
+
+func (s *failingSource) GetExchangeable() []label.Symbol {
+	return []label.Symbol{label.USD, label.RUB}
+}
+
+func (s *failingSource) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, error) {
+	s.mtx.Lock()
+	s.calls++
+	s.mtx.Unlock()
+
+	if s.err == nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return nil, s.err
+}
+
+func (s *failingSource) count() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.calls
+}
+
+func TestExchanger_ProviderOptions(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+
+	testCases := []struct {
+		name          string
+		err           error
+		opts          []Option
+		providerOpts  []ProviderOption
+		expectedCalls int
+	}{
+		{
+			name:          "test_retryable",
+			err:           errUnavailable,
+			expectedCalls: 3,
+		},
+		{
+			name:          "test_decode_error",
+			err:           &provider.DecodeError{Err: errUnavailable},
+			expectedCalls: 1,
+		},
+		{
+			name:          "test_max_attempts",
+			err:           errUnavailable,
+			providerOpts:  []ProviderOption{WithProviderMaxAttempts(5)},
+			expectedCalls: 5,
+		},
+		{
+			name: "test_retryable_func",
+			err:  errUnavailable,
+			providerOpts: []ProviderOption{WithProviderRetryable(func(err error) bool {
+				return !errors.Is(err, errUnavailable)
+			})},
+			expectedCalls: 1,
+		},
+		{
+			name:          "test_exchanger_provider_options",
+			err:           errUnavailable,
+			opts:          []Option{WithProviderOptions("test_source", WithProviderMaxAttempts(2))},
+			expectedCalls: 2,
+		},
+		{
+			name: "test_register_overrides_exchanger_provider_options",
+			err:  errUnavailable,
+			opts: []Option{
+				WithProviderOptions("test_source", WithProviderMaxAttempts(2)),
+				WithProviderOptions(ProviderNameECB, WithProviderMaxAttempts(6)),
+			},
+			providerOpts:  []ProviderOption{WithProviderMaxAttempts(4)},
+			expectedCalls: 4,
+		},
+		{
+			name:          "test_timeout",
+			providerOpts:  []ProviderOption{WithProviderTimeout(10 * time.Millisecond)},
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]Option{WithRetryNum(2), WithRetryDuration(time.Millisecond)}, tc.opts...)
+
+			source := &failingSource{err: tc.err}
+
+			e := New(http.DefaultClient, opts...)
+			e.providers = make([]*Provider, 0)
+			e.Register("test_source", source, 0, tc.providerOpts...)
+
+			resp := e.GetLatest(context.Background())
+
+			if diff := cmp.Diff(tc.expectedCalls, source.count()); diff != "" {
+				t.Errorf("bad calls (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(ProviderRespStatusFailed, resp.Info[0].Status); diff != "" {
+				t.Errorf("bad status (-want, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 	"golang.org/x/net/html"
 )
@@ -71,9 +72,13 @@ func parseHTML(b []byte) (aedLatestRates, error) {
 		rateStr := buf.String()
 		buf.Reset()
 
-		rate, err := strconv.ParseFloat(rateStr, 64)
+		rate, err := decimal.NewFromString(strings.TrimSpace(rateStr))
 		if err != nil {
-			return aedLatestRates{}, fmt.Errorf("strconv.ParseFloat: %w", err)
+			return aedLatestRates{}, fmt.Errorf("decimal.NewFromString: %w", err)
+		}
+
+		if rate.Sign() <= 0 {
+			return aedLatestRates{}, errParseAttrNotValid
 		}
 
 		dailyRates.rates = append(
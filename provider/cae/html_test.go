@@ -175,7 +175,7 @@ func TestDataMatchingParseHTML(t *testing.T) {
 						t.Errorf("can not find symbol %s in result set", r.symbol.String())
 					}
 
-					if diff := cmp.Diff(v, r.rate); diff != "" {
+					if diff := cmp.Diff(v, r.rate.Float64()); diff != "" {
 						t.Errorf("bad value (-want, +got): %s", diff)
 					}
 				}
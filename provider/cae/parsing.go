@@ -3,6 +3,7 @@ package cae
 import (
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 )
 
@@ -13,5 +14,5 @@ type aedLatestRates struct {
 
 type aedExchangeRate struct {
 	symbol label.Symbol
-	rate   float64
+	rate   decimal.Decimal
 }
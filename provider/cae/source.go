@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 	"github.com/robotomize/gokuu/provider"
 	"github.com/robotomize/gokuu/provider/httputil"
@@ -14,6 +15,9 @@ import (
 
 const hostname = "www.centralbank.ae"
 
+// DefaultEndpoint the URL of the exchange rates page of the Central Bank of the UAE
+var DefaultEndpoint = url.URL{Scheme: "https", Host: hostname, Path: "en/fx-rates"}
+
 var exchangeableSymbols = []label.Symbol{
 	label.AED, label.USD, label.ARS, label.AUD, label.BND, label.BRL, label.CAD, label.CHF, label.CLP, label.CNY, label.COP,
 	label.CZK, label.DKK, label.DZD, label.EUR, label.HUF, label.INR, label.JPY, label.KWD, label.MAD, label.MXN,
@@ -26,23 +30,57 @@ type fetcher struct {
 	httputil.SourceHTTPClient
 }
 
-var _ provider.Source = (*source)(nil)
+var _ provider.HistoricalSource = (*source)(nil)
+
+type Option func(*options)
+
+type options struct {
+	endpoint  url.URL
+	httpOpts  []httputil.Option
+	precision int32
+}
+
+// WithEndpoint request the exchange rates from the mirror of the exchange rates page of the Central Bank of the UAE.
+// The date query parameter is added to the endpoint
+func WithEndpoint(u url.URL) Option {
+	return func(o *options) {
+		o.endpoint = u
+	}
+}
+
+// WithResponseCache send the conditional requests and give back the cached body if the resource is not modified
+func WithResponseCache(cache httputil.ResponseCache) Option {
+	return func(o *options) {
+		o.httpOpts = append(o.httpOpts, httputil.WithResponseCache(cache))
+	}
+}
+
+// WithDivisionPrecision set the number of decimal places of the cross rates, decimal.DivisionPrecision by default
+func WithDivisionPrecision(places int32) Option {
+	return func(o *options) {
+		o.precision = places
+	}
+}
+
+func NewSource(client *http.Client, opts ...Option) *source {
+	o := options{endpoint: DefaultEndpoint, precision: decimal.DivisionPrecision}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-func NewSource(client *http.Client) *source {
 	return &source{
 		client: fetcher{
-			u: &url.URL{
-				Scheme: "https",
-				Host:   hostname,
-				Path:   "en/fx-rates",
-			},
-			SourceHTTPClient: httputil.NewHTTPClient(client),
+			u:                &o.endpoint,
+			SourceHTTPClient: httputil.NewHTTPClient(client, o.httpOpts...),
 		},
+		precision: o.precision,
 	}
 }
 
 type source struct {
 	client fetcher
+	// precision the number of decimal places of the cross rates
+	precision int32
 }
 
 func (s *source) GetExchangeable() []label.Symbol {
@@ -50,7 +88,17 @@ func (s *source) GetExchangeable() []label.Symbol {
 }
 
 func (s *source) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, error) {
-	list, err := s.fetchingPlan(ctx)
+	list, err := s.fetchingPlan(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("fetching plan: %w", err)
+	}
+
+	return list, nil
+}
+
+// FetchOn returns the exchange rates set by the Central Bank of the UAE for the date
+func (s *source) FetchOn(ctx context.Context, date time.Time) ([]provider.ExchangeRate, error) {
+	list, err := s.fetchingPlan(ctx, date)
 	if err != nil {
 		return nil, fmt.Errorf("fetching plan: %w", err)
 	}
@@ -58,20 +106,30 @@ func (s *source) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, erro
 	return list, nil
 }
 
-func (s *source) fetchingPlan(ctx context.Context) ([]provider.ExchangeRate, error) {
-	currDate := time.Now().UTC().Format("02/01/2006")
-	query := s.client.u.Query()
-	query.Add("date_req", currDate)
-	s.client.u.RawQuery = query.Encode()
+// FetchRange requests the exchange rates day by day, see provider.FetchDays
+func (s *source) FetchRange(ctx context.Context, from, to time.Time) ([]provider.ExchangeRate, error) {
+	list, err := provider.FetchDays(ctx, from, to, s.fetchingPlan)
+	if err != nil {
+		return nil, fmt.Errorf("fetch days: %w", err)
+	}
+
+	return list, nil
+}
+
+func (s *source) fetchingPlan(ctx context.Context, date time.Time) ([]provider.ExchangeRate, error) {
+	u := *s.client.u
+	query := u.Query()
+	query.Set("date_req", date.Format("02/01/2006"))
+	u.RawQuery = query.Encode()
 
-	b, err := s.client.Get(ctx, *s.client.u)
+	b, err := s.client.Get(ctx, u)
 	if err != nil {
 		return nil, fmt.Errorf("fetching: %w", err)
 	}
 
 	list, err := s.decode(b)
 	if err != nil {
-		return nil, fmt.Errorf("decode: %w", err)
+		return nil, &provider.DecodeError{Err: err}
 	}
 
 	return list, nil
@@ -80,8 +138,8 @@ func (s *source) fetchingPlan(ctx context.Context) ([]provider.ExchangeRate, err
 func (s *source) decode(b []byte) ([]provider.ExchangeRate, error) {
 	var list []provider.ExchangeRate
 
-	aedSymRates := map[label.Symbol]float64{
-		label.AED: 1,
+	aedSymRates := map[label.Symbol]decimal.Decimal{
+		label.AED: decimal.New(1, 0),
 	}
 
 	aedExchangeRates, err := parseHTML(b)
@@ -89,13 +147,14 @@ func (s *source) decode(b []byte) ([]provider.ExchangeRate, error) {
 		return nil, fmt.Errorf("decode xml: %w", err)
 	}
 
+	// The bank publishes the dirham price of a currency unit
 	for _, r := range aedExchangeRates.rates {
-		aedSymRates[r.symbol] = 1 / r.rate
+		aedSymRates[r.symbol] = r.rate
 	}
 
 	aedExchangeRates.rates = append(
 		aedExchangeRates.rates,
-		aedExchangeRate{symbol: label.AED, rate: 1},
+		aedExchangeRate{symbol: label.AED, rate: decimal.New(1, 0)},
 	)
 
 	for _, sym := range aedExchangeRates.rates {
@@ -115,7 +174,7 @@ func (s *source) decode(b []byte) ([]provider.ExchangeRate, error) {
 					time: aedExchangeRates.time,
 					from: ccy,
 					to:   ccy1,
-					rate: aedSymRates[ccy1.Symbol] / aedSymRates[ccy.Symbol],
+					rate: aedSymRates[ccy.Symbol].Div(aedSymRates[ccy1.Symbol], s.precision),
 				}
 
 				list = append(list, rate)
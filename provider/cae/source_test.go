@@ -124,7 +124,7 @@ func TestSource_fetchLatest(t *testing.T) {
 				{
 					from: label.Currencies[label.AED],
 					to:   label.Currencies[label.USD],
-					rate: 0.27229407760381213,
+					rate: 0.2722940776038121,
 				},
 				{
 					from: label.Currencies[label.USD],
@@ -134,12 +134,12 @@ func TestSource_fetchLatest(t *testing.T) {
 				{
 					from: label.Currencies[label.USD],
 					to:   label.Currencies[label.AUD],
-					rate: 1.362200232492064,
+					rate: 1.3622002324920641,
 				},
 				{
 					from: label.Currencies[label.AUD],
 					to:   label.Currencies[label.USD],
-					rate: 0.7341064669843432,
+					rate: 0.7341064669843431,
 				},
 				{
 					from: label.Currencies[label.AED],
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaxRangeDays the longest range in days FetchDays requests, a request per day
+const MaxRangeDays = 366
+
+// fetchDaysConcurrency the number of days FetchDays requests at a time
+const fetchDaysConcurrency = 4
+
+// ErrRangeTooLong is returned by FetchDays for the ranges longer than MaxRangeDays
+var ErrRangeTooLong = errors.New("date range is too long")
+
+// Day returns the calendar day of t as midnight UTC. Sources publish exchange rates per day,
+// so the dates of historical requests are compared at the day level
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// FetchOnFunc returns the exchange rates in effect on the date, see HistoricalSource.FetchOn
+type FetchOnFunc func(ctx context.Context, date time.Time) ([]ExchangeRate, error)
+
+// FetchDays implements FetchRange of the sources that publish a day per request. It requests every day
+// between from and to inclusive with fetchOn, a few days at a time. The banks answer with the last set rates
+// for the days without a publication, so the rates of each publication are returned once, in the order of days,
+// and the publications before the range are skipped
+func FetchDays(ctx context.Context, from, to time.Time, fetchOn FetchOnFunc) ([]ExchangeRate, error) {
+	from, to = Day(from), Day(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf(
+			"%w: range from %s to %s", ErrHistoryNotFound, from.Format("2006-01-02"), to.Format("2006-01-02"),
+		)
+	}
+
+	n := int(to.Sub(from).Hours()/24) + 1
+	if n > MaxRangeDays {
+		return nil, fmt.Errorf("%w: %d days, at most %d", ErrRangeTooLong, n, MaxRangeDays)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mtx      sync.Mutex
+		firstErr error
+	)
+
+	days := make([][]ExchangeRate, n)
+	sem := make(chan struct{}, fetchDaysConcurrency)
+
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			rates, err := fetchOn(ctx, from.AddDate(0, 0, i))
+			if err != nil {
+				mtx.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mtx.Unlock()
+
+				return
+			}
+
+			days[i] = rates
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var list []ExchangeRate
+	published := make(map[time.Time]struct{})
+	for _, rates := range days {
+		if len(rates) == 0 {
+			continue
+		}
+
+		t := rates[0].Time()
+		if _, ok := published[t]; ok || t.Before(from) {
+			continue
+		}
+
+		published[t] = struct{}{}
+		list = append(list, rates...)
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf(
+			"%w: range from %s to %s", ErrHistoryNotFound, from.Format("2006-01-02"), to.Format("2006-01-02"),
+		)
+	}
+
+	return list, nil
+}
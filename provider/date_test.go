@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFetchDays(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	// publications on Thursday 2021-07-29 and Friday 2021-07-30, the weekend answers with the Friday rates
+	thursday := time.Date(2021, 7, 29, 0, 0, 0, 0, time.UTC)
+	friday := thursday.AddDate(0, 0, 1)
+	errFetch := errors.New("unavailable")
+
+	fetchOn := func(_ context.Context, date time.Time) ([]ExchangeRate, error) {
+		published := date
+		switch {
+		case date.After(thursday.AddDate(0, 0, 4)):
+			return nil, errFetch
+		case date.After(friday):
+			published = friday
+		}
+
+		rate := NewMockExchangeRate(ctrl)
+		rate.EXPECT().Time().Return(published).AnyTimes()
+
+		return []ExchangeRate{rate}, nil
+	}
+
+	testCases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		expected []time.Time
+		err      error
+	}{
+		{
+			name:     "test_same_day",
+			from:     thursday.Add(15 * time.Hour),
+			to:       thursday.Add(9 * time.Hour),
+			expected: []time.Time{thursday},
+		},
+		{
+			name:     "test_weekend",
+			from:     thursday,
+			to:       thursday.AddDate(0, 0, 3),
+			expected: []time.Time{thursday, friday},
+		},
+		{
+			name: "test_weekend_only",
+			from: friday.AddDate(0, 0, 1),
+			to:   friday.AddDate(0, 0, 2),
+			err:  ErrHistoryNotFound,
+		},
+		{
+			name: "test_reversed",
+			from: friday,
+			to:   thursday,
+			err:  ErrHistoryNotFound,
+		},
+		{
+			name: "test_too_long",
+			from: thursday.AddDate(0, 0, -MaxRangeDays),
+			to:   thursday,
+			err:  ErrRangeTooLong,
+		},
+		{
+			name: "test_fetch_error",
+			from: thursday,
+			to:   thursday.AddDate(0, 0, 10),
+			err:  errFetch,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rates, err := FetchDays(context.Background(), tc.from, tc.to, fetchOn)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got: %v", tc.err, err)
+			}
+
+			var dates []time.Time
+			for _, r := range rates {
+				dates = append(dates, r.Time())
+			}
+
+			if diff := cmp.Diff(tc.expected, dates); diff != "" {
+				t.Errorf("bad publication dates (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchDays_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	var (
+		mtx              sync.Mutex
+		inFlight, maxRun int
+	)
+
+	fetchOn := func(_ context.Context, date time.Time) ([]ExchangeRate, error) {
+		mtx.Lock()
+		inFlight++
+		if inFlight > maxRun {
+			maxRun = inFlight
+		}
+		mtx.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mtx.Lock()
+		inFlight--
+		mtx.Unlock()
+
+		rate := NewMockExchangeRate(ctrl)
+		rate.EXPECT().Time().Return(date).AnyTimes()
+
+		return []ExchangeRate{rate}, nil
+	}
+
+	from := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	rates, err := FetchDays(context.Background(), from, from.AddDate(0, 0, 29), fetchOn)
+	if err != nil {
+		t.Fatalf("fetch days: %v", err)
+	}
+
+	if diff := cmp.Diff(30, len(rates)); diff != "" {
+		t.Errorf("bad number of days (-want, +got): %s", diff)
+	}
+
+	for i, r := range rates {
+		if !r.Time().Equal(from.AddDate(0, 0, i)) {
+			t.Errorf("day %d out of order: %s", i, r.Time())
+		}
+	}
+
+	if maxRun > fetchDaysConcurrency {
+		t.Errorf("expected at most %d requests at a time, got %d", fetchDaysConcurrency, maxRun)
+	}
+}
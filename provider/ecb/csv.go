@@ -6,13 +6,31 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 )
 
+// csvNotAvailable the value of the currency that was not quoted on the day
+const csvNotAvailable = "N/A"
+
+// The daily file uses the "02 January 2006" date layout and the history file uses ISO 8601 dates
+var csvDateLayouts = []string{"02 January 2006", "2006-01-02"}
+
+func parseCSVDate(token string) (time.Time, error) {
+	var err error
+	for _, layout := range csvDateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, token); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
 func decodeCSV() decodeFunc {
 	return func(b []byte, iterFunc func(rates euroLatestRates) error) error {
 		if iterFunc == nil {
@@ -60,7 +78,7 @@ func decodeCSV() decodeFunc {
 				}
 
 				if header[n] == "Date" {
-					t, err := time.Parse("02 January 2006", token)
+					t, err := parseCSVDate(token)
 					if err != nil {
 						return fmt.Errorf("time.Parse: %w", err)
 					}
@@ -69,18 +87,23 @@ func decodeCSV() decodeFunc {
 					continue
 				}
 
+				// The history files mark the currencies that were not quoted on the day
+				if token == csvNotAvailable {
+					continue
+				}
+
 				symbol := header[n]
 				currencySymbol := (label.Symbol)(symbol)
 				if _, ok := label.Currencies[currencySymbol]; !ok {
 					continue
 				}
 
-				r, err := strconv.ParseFloat(token, 64)
+				r, err := decimal.NewFromString(token)
 				if err != nil {
-					return fmt.Errorf("strconv.ParseFloat: %w", err)
+					return fmt.Errorf("decimal.NewFromString: %w", err)
 				}
 
-				if r <= 0 {
+				if r.Sign() <= 0 {
 					return errAttributeNotValid
 				}
 
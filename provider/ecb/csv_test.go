@@ -218,7 +218,7 @@ func TestDataMatchingDecodeCSV(t *testing.T) {
 						t.Errorf("unknown currency symbol in test dataset")
 					}
 
-					if diff := cmp.Diff(rate, pair.rate); diff != "" {
+					if diff := cmp.Diff(rate, pair.rate.Float64()); diff != "" {
 						t.Errorf("mismatch (-want, +got):\n%s", diff)
 					}
 				}
@@ -1,10 +1,14 @@
 package ecb
 
 import (
+	"bytes"
 	"errors"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+	"github.com/robotomize/gokuu/provider/httputil"
 )
 
 var (
@@ -24,5 +28,22 @@ type euroLatestRates struct {
 
 type euroExchangeRate struct {
 	symbol label.Symbol
-	rate   float64
+	rate   decimal.Decimal
+}
+
+// Decode decodes the euro reference rates in the XML, CSV or zipped CSV formats of the ECB, e.g. the daily
+// or the history file saved for offline use. It returns the cross rates of all currencies by publication date
+// rounded to decimal.DivisionPrecision decimal places
+func Decode(b []byte) (provider.History, error) {
+	decodeFunc := decodeZIP(httputil.ByExt(".csv"), decodeCSV())
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("<")) {
+		decodeFunc = decodeXML()
+	}
+
+	list, err := decode(b, decodeFunc, decimal.DivisionPrecision)
+	if err != nil {
+		return nil, &provider.DecodeError{Err: err}
+	}
+
+	return provider.NewHistory(list), nil
 }
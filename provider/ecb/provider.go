@@ -3,17 +3,18 @@ package ecb
 import (
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 	"github.com/robotomize/gokuu/provider"
 )
 
-var _ provider.ExchangeRate = (*ExchangeRate)(nil)
+var _ provider.DecimalExchangeRate = (*ExchangeRate)(nil)
 
 type ExchangeRate struct {
 	time time.Time
 	from label.Currency
 	to   label.Currency
-	rate float64
+	rate decimal.Decimal
 }
 
 func (e ExchangeRate) Time() time.Time {
@@ -29,5 +30,9 @@ func (e ExchangeRate) To() label.Currency {
 }
 
 func (e ExchangeRate) Rate() float64 {
+	return e.rate.Float64()
+}
+
+func (e ExchangeRate) Decimal() decimal.Decimal {
 	return e.rate
 }
@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/internal/logging"
 	"github.com/robotomize/gokuu/label"
 	"github.com/robotomize/gokuu/provider"
 	"github.com/robotomize/gokuu/provider/httputil"
@@ -16,15 +21,21 @@ import (
 const hostname = "www.ecb.europa.eu"
 
 const (
-	latestXMLRawPath = "/stats/eurofxref/eurofxref-daily.xml"
-	latestCSVRawPath = "/stats/eurofxref/eurofxref.zip"
+	latestXMLRawPath     = "/stats/eurofxref/eurofxref-daily.xml"
+	latestCSVRawPath     = "/stats/eurofxref/eurofxref.zip"
+	recentHistXMLRawPath = "/stats/eurofxref/eurofxref-hist-90d.xml"
+	fullHistZIPRawPath   = "/stats/eurofxref/eurofxref-hist.zip"
 )
 
-var (
-	defaultLatestResourceCSV = url.URL{Scheme: "https", Host: hostname, Path: latestCSVRawPath}
-	defaultLatestResourceXML = url.URL{Scheme: "https", Host: hostname, Path: latestXMLRawPath}
+// The names of the CSV files in the latest and the full history archives
+const (
+	latestCSVFileName   = "eurofxref.csv"
+	fullHistCSVFileName = "eurofxref-hist.csv"
 )
 
+// DefaultBaseURL the base URL of the ECB resources
+var DefaultBaseURL = url.URL{Scheme: "https", Host: hostname}
+
 var exchangeableSymbols = []label.Symbol{
 	label.USD, label.EUR, label.JPY, label.BGN, label.CZK, label.DKK, label.GBP, label.HUF, label.PLN,
 	label.RON, label.SEK, label.CHF, label.ISK, label.NOK, label.HRK, label.RUB, label.TRY, label.AUD,
@@ -32,7 +43,7 @@ var exchangeableSymbols = []label.Symbol{
 	label.NZD, label.PHP, label.SGD, label.THB, label.ZAR,
 }
 
-var _ provider.Source = (*source)(nil)
+var _ provider.HistoricalSource = (*source)(nil)
 
 type fetcher struct {
 	latestURL url.URL
@@ -40,24 +51,96 @@ type fetcher struct {
 	httputil.SourceHTTPClient
 }
 
-func NewSource(client *http.Client) *source {
-	httpClient := httputil.NewHTTPClient(client)
+type Option func(*options)
+
+type options struct {
+	baseURL    url.URL
+	httpOpts   []httputil.Option
+	noFullHist bool
+	precision  int32
+}
+
+// WithBaseURL request the resources from the mirror of the ECB site, the resource paths are appended to the base URL,
+// e.g. https://mirror.example.com/ecb serves /ecb/stats/eurofxref/eurofxref-daily.xml
+func WithBaseURL(u url.URL) Option {
+	return func(o *options) {
+		o.baseURL = u
+	}
+}
+
+// WithResponseCache send the conditional requests and give back the cached body if the resource is not modified
+func WithResponseCache(cache httputil.ResponseCache) Option {
+	return func(o *options) {
+		o.httpOpts = append(o.httpOpts, httputil.WithResponseCache(cache))
+	}
+}
+
+// WithoutFullHistory do not download the full history archive of several megabytes for the dates
+// the 90-day feed does not cover, FetchOn and FetchRange return ErrHistoryNotFound for them instead
+func WithoutFullHistory() Option {
+	return func(o *options) {
+		o.noFullHist = true
+	}
+}
+
+// WithDivisionPrecision set the number of decimal places of the cross rates, decimal.DivisionPrecision by default
+func WithDivisionPrecision(places int32) Option {
+	return func(o *options) {
+		o.precision = places
+	}
+}
+
+func NewSource(client *http.Client, opts ...Option) *source {
+	o := options{baseURL: DefaultBaseURL, precision: decimal.DivisionPrecision}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	httpClient := httputil.NewHTTPClient(client, o.httpOpts...)
 
 	return &source{
 		fetchers: []fetcher{{
-			latestURL:        defaultLatestResourceCSV,
-			decodeFunc:       decodeCSV(),
+			latestURL:        resource(o.baseURL, latestCSVRawPath),
+			decodeFunc:       decodeZIP(httputil.ByName(latestCSVFileName), decodeCSV()),
 			SourceHTTPClient: httpClient,
 		}, {
-			latestURL:        defaultLatestResourceXML,
+			latestURL:        resource(o.baseURL, latestXMLRawPath),
 			decodeFunc:       decodeXML(),
 			SourceHTTPClient: httpClient,
 		}},
+		recent: fetcher{
+			latestURL:        resource(o.baseURL, recentHistXMLRawPath),
+			decodeFunc:       decodeXML(),
+			SourceHTTPClient: httpClient,
+		},
+		full: fetcher{
+			latestURL:        resource(o.baseURL, fullHistZIPRawPath),
+			decodeFunc:       decodeZIP(httputil.ByName(fullHistCSVFileName), decodeCSV()),
+			SourceHTTPClient: httpClient,
+		},
+		noFullHist: o.noFullHist,
+		precision:  o.precision,
 	}
 }
 
+// resource returns the URL of the resource path relative to the base URL
+func resource(base url.URL, path string) url.URL {
+	base.Path = strings.TrimSuffix(base.Path, "/") + path
+	base.RawPath = ""
+
+	return base
+}
+
 type source struct {
 	fetchers []fetcher
+	// recent the history feed for the last 90 days
+	recent fetcher
+	// full the history archive since 1999
+	full fetcher
+	// noFullHist the full history archive is not downloaded for the dates before the 90-day feed
+	noFullHist bool
+	// precision the number of decimal places of the cross rates
+	precision int32
 }
 
 func (s *source) GetExchangeable() []label.Symbol {
@@ -73,6 +156,178 @@ func (s *source) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, erro
 	return list, nil
 }
 
+// FetchOn returns the reference rates of the last publication on or before the date.
+// The 90-day feed is used for recent dates, older dates are loaded from the full history archive
+func (s *source) FetchOn(ctx context.Context, date time.Time) ([]provider.ExchangeRate, error) {
+	date = provider.Day(date)
+
+	days, err := s.fetchDays(ctx, date, func(t time.Time) bool {
+		return !t.After(date)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch days: %w", err)
+	}
+
+	var last *euroLatestRates
+	for i := range days {
+		if last == nil || days[i].time.After(last.time) {
+			last = &days[i]
+		}
+	}
+
+	if last == nil {
+		return nil, fmt.Errorf("%w: %s", provider.ErrHistoryNotFound, date.Format("2006-01-02"))
+	}
+
+	return crossRates(*last, s.precision), nil
+}
+
+// FetchRange returns the reference rates of all publications between from and to inclusive
+func (s *source) FetchRange(ctx context.Context, from, to time.Time) ([]provider.ExchangeRate, error) {
+	from, to = provider.Day(from), provider.Day(to)
+
+	days, err := s.fetchDays(ctx, from, func(t time.Time) bool {
+		return !t.Before(from) && !t.After(to)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch days: %w", err)
+	}
+
+	if len(days) == 0 {
+		return nil, fmt.Errorf(
+			"%w: range from %s to %s", provider.ErrHistoryNotFound, from.Format("2006-01-02"), to.Format("2006-01-02"),
+		)
+	}
+
+	var list []provider.ExchangeRate
+	for _, day := range days {
+		list = append(list, crossRates(day, s.precision)...)
+	}
+
+	return list, nil
+}
+
+// FetchHistory loads the full history of the euro reference rates since 1999 from eurofxref-hist.zip.
+// Each day contains the EUR based pairs in both directions, use FetchOn for the cross rates of a day
+func (s *source) FetchHistory(ctx context.Context) (provider.History, error) {
+	days, _, err := s.decodeHistory(ctx, s.full, func(time.Time) bool { return true })
+	if err != nil {
+		return nil, fmt.Errorf("decode history: %w", err)
+	}
+
+	return s.history(days), nil
+}
+
+// FetchRecentHistory loads the euro reference rates for the last 90 days from eurofxref-hist-90d.xml.
+// Each day contains the EUR based pairs in both directions
+func (s *source) FetchRecentHistory(ctx context.Context) (provider.History, error) {
+	days, _, err := s.decodeHistory(ctx, s.recent, func(time.Time) bool { return true })
+	if err != nil {
+		return nil, fmt.Errorf("decode history: %w", err)
+	}
+
+	return s.history(days), nil
+}
+
+// fetchDays decodes the accepted days from the 90-day feed. If the feed does not reach back to the since date
+// or can not be loaded, the days are decoded from the full history archive unless it is disabled
+func (s *source) fetchDays(
+	ctx context.Context, since time.Time, accept func(time.Time) bool,
+) ([]euroLatestRates, error) {
+	days, first, err := s.decodeHistory(ctx, s.recent, accept)
+	if err == nil && !first.IsZero() && !first.After(since) {
+		return days, nil
+	}
+
+	if s.noFullHist {
+		if err != nil {
+			return nil, fmt.Errorf("decode recent history: %w", err)
+		}
+
+		return nil, fmt.Errorf(
+			"%w: %s is before the 90-day feed", provider.ErrHistoryNotFound, since.Format("2006-01-02"),
+		)
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Printf("ecb: 90-day feed failed, loading the full history: %v", err)
+	} else {
+		logging.FromContext(ctx).Printf(
+			"ecb: %s is before the 90-day feed, loading the full history", since.Format("2006-01-02"),
+		)
+	}
+
+	days, _, err = s.decodeHistory(ctx, s.full, accept)
+	if err != nil {
+		return nil, fmt.Errorf("decode full history: %w", err)
+	}
+
+	return days, nil
+}
+
+// decodeHistory returns the accepted days of the history and the date of the earliest publication in it
+func (s *source) decodeHistory(
+	ctx context.Context, fet fetcher, accept func(time.Time) bool,
+) ([]euroLatestRates, time.Time, error) {
+	var first time.Time
+
+	b, err := fet.Get(ctx, fet.latestURL)
+	if err != nil {
+		return nil, first, fmt.Errorf("fetching: %w", err)
+	}
+
+	var days []euroLatestRates
+	if err := fet.decodeFunc(b, func(r euroLatestRates) error {
+		if first.IsZero() || r.time.Before(first) {
+			first = r.time
+		}
+
+		if accept(r.time) {
+			days = append(days, r)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, first, &provider.DecodeError{Err: err}
+	}
+
+	return days, first, nil
+}
+
+// history converts the euro reference rates to a date-indexed history of the EUR based pairs
+func (s *source) history(days []euroLatestRates) provider.History {
+	eur := label.Currencies[label.EUR]
+	history := make(provider.History, 0, len(days))
+
+	for _, day := range days {
+		daily := provider.DailyRates{
+			Time:  day.time,
+			Rates: make([]provider.ExchangeRate, 0, len(day.rates)*2),
+		}
+
+		for _, pair := range day.rates {
+			ccy, ok := label.Currencies[pair.symbol]
+			if !ok {
+				continue
+			}
+
+			daily.Rates = append(
+				daily.Rates,
+				ExchangeRate{time: day.time, from: eur, to: ccy, rate: pair.rate},
+				ExchangeRate{time: day.time, from: ccy, to: eur, rate: pair.rate.Inv(s.precision)},
+			)
+		}
+
+		history = append(history, daily)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Time.Before(history[j].Time)
+	})
+
+	return history
+}
+
 func (s *source) fetchingPlan(ctx context.Context) ([]provider.ExchangeRate, error) {
 	type fetchingDat struct {
 		err error
@@ -137,52 +392,19 @@ func (s *source) fetchingPlan(ctx context.Context) ([]provider.ExchangeRate, err
 	}
 
 	d, b := dat.d, dat.b
-	list, err := s.decode(b, d)
+	list, err := decode(b, d, s.precision)
 	if err != nil {
-		return nil, fmt.Errorf("decode: %w", err)
+		return nil, &provider.DecodeError{Err: err}
 	}
 
 	return list, nil
 }
 
-func (s *source) decode(b []byte, decodeFunc decodeFunc) ([]provider.ExchangeRate, error) {
+func decode(b []byte, decodeFunc decodeFunc, precision int32) ([]provider.ExchangeRate, error) {
 	var list []provider.ExchangeRate
 
-	euroSymRates := map[label.Symbol]float64{
-		label.EUR: 1,
-	}
-
 	if err := decodeFunc(b, func(r euroLatestRates) error {
-		for _, pair := range r.rates {
-			euroSymRates[pair.symbol] = pair.rate
-		}
-
-		r.rates = append(r.rates, euroExchangeRate{symbol: label.EUR, rate: 1})
-
-		for _, sym := range r.rates {
-			for _, sym1 := range r.rates {
-				if sym.symbol != sym1.symbol {
-					ccy, ok := label.Currencies[sym.symbol]
-					if !ok {
-						continue
-					}
-
-					ccy1, ok := label.Currencies[sym1.symbol]
-					if !ok {
-						continue
-					}
-
-					rate := ExchangeRate{
-						time: r.time,
-						from: ccy,
-						to:   ccy1,
-						rate: euroSymRates[ccy1.Symbol] / euroSymRates[ccy.Symbol],
-					}
-
-					list = append(list, rate)
-				}
-			}
-		}
+		list = append(list, crossRates(r, precision)...)
 
 		return nil
 	}); err != nil {
@@ -191,3 +413,48 @@ func (s *source) decode(b []byte, decodeFunc decodeFunc) ([]provider.ExchangeRat
 
 	return list, nil
 }
+
+// crossRates calculates the exchange rates between all currencies of the daily euro reference rates
+// rounded to the precision decimal places
+func crossRates(r euroLatestRates, precision int32) []provider.ExchangeRate {
+	var list []provider.ExchangeRate
+
+	euroSymRates := map[label.Symbol]decimal.Decimal{
+		label.EUR: decimal.New(1, 0),
+	}
+
+	for _, pair := range r.rates {
+		euroSymRates[pair.symbol] = pair.rate
+	}
+
+	rates := make([]euroExchangeRate, 0, len(r.rates)+1)
+	rates = append(rates, r.rates...)
+	rates = append(rates, euroExchangeRate{symbol: label.EUR, rate: decimal.New(1, 0)})
+
+	for _, sym := range rates {
+		for _, sym1 := range rates {
+			if sym.symbol != sym1.symbol {
+				ccy, ok := label.Currencies[sym.symbol]
+				if !ok {
+					continue
+				}
+
+				ccy1, ok := label.Currencies[sym1.symbol]
+				if !ok {
+					continue
+				}
+
+				rate := ExchangeRate{
+					time: r.time,
+					from: ccy,
+					to:   ccy1,
+					rate: euroSymRates[ccy1.Symbol].Div(euroSymRates[ccy.Symbol], precision),
+				}
+
+				list = append(list, rate)
+			}
+		}
+	}
+
+	return list
+}
@@ -1,6 +1,7 @@
 package ecb
 
 import (
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"context"
@@ -9,12 +10,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
 	"github.com/robotomize/gokuu/provider/httputil"
 )
 
@@ -215,6 +220,46 @@ func TestSource_FetchLatest(t *testing.T) {
 				return mux
 			},
 		},
+		{
+			name:       "fetch_latest_zip_archive",
+			datetime:   "2021-06-18",
+			xmlPattern: testXMLLatestPattern,
+			csvPattern: testCSVLatestPattern,
+			data: []struct {
+				from label.Currency
+				to   label.Currency
+				rate float64
+			}{
+				{
+					from: label.Currencies[label.USD],
+					to:   label.Currencies[label.JPY],
+					rate: 110.203395528660279,
+				},
+				{
+					from: label.Currencies[label.EUR],
+					to:   label.Currencies[label.JPY],
+					rate: 131.12,
+				},
+			},
+			handlerFunc: func() http.Handler {
+				archive, err := os.ReadFile(filepath.Join("testdata", "eurofxref.zip"))
+				if err != nil {
+					panic(err)
+				}
+
+				mux := http.NewServeMux()
+				mux.HandleFunc(testXMLLatestPattern, func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+
+				mux.HandleFunc(testCSVLatestPattern, func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/x-zip-compressed")
+					_, _ = w.Write(archive)
+				})
+
+				return mux
+			},
+		},
 		{
 			name:       "fetch_latest_body_gzip",
 			datetime:   "2021-06-18",
@@ -328,7 +373,7 @@ func TestSource_FetchLatest(t *testing.T) {
 
 				fetchers = append(fetchers, fetcher{
 					latestURL:        *csvURL,
-					decodeFunc:       decodeCSV(),
+					decodeFunc:       decodeZIP(httputil.ByName(latestCSVFileName), decodeCSV()),
 					SourceHTTPClient: httputil.NewHTTPClient(client),
 				})
 			}
@@ -385,3 +430,364 @@ func TestSource_FetchLatest(t *testing.T) {
 		})
 	}
 }
+
+func TestSource_FetchOn(t *testing.T) {
+	t.Parallel()
+
+	histHandler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`
+						<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+							<gesmes:subject>Reference rates</gesmes:subject>
+							<gesmes:Sender>
+								<gesmes:name>European Central Bank</gesmes:name>
+							</gesmes:Sender>
+							<Cube>
+								<Cube time="2021-06-21">
+									<Cube currency="USD" rate="1.1892"/>
+								</Cube>
+								<Cube time="2021-06-18">
+									<Cube currency="USD" rate="1.1898"/>
+								</Cube>
+								<Cube time="2021-06-17">
+									<Cube currency="USD" rate="1.1957"/>
+								</Cube>
+							</Cube>
+						</gesmes:Envelope>
+				`))
+	}
+
+	testCases := []struct {
+		name     string
+		date     string
+		err      error
+		datetime string
+		rate     float64
+	}{
+		{
+			name:     "fetch_on_publication_day",
+			date:     "2021-06-17",
+			datetime: "2021-06-17",
+			rate:     1.1957,
+		},
+		{
+			name:     "fetch_on_weekend",
+			date:     "2021-06-20",
+			datetime: "2021-06-18",
+			rate:     1.1898,
+		},
+		{
+			name: "fetch_on_before_history",
+			date: "2021-06-01",
+			err:  provider.ErrHistoryNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(histHandler))
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL + "/hist")
+			if err != nil {
+				t.Fatalf("unable to parse history url: %v", err)
+			}
+
+			source := NewSource(srv.Client())
+			source.recent.latestURL = *u
+
+			date, err := parseDatetime(tc.date)
+			if err != nil {
+				t.Fatalf("date in test data invalid")
+			}
+
+			rates, err := source.FetchOn(context.Background(), date)
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("fetch on: %v", err)
+				}
+
+				return
+			}
+
+			datetime, err := parseDatetime(tc.datetime)
+			if err != nil {
+				t.Fatalf("datetime in test data invalid")
+			}
+
+			if diff := cmp.Diff(2, len(rates)); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+
+			for _, r := range rates {
+				if diff := cmp.Diff(datetime, r.Time()); diff != "" {
+					t.Errorf("mismatch (-want, +got):\n%s", diff)
+				}
+
+				if r.From().Symbol == label.EUR && r.To().Symbol == label.USD {
+					if diff := cmp.Diff(tc.rate, r.Rate()); diff != "" {
+						t.Errorf("mismatch (-want, +got):\n%s", diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+const testFullHistCSV = `Date,USD,JPY,BGN,CYP,
+2021-06-18,1.1898,131.12,1.9558,N/A,
+2021-06-17,1.1957,131.84,1.9558,N/A,
+1999-01-04,1.1789,133.73,N/A,0.58231,
+`
+
+func testZIPHandlerFunc(t *testing.T, name, content string) http.HandlerFunc {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	f, err := archive.Create(name)
+	if err != nil {
+		t.Fatalf("create zip file: %v", err)
+	}
+
+	if _, err = f.Write([]byte(content)); err != nil {
+		t.Fatalf("write zip file: %v", err)
+	}
+
+	if err = archive.Close(); err != nil {
+		t.Fatalf("close zip archive: %v", err)
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+func TestSource_FetchHistory(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		fileName string
+		err      error
+		dates    []string
+	}{
+		{
+			name:     "fetch_history_zip",
+			fileName: fullHistCSVFileName,
+			dates:    []string{"1999-01-04", "2021-06-17", "2021-06-18"},
+		},
+		{
+			name:     "fetch_history_zip_file_not_found",
+			fileName: latestCSVFileName,
+			err:      httputil.ErrMemberNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(testZIPHandlerFunc(t, tc.fileName, testFullHistCSV))
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL + "/hist.zip")
+			if err != nil {
+				t.Fatalf("unable to parse history url: %v", err)
+			}
+
+			source := NewSource(srv.Client())
+			source.full.latestURL = *u
+
+			history, err := source.FetchHistory(context.Background())
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("fetch history: %v", err)
+				}
+
+				return
+			}
+
+			dates := make([]string, 0, len(history))
+			for _, day := range history.Dates() {
+				dates = append(dates, day.Format("2006-01-02"))
+			}
+
+			if diff := cmp.Diff(tc.dates, dates); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+
+			day, ok := history.On(time.Date(2021, 6, 20, 12, 0, 0, 0, time.UTC))
+			if !ok {
+				t.Fatalf("history on weekend not found")
+			}
+
+			for _, r := range day.Rates {
+				if r.From().Symbol == label.EUR && r.To().Symbol == label.JPY {
+					if diff := cmp.Diff(131.12, r.Rate()); diff != "" {
+						t.Errorf("mismatch (-want, +got):\n%s", diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSource_FetchOnFullHistory(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mirror/ecb"+fullHistZIPRawPath, testZIPHandlerFunc(t, fullHistCSVFileName, testFullHistCSV))
+	mux.HandleFunc("/mirror/ecb"+recentHistXMLRawPath, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`
+						<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+							<Cube>
+								<Cube time="2021-06-18">
+									<Cube currency="USD" rate="1.1898"/>
+								</Cube>
+							</Cube>
+						</gesmes:Envelope>
+				`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	base, err := url.Parse(srv.URL + "/mirror/ecb/")
+	if err != nil {
+		t.Fatalf("unable to parse base url: %v", err)
+	}
+
+	source := NewSource(srv.Client(), WithBaseURL(*base))
+
+	rates, err := source.FetchOn(context.Background(), time.Date(1999, 1, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("fetch on: %v", err)
+	}
+
+	if len(rates) == 0 {
+		t.Fatalf("exchange rates from the full history not found")
+	}
+
+	datetime, err := parseDatetime("1999-01-04")
+	if err != nil {
+		t.Fatalf("datetime in test data invalid")
+	}
+
+	for _, r := range rates {
+		if diff := cmp.Diff(datetime, r.Time()); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+
+		if r.From().Symbol == label.EUR && r.To().Symbol == label.USD {
+			if diff := cmp.Diff(1.1789, r.Rate()); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		}
+	}
+}
+
+func TestSource_FetchOnFallback(t *testing.T) {
+	t.Parallel()
+
+	recentXML := `<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2021-06-18">
+			<Cube currency="USD" rate="1.1898"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	testCases := []struct {
+		name         string
+		date         time.Time
+		recentFailed bool
+		opts         []Option
+		fullRequests int
+		err          error
+	}{
+		{
+			name: "test_recent_feed",
+			date: time.Date(2021, 6, 18, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "test_before_recent_feed",
+			date:         time.Date(1999, 1, 5, 0, 0, 0, 0, time.UTC),
+			fullRequests: 1,
+		},
+		{
+			name:         "test_recent_feed_failed",
+			date:         time.Date(2021, 6, 18, 0, 0, 0, 0, time.UTC),
+			recentFailed: true,
+			fullRequests: 1,
+		},
+		{
+			name: "test_full_history_disabled",
+			date: time.Date(1999, 1, 5, 0, 0, 0, 0, time.UTC),
+			opts: []Option{WithoutFullHistory()},
+			err:  provider.ErrHistoryNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mtx          sync.Mutex
+				fullRequests int
+			)
+
+			full := testZIPHandlerFunc(t, fullHistCSVFileName, testFullHistCSV)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc(fullHistZIPRawPath, func(w http.ResponseWriter, req *http.Request) {
+				mtx.Lock()
+				fullRequests++
+				mtx.Unlock()
+
+				full(w, req)
+			})
+			mux.HandleFunc(recentHistXMLRawPath, func(w http.ResponseWriter, req *http.Request) {
+				if tc.recentFailed {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				_, _ = w.Write([]byte(recentXML))
+			})
+
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL)
+			if err != nil {
+				t.Fatalf("unable to parse url: %v", err)
+			}
+
+			source := NewSource(srv.Client(), append([]Option{WithBaseURL(*u)}, tc.opts...)...)
+
+			rates, err := source.FetchOn(context.Background(), tc.date)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got: %v", tc.err, err)
+			}
+
+			if tc.err == nil && len(rates) == 0 {
+				t.Errorf("exchange rates not found")
+			}
+
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			if diff := cmp.Diff(tc.fullRequests, fullRequests); diff != "" {
+				t.Errorf("bad full history requests (-want, +got): %s", diff)
+			}
+		})
+	}
+}
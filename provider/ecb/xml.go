@@ -6,10 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strconv"
 	"sync"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 )
 
@@ -78,7 +78,7 @@ func decodeXML() decodeFunc {
 						dailyRate.rates = append(
 							dailyRate.rates, euroExchangeRate{
 								symbol: label.Symbol(r.Currency),
-								rate:   r.Rate.Float64(),
+								rate:   r.Rate.Decimal(),
 							},
 						)
 					}
@@ -132,19 +132,23 @@ func (i *XMLCurrencyAttr) UnmarshalXMLAttr(attr xml.Attr) error {
 
 var _ xml.UnmarshalerAttr = (*XMLRateAttr)(nil)
 
-type XMLRateAttr float64
+type XMLRateAttr decimal.Decimal
 
 func (i *XMLRateAttr) Float64() float64 {
-	return float64(*i)
+	return i.Decimal().Float64()
+}
+
+func (i *XMLRateAttr) Decimal() decimal.Decimal {
+	return decimal.Decimal(*i)
 }
 
 func (i *XMLRateAttr) UnmarshalXMLAttr(attr xml.Attr) error {
-	rate, err := strconv.ParseFloat(attr.Value, 64)
+	rate, err := decimal.NewFromString(attr.Value)
 	if err != nil {
-		return fmt.Errorf("strconv.ParseFloat: %w", err)
+		return fmt.Errorf("decimal.NewFromString: %w", err)
 	}
 
-	if rate <= 0 {
+	if rate.Sign() <= 0 {
 		return errAttributeNotValid
 	}
 
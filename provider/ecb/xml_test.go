@@ -130,7 +130,7 @@ func TestDataMatchingDecodeXML(t *testing.T) {
 						t.Errorf("unknown currency symbol in test dataset")
 					}
 
-					if diff := cmp.Diff(rate, pair.rate); diff != "" {
+					if diff := cmp.Diff(rate, pair.rate.Float64()); diff != "" {
 						t.Errorf("mismatch (-want, +got):\n%s", diff)
 					}
 				}
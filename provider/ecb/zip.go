@@ -0,0 +1,38 @@
+package ecb
+
+import (
+	"fmt"
+
+	"github.com/robotomize/gokuu/provider/httputil"
+)
+
+// decodeZIP returns the decoding function that decodes the files of the ZIP archive selected by sel
+// with the next decoding function. The content that is not an archive is decoded as is
+func decodeZIP(sel httputil.MemberSelector, next decodeFunc) decodeFunc {
+	return func(b []byte, iterFunc func(rates euroLatestRates) error) error {
+		if iterFunc == nil {
+			return errMissingIterFunc
+		}
+
+		if httputil.Sniff(b) != httputil.FormatZIP {
+			return next(b, iterFunc)
+		}
+
+		members, err := httputil.ReadArchive(b, sel)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errDecodeToken, err)
+		}
+
+		if len(members) == 0 {
+			return httputil.ErrMemberNotFound
+		}
+
+		for _, m := range members {
+			if err := next(m.Body, iterFunc); err != nil {
+				return fmt.Errorf("%s: %w", m.Name, err)
+			}
+		}
+
+		return nil
+	}
+}
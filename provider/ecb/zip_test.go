@@ -0,0 +1,77 @@
+package ecb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/provider/httputil"
+)
+
+func TestDecodeZIP(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		fixture     string
+		body        []byte
+		sel         httputil.MemberSelector
+		expectedErr error
+		expected    []string
+	}{
+		{
+			name:     "test_single_member",
+			fixture:  "eurofxref.zip",
+			sel:      httputil.ByName(latestCSVFileName),
+			expected: []string{"2021-06-18"},
+		},
+		{
+			name:     "test_multiple_members",
+			fixture:  "multi.zip",
+			sel:      httputil.ByExt(".csv"),
+			expected: []string{"2021-06-17", "2021-06-18"},
+		},
+		{
+			name:        "test_member_not_found",
+			fixture:     "multi.zip",
+			sel:         httputil.ByName(fullHistCSVFileName),
+			expectedErr: httputil.ErrMemberNotFound,
+		},
+		{
+			name:     "test_plain_content",
+			body:     []byte("Date, USD, JPY\n 18 June 2021, 1.1898, 131.12"),
+			sel:      httputil.ByName(latestCSVFileName),
+			expected: []string{"2021-06-18"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := tc.body
+			if tc.fixture != "" {
+				var err error
+				if b, err = os.ReadFile(filepath.Join("testdata", tc.fixture)); err != nil {
+					t.Fatalf("read fixture: %v", err)
+				}
+			}
+
+			var dates []string
+			err := decodeZIP(tc.sel, decodeCSV())(b, func(r euroLatestRates) error {
+				dates = append(dates, r.time.Format("2006-01-02"))
+				return nil
+			})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("bad error, want %v, got %v", tc.expectedErr, err)
+			}
+
+			if diff := cmp.Diff(tc.expected, dates); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
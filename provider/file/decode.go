@@ -0,0 +1,178 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+	"github.com/robotomize/gokuu/provider/ecb"
+	"github.com/robotomize/gokuu/provider/httputil"
+	"github.com/robotomize/gokuu/provider/rcb"
+	"github.com/robotomize/gokuu/provider/snapshot"
+)
+
+var errBadLayout = errors.New("unknown json layout")
+
+const jsonDateLayout = "2006-01-02"
+
+// decode detects the format of the file by the content and decodes the exchange rates
+func decode(b []byte) ([]provider.ExchangeRate, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf")))
+
+	switch {
+	case httputil.Sniff(b) == httputil.FormatZIP:
+		return decodeECB(b)
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("[")):
+		list, err := decodeJSON(trimmed)
+		if err != nil {
+			return nil, &provider.DecodeError{Err: err}
+		}
+
+		return list, nil
+	case bytes.HasPrefix(trimmed, []byte("<")) && bytes.Contains(trimmed, []byte("<ValCurs")):
+		return rcb.Decode(b)
+	default:
+		return decodeECB(b)
+	}
+}
+
+func decodeECB(b []byte) ([]provider.ExchangeRate, error) {
+	history, err := ecb.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return history.Flatten(), nil
+}
+
+// jsonDay the generic JSON layout of the exchange rates published on the date:
+// the price of a unit of the base currency in the other currencies
+type jsonDay struct {
+	Base  label.Symbol                 `json:"base"`
+	Date  string                       `json:"date"`
+	Rates map[label.Symbol]json.Number `json:"rates"`
+}
+
+// decodeJSON decodes the generic layout, a single day or a list of days, or the gokuu snapshot
+func decodeJSON(b []byte) ([]provider.ExchangeRate, error) {
+	if b[0] == '{' {
+		var probe struct {
+			Rates json.RawMessage `json:"rates"`
+		}
+
+		if err := json.Unmarshal(b, &probe); err != nil {
+			return nil, err
+		}
+
+		if bytes.HasPrefix(bytes.TrimSpace(probe.Rates), []byte("[")) {
+			return decodeSnapshot(b)
+		}
+
+		b = append(append([]byte("["), b...), ']')
+	}
+
+	var days []jsonDay
+	if err := json.Unmarshal(b, &days); err != nil {
+		return nil, err
+	}
+
+	var list []provider.ExchangeRate
+	for i, day := range days {
+		rates, err := day.crossRates()
+		if err != nil {
+			return nil, fmt.Errorf("day %d: %w", i+1, err)
+		}
+
+		list = append(list, rates...)
+	}
+
+	return list, nil
+}
+
+func decodeSnapshot(b []byte) ([]provider.ExchangeRate, error) {
+	s, err := snapshot.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]provider.ExchangeRate, 0, len(s.Rates))
+	for _, r := range s.Rates {
+		from, ok := label.Currencies[r.From]
+		if !ok {
+			continue
+		}
+
+		to, ok := label.Currencies[r.To]
+		if !ok {
+			continue
+		}
+
+		list = append(list, ExchangeRate{time: provider.Day(r.Date), from: from, to: to, rate: r.Rate})
+	}
+
+	return list, nil
+}
+
+// crossRates calculates the exchange rates between all currencies of the day. The currencies unknown to gokuu
+// are skipped
+func (d jsonDay) crossRates() ([]provider.ExchangeRate, error) {
+	if _, ok := label.Currencies[d.Base]; !ok {
+		return nil, fmt.Errorf("%w: base currency %q", errBadLayout, d.Base)
+	}
+
+	date, err := time.Parse(jsonDateLayout, d.Date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parse date: %v", errBadLayout, err)
+	}
+
+	rates := map[label.Symbol]decimal.Decimal{d.Base: decimal.New(1, 0)}
+	for symbol, value := range d.Rates {
+		if _, ok := label.Currencies[symbol]; !ok {
+			continue
+		}
+
+		rate, err := decimal.NewFromString(value.String())
+		if err != nil {
+			return nil, fmt.Errorf("parse rate of %s: %w", symbol, err)
+		}
+
+		if rate.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: rate of %s is %s", errBadLayout, symbol, rate)
+		}
+
+		rates[symbol] = rate
+	}
+
+	symbols := make([]label.Symbol, 0, len(rates))
+	for symbol := range rates {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i] < symbols[j]
+	})
+
+	list := make([]provider.ExchangeRate, 0, len(rates)*(len(rates)-1))
+	for _, from := range symbols {
+		for _, to := range symbols {
+			if from == to {
+				continue
+			}
+
+			list = append(list, ExchangeRate{
+				time: date,
+				from: label.Currencies[from],
+				to:   label.Currencies[to],
+				rate: rates[to].Div(rates[from], decimal.DivisionPrecision),
+			})
+		}
+	}
+
+	return list, nil
+}
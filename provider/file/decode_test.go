@@ -0,0 +1,191 @@
+package file
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+const (
+	testECBXML = `<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+    <gesmes:subject>Reference rates</gesmes:subject>
+    <Cube>
+        <Cube time="2021-10-05">
+            <Cube currency="USD" rate="1.1593"/>
+            <Cube currency="JPY" rate="129.29"/>
+        </Cube>
+    </Cube>
+</gesmes:Envelope>`
+	testRCBXML = `<ValCurs Date="05.10.2021" name="Foreign Currency Market">
+    <Valute ID="R01235">
+        <NumCode>840</NumCode>
+        <CharCode>USD</CharCode>
+        <Nominal>1</Nominal>
+        <Name>US Dollar</Name>
+        <Value>72,5048</Value>
+    </Valute>
+</ValCurs>`
+	testJSON = `{"base":"USD","date":"2021-10-05","rates":{"RUB":"72.5048","EUR":0.8626,"ZZZ":1}}`
+)
+
+type testRate struct {
+	Date string
+	From label.Symbol
+	To   label.Symbol
+	Rate string
+}
+
+func toTestRates(rates []provider.ExchangeRate) []testRate {
+	list := make([]testRate, len(rates))
+	for i, r := range rates {
+		list[i] = testRate{
+			Date: r.Time().Format("2006-01-02"),
+			From: r.From().Symbol,
+			To:   r.To().Symbol,
+			Rate: provider.RateDecimal(r).String(),
+		}
+	}
+
+	return list
+}
+
+// findRates returns the exchange rates of the pairs from the base currency
+func findRates(rates []provider.ExchangeRate, base label.Symbol) []testRate {
+	var list []testRate
+	for _, r := range toTestRates(rates) {
+		if r.From == base {
+			list = append(list, r)
+		}
+	}
+
+	return list
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		bytes    string
+		base     label.Symbol
+		expected []testRate
+	}{
+		{
+			name:  "test_ecb_xml",
+			bytes: testECBXML,
+			base:  label.EUR,
+			expected: []testRate{
+				{Date: "2021-10-05", From: label.EUR, To: label.USD, Rate: "1.1593"},
+				{Date: "2021-10-05", From: label.EUR, To: label.JPY, Rate: "129.29"},
+			},
+		},
+		{
+			name:  "test_ecb_csv",
+			bytes: "Date, USD, JPY, \n05 October 2021, 1.1593, 129.29, \n",
+			base:  label.EUR,
+			expected: []testRate{
+				{Date: "2021-10-05", From: label.EUR, To: label.USD, Rate: "1.1593"},
+				{Date: "2021-10-05", From: label.EUR, To: label.JPY, Rate: "129.29"},
+			},
+		},
+		{
+			name:  "test_rcb_xml",
+			bytes: testRCBXML,
+			base:  label.USD,
+			expected: []testRate{
+				{Date: "2021-10-05", From: label.USD, To: label.RUB, Rate: "72.5048"},
+			},
+		},
+		{
+			name:  "test_json_day",
+			bytes: testJSON,
+			base:  label.USD,
+			expected: []testRate{
+				{Date: "2021-10-05", From: label.USD, To: label.EUR, Rate: "0.8626"},
+				{Date: "2021-10-05", From: label.USD, To: label.RUB, Rate: "72.5048"},
+			},
+		},
+		{
+			name:  "test_json_days",
+			bytes: `[` + testJSON + `, {"base":"USD","date":"2021-10-06","rates":{"RUB":"72.6"}}]`,
+			base:  label.USD,
+			expected: []testRate{
+				{Date: "2021-10-05", From: label.USD, To: label.EUR, Rate: "0.8626"},
+				{Date: "2021-10-05", From: label.USD, To: label.RUB, Rate: "72.5048"},
+				{Date: "2021-10-06", From: label.USD, To: label.RUB, Rate: "72.6"},
+			},
+		},
+		{
+			name: "test_json_snapshot",
+			bytes: `{"fetched_at":"2021-10-05T16:00:00Z","rates":[` +
+				`{"from":"USD","to":"RUB","rate":"72.5048","date":"2021-10-05T00:00:00Z"}]}`,
+			base: label.USD,
+			expected: []testRate{
+				{Date: "2021-10-05", From: label.USD, To: label.RUB, Rate: "72.5048"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rates, err := decode([]byte(tc.bytes))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, findRates(rates, tc.base)); diff != "" {
+				t.Errorf("bad rates (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDecode_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		bytes    string
+		expected error
+	}{
+		{
+			name:     "test_unknown_base",
+			bytes:    `{"base":"ZZZ","date":"2021-10-05","rates":{"USD":1}}`,
+			expected: errBadLayout,
+		},
+		{
+			name:     "test_bad_date",
+			bytes:    `{"base":"USD","date":"05.10.2021","rates":{"RUB":72.5048}}`,
+			expected: errBadLayout,
+		},
+		{
+			name:     "test_negative_rate",
+			bytes:    `{"base":"USD","date":"2021-10-05","rates":{"RUB":-1}}`,
+			expected: errBadLayout,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := decode([]byte(tc.bytes))
+
+			var decodeErr *provider.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected decode error, got: %v", err)
+			}
+
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected error %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
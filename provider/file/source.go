@@ -0,0 +1,347 @@
+// Package file is the offline source of exchange rates read from the local files. It reads the ECB XML, CSV
+// and zipped CSV files, the daily XML of the Central Bank of Russia, the generic JSON layout and the gokuu
+// snapshots. The files are reloaded when they change, so a new daily file dropped to the directory is served
+// by the next fetch. The files that fail to decode are logged and skipped until they change
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/internal/logging"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+var ErrNoRates = errors.New("no exchange rates in the files")
+
+var (
+	_ provider.HistoricalSource    = (*source)(nil)
+	_ provider.ChangingSource      = (*source)(nil)
+	_ provider.DecimalExchangeRate = (*ExchangeRate)(nil)
+)
+
+type ExchangeRate struct {
+	time time.Time
+	from label.Currency
+	to   label.Currency
+	rate decimal.Decimal
+}
+
+func (e ExchangeRate) Time() time.Time {
+	return e.time
+}
+
+func (e ExchangeRate) From() label.Currency {
+	return e.from
+}
+
+func (e ExchangeRate) To() label.Currency {
+	return e.to
+}
+
+func (e ExchangeRate) Rate() float64 {
+	return e.rate.Float64()
+}
+
+func (e ExchangeRate) Decimal() decimal.Decimal {
+	return e.rate
+}
+
+type Option func(*options)
+
+type options struct {
+	pattern      string
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+// WithPattern read only the files with the names matching the pattern, see path.Match. All files by default
+func WithPattern(pattern string) Option {
+	return func(o *options) {
+		o.pattern = pattern
+	}
+}
+
+// WithPollInterval check the files for changes at most once per interval. By default the files are checked
+// on every request
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = d
+	}
+}
+
+// WithClock set the clock the poll interval is measured with
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// NewDirSource returns the source reading the files of the directory, see NewSource
+func NewDirSource(dir string, opts ...Option) *source {
+	return NewSource(os.DirFS(dir), opts...)
+}
+
+// NewSource returns the source reading the files in the root of fsys. The hidden files are skipped, write the new
+// file under a hidden name and rename it to make it visible at once. If several files have the exchange rate
+// of the pair on the same day, the rate of the last file in lexical order is used
+func NewSource(fsys fs.FS, opts ...Option) *source {
+	o := options{pattern: "*", now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &source{fsys: fsys, opts: o}
+}
+
+type source struct {
+	// version of the loaded files, it is incremented on every reload
+	version uint64
+
+	fsys fs.FS
+	opts options
+
+	mtx sync.Mutex
+	// checked time of the last check of the files
+	checked time.Time
+	// fingerprint the names, sizes and modification times of the loaded files
+	fingerprint string
+	rates       *rates
+}
+
+// rates the exchange rates of the files
+type rates struct {
+	exchangeable []label.Symbol
+	latest       []provider.ExchangeRate
+	history      provider.History
+}
+
+// GetExchangeable returns the currencies of the files loaded by the last fetch, the files are loaded if no fetch
+// loaded them yet. Empty if the files can not be read
+func (s *source) GetExchangeable() []label.Symbol {
+	s.mtx.Lock()
+	r := s.rates
+	s.mtx.Unlock()
+
+	if r == nil {
+		var err error
+		if r, err = s.load(context.Background()); err != nil {
+			return nil
+		}
+	}
+
+	return r.exchangeable
+}
+
+// ExchangeableVersion returns the version of the loaded files, the exchangeable currencies are read again
+// once a fetch reloads the changed files
+func (s *source) ExchangeableVersion() uint64 {
+	return atomic.LoadUint64(&s.version)
+}
+
+func (s *source) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, error) {
+	r, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]provider.ExchangeRate, len(r.latest))
+	copy(list, r.latest)
+
+	return list, nil
+}
+
+// FetchOn returns the exchange rate of every pair in effect on the date: the rate of the last day on or before
+// the date that has it. The files of different banks are published on different days, so the pairs missing
+// from the last publication are taken from the earlier ones
+func (s *source) FetchOn(ctx context.Context, date time.Time) ([]provider.ExchangeRate, error) {
+	r, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []provider.ExchangeRate
+	if len(r.history) > 0 {
+		list = r.history.Range(r.history[0].Time, date).Latest()
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%w: %s", provider.ErrHistoryNotFound, date.Format("2006-01-02"))
+	}
+
+	return list, nil
+}
+
+func (s *source) FetchRange(ctx context.Context, from, to time.Time) ([]provider.ExchangeRate, error) {
+	r, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := r.history.Range(from, to).Flatten()
+	if len(list) == 0 {
+		return nil, fmt.Errorf(
+			"%w: range from %s to %s", provider.ErrHistoryNotFound, from.Format("2006-01-02"), to.Format("2006-01-02"),
+		)
+	}
+
+	return list, nil
+}
+
+// load returns the exchange rates of the files reloading them if they changed.
+// The files are read again by the next request if none of them could be decoded
+func (s *source) load(ctx context.Context) (*rates, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.opts.now()
+	if s.rates != nil && s.opts.pollInterval > 0 && now.Sub(s.checked) < s.opts.pollInterval {
+		return s.rates, nil
+	}
+
+	names, fingerprint, err := s.scan()
+	if err != nil {
+		return nil, fmt.Errorf("scan files: %w", err)
+	}
+
+	s.checked = now
+	if s.rates != nil && fingerprint == s.fingerprint {
+		return s.rates, nil
+	}
+
+	r, err := s.read(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	s.rates = r
+	s.fingerprint = fingerprint
+	atomic.AddUint64(&s.version, 1)
+
+	return r, nil
+}
+
+// scan returns the names of the files to read in lexical order and their fingerprint
+func (s *source) scan() ([]string, string, error) {
+	entries, err := fs.ReadDir(s.fsys, ".")
+	if err != nil {
+		return nil, "", err
+	}
+
+	var names []string
+	var fingerprint strings.Builder
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if ok, err := path.Match(s.opts.pattern, name); err != nil || !ok {
+			if err != nil {
+				return nil, "", fmt.Errorf("match %s: %w", name, err)
+			}
+
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, "", err
+		}
+
+		names = append(names, name)
+
+		fingerprint.WriteString(name)
+		fingerprint.WriteByte(0)
+		fingerprint.WriteString(strconv.FormatInt(info.Size(), 10))
+		fingerprint.WriteByte(0)
+		fingerprint.WriteString(strconv.FormatInt(info.ModTime().UnixNano(), 10))
+		fingerprint.WriteByte(0)
+	}
+
+	return names, fingerprint.String(), nil
+}
+
+// read decodes the files. The rate of the pair on the day is taken from the last file that has it.
+// The files that can not be read or decoded are logged and skipped, the error of the last of them
+// is returned if no file could be decoded
+func (s *source) read(ctx context.Context, names []string) (*rates, error) {
+	type key struct {
+		day      time.Time
+		from, to label.Symbol
+	}
+
+	idx := make(map[key]int)
+	var list []provider.ExchangeRate
+	var lastErr error
+
+	for _, name := range names {
+		decoded, err := s.decodeFile(name)
+		if err != nil {
+			logging.FromContext(ctx).Printf("file: skip %v", err)
+			lastErr = err
+
+			continue
+		}
+
+		for _, r := range decoded {
+			k := key{day: provider.Day(r.Time()), from: r.From().Symbol, to: r.To().Symbol}
+			if i, ok := idx[k]; ok {
+				list[i] = r
+				continue
+			}
+
+			idx[k] = len(list)
+			list = append(list, r)
+		}
+	}
+
+	if len(list) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+
+		return nil, ErrNoRates
+	}
+
+	r := &rates{history: provider.NewHistory(list)}
+	r.latest = r.history.Latest()
+
+	uniq := make(map[label.Symbol]struct{})
+	for _, rate := range r.latest {
+		for _, symbol := range []label.Symbol{rate.From().Symbol, rate.To().Symbol} {
+			if _, ok := uniq[symbol]; !ok {
+				uniq[symbol] = struct{}{}
+				r.exchangeable = append(r.exchangeable, symbol)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// decodeFile reads and decodes the file
+func (s *source) decodeFile(name string) ([]provider.ExchangeRate, error) {
+	b, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+
+	decoded, err := decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,308 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	modTime := time.Date(2021, 10, 5, 16, 0, 0, 0, time.UTC)
+
+	fsys := fstest.MapFS{
+		"2021-10-05.xml": {Data: []byte(testECBXML), ModTime: modTime},
+		"2021-10-05.json": {
+			Data:    []byte(`{"base":"EUR","date":"2021-10-05","rates":{"USD":"1.16"}}`),
+			ModTime: modTime,
+		},
+		"rates.txt":  {Data: []byte("ignored"), ModTime: modTime},
+		".tmp.xml":   {Data: []byte("ignored"), ModTime: modTime},
+		"old/ro.xml": {Data: []byte("ignored"), ModTime: modTime},
+	}
+
+	src := NewSource(fsys, WithPattern("*.[jx]*"))
+
+	if diff := cmp.Diff([]label.Symbol{label.EUR, label.USD, label.JPY}, src.GetExchangeable()); diff != "" {
+		t.Errorf("bad exchangeable (-want, +got): %s", diff)
+	}
+
+	latest, err := src.FetchLatest(ctx)
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	// the rate of the xml file replaces the rate of the json file read before
+	expected := []testRate{
+		{Date: "2021-10-05", From: label.EUR, To: label.USD, Rate: "1.1593"},
+		{Date: "2021-10-05", From: label.EUR, To: label.JPY, Rate: "129.29"},
+	}
+	if diff := cmp.Diff(expected, findRates(latest, label.EUR)); diff != "" {
+		t.Errorf("bad latest rates (-want, +got): %s", diff)
+	}
+
+	// the new daily file is loaded by the next request
+	fsys["2021-10-06.xml"] = &fstest.MapFile{
+		Data: []byte(`<gesmes:Envelope><Cube><Cube time="2021-10-06">` +
+			`<Cube currency="USD" rate="1.1554"/></Cube></Cube></gesmes:Envelope>`),
+		ModTime: modTime.Add(24 * time.Hour),
+	}
+
+	latest, err = src.FetchLatest(ctx)
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-06", From: label.EUR, To: label.USD, Rate: "1.1554"},
+		{Date: "2021-10-05", From: label.EUR, To: label.JPY, Rate: "129.29"},
+	}
+	if diff := cmp.Diff(expected, findRates(latest, label.EUR)); diff != "" {
+		t.Errorf("bad reloaded rates (-want, +got): %s", diff)
+	}
+
+	on, err := src.FetchOn(ctx, time.Date(2021, 10, 5, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("fetch on: %v", err)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-05", From: label.EUR, To: label.USD, Rate: "1.1593"},
+		{Date: "2021-10-05", From: label.EUR, To: label.JPY, Rate: "129.29"},
+	}
+	if diff := cmp.Diff(expected, findRates(on, label.EUR)); diff != "" {
+		t.Errorf("bad rates on date (-want, +got): %s", diff)
+	}
+
+	rng, err := src.FetchRange(
+		ctx, time.Date(2021, 10, 6, 0, 0, 0, 0, time.UTC), time.Date(2021, 10, 8, 0, 0, 0, 0, time.UTC),
+	)
+	if err != nil {
+		t.Fatalf("fetch range: %v", err)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-06", From: label.EUR, To: label.USD, Rate: "1.1554"},
+	}
+	if diff := cmp.Diff(expected, findRates(rng, label.EUR)); diff != "" {
+		t.Errorf("bad rates in range (-want, +got): %s", diff)
+	}
+
+	if _, err := src.FetchOn(ctx, time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, provider.ErrHistoryNotFound) {
+		t.Errorf("expected history not found, got: %v", err)
+	}
+
+	// the half-written file is skipped, the other files are still served
+	fsys["2021-10-07.json"] = &fstest.MapFile{Data: []byte(`{"base":"USD"`), ModTime: modTime}
+
+	latest, err = src.FetchLatest(ctx)
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-06", From: label.EUR, To: label.USD, Rate: "1.1554"},
+		{Date: "2021-10-05", From: label.EUR, To: label.JPY, Rate: "129.29"},
+	}
+	if diff := cmp.Diff(expected, findRates(latest, label.EUR)); diff != "" {
+		t.Errorf("bad rates with the broken file (-want, +got): %s", diff)
+	}
+
+	// the file is read again once written
+	fsys["2021-10-07.json"] = &fstest.MapFile{
+		Data:    []byte(`{"base":"EUR","date":"2021-10-07","rates":{"USD":"1.1555"}}`),
+		ModTime: modTime.Add(48 * time.Hour),
+	}
+
+	latest, err = src.FetchLatest(ctx)
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-07", From: label.EUR, To: label.USD, Rate: "1.1555"},
+		{Date: "2021-10-05", From: label.EUR, To: label.JPY, Rate: "129.29"},
+	}
+	if diff := cmp.Diff(expected, findRates(latest, label.EUR)); diff != "" {
+		t.Errorf("bad repaired rates (-want, +got): %s", diff)
+	}
+}
+
+func TestSource_FetchOnMixedDates(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// the ECB file of Friday and the CBR file of Saturday
+	fsys := fstest.MapFS{
+		"ecb.xml": {
+			Data: []byte(`<gesmes:Envelope><Cube><Cube time="2021-10-08">` +
+				`<Cube currency="USD" rate="1.1569"/></Cube></Cube></gesmes:Envelope>`),
+		},
+		"cbr.json": {Data: []byte(`{"base":"RUB","date":"2021-10-09","rates":{"USD":"0.0139"}}`)},
+	}
+
+	src := NewSource(fsys)
+
+	on, err := src.FetchOn(ctx, time.Date(2021, 10, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("fetch on: %v", err)
+	}
+
+	expected := []testRate{
+		{Date: "2021-10-08", From: label.EUR, To: label.USD, Rate: "1.1569"},
+	}
+	if diff := cmp.Diff(expected, findRates(on, label.EUR)); diff != "" {
+		t.Errorf("bad rates from EUR (-want, +got): %s", diff)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-09", From: label.RUB, To: label.USD, Rate: "0.0139"},
+	}
+	if diff := cmp.Diff(expected, findRates(on, label.RUB)); diff != "" {
+		t.Errorf("bad rates from RUB (-want, +got): %s", diff)
+	}
+
+	// the CBR rates are not in effect before their date
+	on, err = src.FetchOn(ctx, time.Date(2021, 10, 8, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("fetch on: %v", err)
+	}
+
+	if got := findRates(on, label.RUB); len(got) != 0 {
+		t.Errorf("expected no rates from RUB, got: %v", got)
+	}
+}
+
+func TestSource_BrokenFiles(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"README":    {Data: []byte("the daily exchange rates")},
+		"rates.tmp": {Data: []byte(`{"base":"EUR"`)},
+	}
+
+	src := NewSource(fsys)
+
+	// nothing to serve, the error of the files is returned
+	var decodeErr *provider.DecodeError
+	if _, err := src.FetchLatest(context.Background()); !errors.As(err, &decodeErr) {
+		t.Errorf("expected decode error, got: %v", err)
+	}
+
+	fsys["rates.xml"] = &fstest.MapFile{Data: []byte(testECBXML)}
+
+	latest, err := src.FetchLatest(context.Background())
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	if len(latest) == 0 {
+		t.Errorf("expected the rates of the valid file")
+	}
+}
+
+func TestSource_PollInterval(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	now := time.Date(2021, 10, 5, 16, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	fsys := fstest.MapFS{"rates.xml": {Data: []byte(testECBXML)}}
+	src := NewSource(fsys, WithPollInterval(time.Hour), WithClock(clock))
+
+	if _, err := src.FetchLatest(ctx); err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	// the files are not checked again within the interval
+	delete(fsys, "rates.xml")
+
+	latest, err := src.FetchLatest(ctx)
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	if len(latest) == 0 {
+		t.Errorf("expected cached rates")
+	}
+
+	// the files are checked again after the interval
+	now = now.Add(time.Hour)
+
+	if _, err := src.FetchLatest(ctx); !errors.Is(err, ErrNoRates) {
+		t.Errorf("expected no rates error, got: %v", err)
+	}
+}
+
+func TestSource_Exchangeable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"2021-10-05.json": {Data: []byte(`{"base":"EUR","date":"2021-10-05","rates":{"USD":"1.16"}}`)},
+	}
+	src := NewSource(fsys)
+
+	if diff := cmp.Diff([]label.Symbol{label.EUR, label.USD}, src.GetExchangeable()); diff != "" {
+		t.Errorf("bad exchangeable (-want, +got): %s", diff)
+	}
+
+	version := src.ExchangeableVersion()
+
+	// the currencies of the new file are read by the next fetch, not by GetExchangeable
+	fsys["2021-10-06.json"] = &fstest.MapFile{
+		Data:    []byte(`{"base":"EUR","date":"2021-10-06","rates":{"JPY":"129.29"}}`),
+		ModTime: time.Date(2021, 10, 6, 16, 0, 0, 0, time.UTC),
+	}
+
+	if diff := cmp.Diff([]label.Symbol{label.EUR, label.USD}, src.GetExchangeable()); diff != "" {
+		t.Errorf("bad exchangeable before fetch (-want, +got): %s", diff)
+	}
+
+	if _, err := src.FetchLatest(ctx); err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	if diff := cmp.Diff([]label.Symbol{label.EUR, label.USD, label.JPY}, src.GetExchangeable()); diff != "" {
+		t.Errorf("bad exchangeable after fetch (-want, +got): %s", diff)
+	}
+
+	if src.ExchangeableVersion() == version {
+		t.Errorf("version is not changed by the reload")
+	}
+
+	// the unchanged files keep the version
+	version = src.ExchangeableVersion()
+	if _, err := src.FetchLatest(ctx); err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	if diff := cmp.Diff(version, src.ExchangeableVersion()); diff != "" {
+		t.Errorf("bad version (-want, +got): %s", diff)
+	}
+}
+
+func TestSource_NoRates(t *testing.T) {
+	t.Parallel()
+
+	src := NewSource(fstest.MapFS{"rates.xml": {Data: []byte(testECBXML)}}, WithPattern("*.json"))
+	if _, err := src.FetchLatest(context.Background()); !errors.Is(err, ErrNoRates) {
+		t.Errorf("expected no rates error, got: %v", err)
+	}
+
+	if got := src.GetExchangeable(); len(got) != 0 {
+		t.Errorf("expected no exchangeable currencies, got: %v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"sort"
+	"time"
+
+	"github.com/robotomize/gokuu/label"
+)
+
+// DailyRates is a set of exchange rates published on the same date
+type DailyRates struct {
+	Time  time.Time
+	Rates []ExchangeRate
+}
+
+// History is a date-indexed list of exchange rates publications. Use NewHistory to build a sorted history
+type History []DailyRates
+
+// NewHistory groups the exchange rates by publication date and sorts them in ascending order
+func NewHistory(rates []ExchangeRate) History {
+	days := make(map[time.Time]int)
+	history := make(History, 0)
+
+	for _, r := range rates {
+		t := r.Time()
+		idx, ok := days[t]
+		if !ok {
+			idx = len(history)
+			days[t] = idx
+			history = append(history, DailyRates{Time: t})
+		}
+
+		history[idx].Rates = append(history[idx].Rates, r)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Time.Before(history[j].Time)
+	})
+
+	return history
+}
+
+// Dates returns the publication dates of the history
+func (h History) Dates() []time.Time {
+	dates := make([]time.Time, len(h))
+	for i := range h {
+		dates[i] = h[i].Time
+	}
+
+	return dates
+}
+
+// On returns the exchange rates in effect on the date: the last publication on or before the day
+func (h History) On(date time.Time) (DailyRates, bool) {
+	day := Day(date)
+	idx := sort.Search(len(h), func(i int) bool {
+		return h[i].Time.After(day)
+	})
+
+	if idx == 0 {
+		return DailyRates{}, false
+	}
+
+	return h[idx-1], true
+}
+
+// Range returns the publications between from and to inclusive
+func (h History) Range(from, to time.Time) History {
+	from, to = Day(from), Day(to)
+	start := sort.Search(len(h), func(i int) bool {
+		return !h[i].Time.Before(from)
+	})
+
+	end := sort.Search(len(h), func(i int) bool {
+		return h[i].Time.After(to)
+	})
+
+	if start >= end {
+		return History{}
+	}
+
+	return h[start:end]
+}
+
+// Flatten returns all exchange rates of the history
+func (h History) Flatten() []ExchangeRate {
+	var list []ExchangeRate
+	for _, day := range h {
+		list = append(list, day.Rates...)
+	}
+
+	return list
+}
+
+// Latest returns the exchange rate of every currency pair from the last publication that quoted it
+func (h History) Latest() []ExchangeRate {
+	type pair struct {
+		from, to label.Symbol
+	}
+
+	idx := make(map[pair]int)
+	var list []ExchangeRate
+	for _, day := range h {
+		for _, r := range day.Rates {
+			key := pair{from: r.From().Symbol, to: r.To().Symbol}
+			if i, ok := idx[key]; ok {
+				list[i] = r
+				continue
+			}
+
+			idx[key] = len(list)
+			list = append(list, r)
+		}
+	}
+
+	return list
+}
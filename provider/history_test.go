@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+)
+
+func TestHistory_On(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	days := []time.Time{
+		time.Date(2021, 6, 21, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 17, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 18, 0, 0, 0, 0, time.UTC),
+	}
+
+	rates := make([]ExchangeRate, 0, len(days))
+	for _, day := range days {
+		rate := NewMockExchangeRate(ctrl)
+		rate.EXPECT().Time().Return(day).AnyTimes()
+		rates = append(rates, rate)
+	}
+
+	history := NewHistory(rates)
+
+	testCases := []struct {
+		name     string
+		date     time.Time
+		expected time.Time
+		found    bool
+	}{
+		{
+			name:     "test_publication_day",
+			date:     time.Date(2021, 6, 18, 15, 30, 0, 0, time.UTC),
+			expected: days[2],
+			found:    true,
+		},
+		{
+			name:     "test_weekend",
+			date:     time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC),
+			expected: days[2],
+			found:    true,
+		},
+		{
+			name: "test_before_history",
+			date: time.Date(2021, 6, 16, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			day, ok := history.On(tc.date)
+			if diff := cmp.Diff(tc.found, ok); diff != "" {
+				t.Fatalf("bad expected (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(tc.expected, day.Time); diff != "" {
+				t.Errorf("bad expected (-want, +got): %s", diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff(2, len(history.Range(days[1], days[2]))); diff != "" {
+		t.Errorf("bad range (-want, +got): %s", diff)
+	}
+}
+
+func TestHistory_Latest(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	newRate := func(day time.Time, from, to label.Symbol) ExchangeRate {
+		rate := NewMockExchangeRate(ctrl)
+		rate.EXPECT().Time().Return(day).AnyTimes()
+		rate.EXPECT().From().Return(label.Currencies[from]).AnyTimes()
+		rate.EXPECT().To().Return(label.Currencies[to]).AnyTimes()
+
+		return rate
+	}
+
+	day1 := time.Date(2021, 6, 17, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2021, 6, 18, 0, 0, 0, 0, time.UTC)
+
+	history := NewHistory([]ExchangeRate{
+		newRate(day2, label.EUR, label.USD),
+		newRate(day1, label.EUR, label.USD),
+		newRate(day1, label.EUR, label.JPY),
+	})
+
+	type latestRate struct {
+		Time     time.Time
+		From, To label.Symbol
+	}
+
+	var got []latestRate
+	for _, r := range history.Latest() {
+		got = append(got, latestRate{Time: r.Time(), From: r.From().Symbol, To: r.To().Symbol})
+	}
+
+	expected := []latestRate{
+		{Time: day2, From: label.EUR, To: label.USD},
+		{Time: day1, From: label.EUR, To: label.JPY},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("bad latest rates (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,132 @@
+package httputil
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNotArchive     = errors.New("content is not a ZIP archive")
+	ErrMemberNotFound = errors.New("member not found in archive")
+)
+
+// Format the format of the content detected by the magic bytes
+type Format int
+
+const (
+	FormatPlain Format = iota
+	FormatGzip
+	FormatZIP
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatGzip:
+		return "gzip"
+	case FormatZIP:
+		return "zip"
+	default:
+		return "plain"
+	}
+}
+
+// Sniff detects the format of the content by the magic bytes, the content type of the servers is not reliable
+func Sniff(b []byte) Format {
+	switch {
+	case len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b:
+		return FormatGzip
+	case len(b) >= 4 && b[0] == 'P' && b[1] == 'K' &&
+		(b[2] == 0x03 && b[3] == 0x04 || b[2] == 0x05 && b[3] == 0x06):
+		// a local file header or the end of an empty archive
+		return FormatZIP
+	default:
+		return FormatPlain
+	}
+}
+
+// Member the file of the ZIP archive
+type Member struct {
+	Name string
+	Body []byte
+}
+
+// MemberSelector reports whether the member of the archive should be extracted
+type MemberSelector func(name string) bool
+
+// ByName selects the member with the base name, the directories in the archive are ignored
+func ByName(name string) MemberSelector {
+	return func(n string) bool {
+		return path.Base(n) == name
+	}
+}
+
+// ByExt selects the members with the extension, e.g. ".csv"
+func ByExt(ext string) MemberSelector {
+	return func(n string) bool {
+		return strings.EqualFold(path.Ext(n), ext)
+	}
+}
+
+// ReadArchive returns the files of the ZIP archive matching the selector in the order of the archive.
+// A nil selector returns all files
+func ReadArchive(b []byte, sel MemberSelector) ([]Member, error) {
+	if Sniff(b) != FormatZIP {
+		return nil, ErrNotArchive
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("zip.NewReader: %w", err)
+	}
+
+	var members []Member
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || sel != nil && !sel(f.Name) {
+			continue
+		}
+
+		body, err := readMember(f)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, Member{Name: f.Name, Body: body})
+	}
+
+	return members, nil
+}
+
+// Extract returns the first file of the ZIP archive matching the selector
+func Extract(b []byte, sel MemberSelector) ([]byte, error) {
+	members, err := ReadArchive(b, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(members) == 0 {
+		return nil, ErrMemberNotFound
+	}
+
+	return members[0].Body, nil
+}
+
+func readMember(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", f.Name, err)
+	}
+
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.Name, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,179 @@
+package httputil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testCSV = "Date, USD, JPY, \n18 June 2021, 1.1898, 131.12, \n"
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	return b
+}
+
+func TestSniff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		body     []byte
+		expected Format
+	}{
+		{
+			name:     "test_zip",
+			body:     readFixture(t, "eurofxref.zip"),
+			expected: FormatZIP,
+		},
+		{
+			name:     "test_empty_zip",
+			body:     []byte("PK\x05\x06\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"),
+			expected: FormatZIP,
+		},
+		{
+			name:     "test_gzip",
+			body:     readFixture(t, "eurofxref.csv.gz"),
+			expected: FormatGzip,
+		},
+		{
+			name:     "test_plain",
+			body:     []byte(testCSV),
+			expected: FormatPlain,
+		},
+		{
+			name:     "test_short",
+			body:     []byte("P"),
+			expected: FormatPlain,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.expected, Sniff(tc.body)); diff != "" {
+				t.Errorf("bad format (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		fixture     string
+		sel         MemberSelector
+		expectedErr error
+		expected    []string
+	}{
+		{
+			name:     "test_all_members",
+			fixture:  "multi.zip",
+			expected: []string{"README.txt", "rates/2021-06-17.csv", "rates/2021-06-18.csv"},
+		},
+		{
+			name:     "test_by_ext",
+			fixture:  "multi.zip",
+			sel:      ByExt(".CSV"),
+			expected: []string{"rates/2021-06-17.csv", "rates/2021-06-18.csv"},
+		},
+		{
+			name:     "test_by_name_in_dir",
+			fixture:  "multi.zip",
+			sel:      ByName("2021-06-18.csv"),
+			expected: []string{"rates/2021-06-18.csv"},
+		},
+		{
+			name:     "test_no_members",
+			fixture:  "eurofxref.zip",
+			sel:      ByExt(".xml"),
+			expected: nil,
+		},
+		{
+			name:        "test_not_archive",
+			fixture:     "eurofxref.csv.gz",
+			expectedErr: ErrNotArchive,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			members, err := ReadArchive(readFixture(t, tc.fixture), tc.sel)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("bad error, want %v, got %v", tc.expectedErr, err)
+			}
+
+			var names []string
+			for _, m := range members {
+				names = append(names, m.Name)
+			}
+
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("bad members (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		fixture     string
+		sel         MemberSelector
+		expectedErr error
+		expected    string
+	}{
+		{
+			name:     "test_by_name",
+			fixture:  "eurofxref.zip",
+			sel:      ByName("eurofxref.csv"),
+			expected: testCSV,
+		},
+		{
+			name:     "test_first_of_many",
+			fixture:  "multi.zip",
+			sel:      ByExt(".csv"),
+			expected: "Date, USD, JPY, \n17 June 2021, 1.1957, 131.84, \n",
+		},
+		{
+			name:        "test_member_not_found",
+			fixture:     "eurofxref.zip",
+			sel:         ByName("eurofxref-hist.csv"),
+			expectedErr: ErrMemberNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := Extract(readFixture(t, tc.fixture), tc.sel)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("bad error, want %v, got %v", tc.expectedErr, err)
+			}
+
+			if diff := cmp.Diff(tc.expected, string(b)); diff != "" {
+				t.Errorf("bad body (-want, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package httputil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CachedResponse the decoded body of a response with its validators
+type CachedResponse struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// ResponseCache stores the responses by URL for the conditional requests
+type ResponseCache interface {
+	Get(key string) (CachedResponse, bool)
+	Set(key string, resp CachedResponse) error
+}
+
+var _ ResponseCache = (*MemoryCache)(nil)
+
+// NewMemoryCache returns the in-memory response cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{items: make(map[string]CachedResponse)}
+}
+
+// MemoryCache the response cache living for the process lifetime
+type MemoryCache struct {
+	mtx   sync.RWMutex
+	items map[string]CachedResponse
+}
+
+func (c *MemoryCache) Get(key string) (CachedResponse, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	resp, ok := c.items[key]
+
+	return resp, ok
+}
+
+func (c *MemoryCache) Set(key string, resp CachedResponse) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.items[key] = resp
+
+	return nil
+}
+
+var _ ResponseCache = (*DiskCache)(nil)
+
+// NewDiskCache returns the response cache storing a file per URL in the dir
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+// DiskCache the response cache surviving restarts
+type DiskCache struct {
+	dir string
+}
+
+// Get returns the cached response, unreadable files are treated as missing
+func (c *DiskCache) Get(key string) (CachedResponse, bool) {
+	var resp CachedResponse
+
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return resp, false
+	}
+
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return resp, false
+	}
+
+	return resp, true
+}
+
+// Set writes the response to a temporary file and renames it so that readers never see a partial file
+func (c *DiskCache) Set(key string, resp CachedResponse) error {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	f, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("write temp file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), c.path(key)); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
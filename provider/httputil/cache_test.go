@@ -0,0 +1,62 @@
+package httputil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResponseCache(t *testing.T) {
+	t.Parallel()
+
+	disk, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("new disk cache: %v", err)
+	}
+
+	testCases := []struct {
+		name  string
+		cache ResponseCache
+	}{
+		{
+			name:  "test_memory",
+			cache: NewMemoryCache(),
+		},
+		{
+			name:  "test_disk",
+			cache: disk,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			const key = "https://cbr.ru/scripts/XML_daily.asp?date_req=30/07/2021"
+
+			if _, ok := tc.cache.Get(key); ok {
+				t.Fatalf("unexpected cached response")
+			}
+
+			expected := CachedResponse{ETag: `"v1"`, LastModified: "Fri, 30 Jul 2021 13:00:00 GMT", Body: []byte("rates")}
+			if err := tc.cache.Set(key, expected); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+
+			expected.Body = []byte("updated rates")
+			if err := tc.cache.Set(key, expected); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+
+			got, ok := tc.cache.Get(key)
+			if !ok {
+				t.Fatalf("cached response not found")
+			}
+
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Errorf("bad cached response (-want, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package httputil
 
 import (
+	"bufio"
 	"compress/gzip"
 	"context"
 	"errors"
@@ -8,38 +9,54 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 )
 
 const defaultUserAgent = "gokuu/0.0.0"
 
-var ErrStatusCode = errors.New("http status != 200")
+var (
+	ErrStatusCode = errors.New("http status != 200")
+	ErrNotCached  = errors.New("not modified response without cached body")
+)
+
+type Option func(*SourceHTTPClient)
+
+// WithResponseCache send the conditional requests with the validators of the cached responses.
+// A not modified response gives back the cached body
+func WithResponseCache(cache ResponseCache) Option {
+	return func(c *SourceHTTPClient) {
+		c.cache = cache
+	}
+}
 
 // DefaultSourceHTTPClient return preconfigured HTTP client
-func DefaultSourceHTTPClient() SourceHTTPClient {
-	return SourceHTTPClient{
-		client: &http.Client{
-			Transport: &http.Transport{
-				MaxIdleConns:          20000,
-				MaxIdleConnsPerHost:   1000,
-				DisableCompression:    true,
-				IdleConnTimeout:       5 * time.Minute,
-				TLSHandshakeTimeout:   10 * time.Second,
-				ExpectContinueTimeout: 1 * time.Second,
-				ResponseHeaderTimeout: 10 * time.Second,
-			},
+func DefaultSourceHTTPClient(opts ...Option) SourceHTTPClient {
+	return NewHTTPClient(&http.Client{
+		Transport: &http.Transport{
+			MaxIdleConns:          20000,
+			MaxIdleConnsPerHost:   1000,
+			DisableCompression:    true,
+			IdleConnTimeout:       5 * time.Minute,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
 		},
-	}
+	}, opts...)
 }
 
 // NewHTTPClient return prepared SourceHTTPClient
-func NewHTTPClient(client *http.Client) SourceHTTPClient {
-	return SourceHTTPClient{client: client}
+func NewHTTPClient(client *http.Client, opts ...Option) SourceHTTPClient {
+	c := SourceHTTPClient{client: client}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
 }
 
 type SourceHTTPClient struct {
 	client *http.Client
+	cache  ResponseCache
 }
 
 func (f SourceHTTPClient) UserAgent() string {
@@ -57,41 +74,88 @@ func (f SourceHTTPClient) fetch(ctx context.Context, u url.URL) ([]byte, error)
 		return nil, fmt.Errorf("build HTTP request: %w", err)
 	}
 
+	key := u.String()
+
+	var (
+		cached   CachedResponse
+		isCached bool
+	)
+
+	if f.cache != nil {
+		if cached, isCached = f.cache.Get(key); isCached {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+	}
+
 	resp, err := f.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("make HTTP request: %w", err)
 	}
 
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		if !isCached {
+			return nil, fmt.Errorf("%s: %w", key, ErrNotCached)
+		}
+
+		return cached.Body, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("http status: %d, %s: %w", resp.StatusCode, resp.Status, ErrStatusCode)
 	}
 
-	defer resp.Body.Close()
+	b, complete, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// the truncated body is not cached, the not modified responses would serve it until the upstream changes
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if f.cache != nil && complete && (etag != "" || lastModified != "") {
+		// the response is valid even if it could not be cached
+		_ = f.cache.Set(key, CachedResponse{ETag: etag, LastModified: lastModified, Body: b})
+	}
 
-	var reader io.ReadCloser
-	contentType := resp.Header.Get("Content-Type")
-	contentEncoding := resp.Header.Get("Content-Encoding")
-	switch {
-	case strings.Contains(contentType, "zip"), strings.Contains(contentType, "application/x-gzip"), strings.Contains(contentEncoding, "gzip"):
-		gz, err := gzip.NewReader(resp.Body)
+	return b, nil
+}
+
+// readBody reads the body decompressing the gzip content. The format is detected by the magic bytes,
+// ZIP archives are returned as is for the decoders to select the members with ReadArchive or Extract.
+// The body cut short is returned as read, complete reports whether it was read to the end
+func readBody(resp *http.Response) (b []byte, complete bool, err error) {
+	body := bufio.NewReader(resp.Body)
+
+	// a short body is detected as plain
+	magic, _ := body.Peek(4)
+
+	var reader io.Reader = body
+	if Sniff(magic) == FormatGzip {
+		gz, err := gzip.NewReader(body)
 		if err != nil {
-			return nil, fmt.Errorf("unable create gzip.NewReader: %w", err)
+			return nil, false, fmt.Errorf("unable create gzip.NewReader: %w", err)
 		}
 		reader = gz
-		defer reader.Close()
-
-	default:
-		reader = resp.Body
+		defer gz.Close()
 	}
 
-	b, err := io.ReadAll(reader)
+	b, err = io.ReadAll(reader)
 	if err != nil {
 		if !errors.Is(err, io.ErrUnexpectedEOF) {
-			return nil, fmt.Errorf("read body: %w", err)
+			return nil, false, fmt.Errorf("read body: %w", err)
 		}
+
+		return b, false, nil
 	}
 
-	return b, nil
+	return b, true, nil
 }
 
 func (f SourceHTTPClient) prepareRequest(ctx context.Context, u url.URL) (*http.Request, error) {
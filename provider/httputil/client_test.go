@@ -1,8 +1,17 @@
 package httputil
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 // Nice test check :)
@@ -14,3 +23,190 @@ func TestHTTPClient_UserAgent(t *testing.T) {
 		t.Errorf("user agent wrong")
 	}
 }
+
+func TestHTTPClient_ConditionalGet(t *testing.T) {
+	t.Parallel()
+
+	const (
+		etag         = `"v1"`
+		lastModified = "Fri, 30 Jul 2021 13:00:00 GMT"
+		body         = "<Envelope>rates</Envelope>"
+	)
+
+	testCases := []struct {
+		name     string
+		header   string
+		value    string
+		validate func(r *http.Request) bool
+	}{
+		{
+			name:   "test_etag",
+			header: "ETag",
+			value:  etag,
+			validate: func(r *http.Request) bool {
+				return r.Header.Get("If-None-Match") == etag
+			},
+		},
+		{
+			name:   "test_last_modified",
+			header: "Last-Modified",
+			value:  lastModified,
+			validate: func(r *http.Request) bool {
+				return r.Header.Get("If-Modified-Since") == lastModified
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var notModified int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.validate(r) {
+					atomic.AddInt32(&notModified, 1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+
+				w.Header().Set(tc.header, tc.value)
+				fmt.Fprint(w, body)
+			}))
+			defer ts.Close()
+
+			u, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("url parse: %v", err)
+			}
+
+			client := NewHTTPClient(ts.Client(), WithResponseCache(NewMemoryCache()))
+
+			for i := 0; i < 3; i++ {
+				b, err := client.Get(context.Background(), *u)
+				if err != nil {
+					t.Fatalf("get: %v", err)
+				}
+
+				if diff := cmp.Diff(body, string(b)); diff != "" {
+					t.Errorf("bad body (-want, +got): %s", diff)
+				}
+			}
+
+			if diff := cmp.Diff(int32(2), atomic.LoadInt32(&notModified)); diff != "" {
+				t.Errorf("bad not modified responses (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_TruncatedBodyNotCached(t *testing.T) {
+	t.Parallel()
+
+	const body = "<Envelope>rates</Envelope>"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", strconv.Itoa(2*len(body)))
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("url parse: %v", err)
+	}
+
+	cache := NewMemoryCache()
+	client := NewHTTPClient(ts.Client(), WithResponseCache(cache))
+
+	b, err := client.Get(context.Background(), *u)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if diff := cmp.Diff(body, string(b)); diff != "" {
+		t.Errorf("bad body (-want, +got): %s", diff)
+	}
+
+	if _, ok := cache.Get(u.String()); ok {
+		t.Errorf("truncated body cached")
+	}
+}
+
+func TestHTTPClient_NotModifiedWithoutCache(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("url parse: %v", err)
+	}
+
+	client := NewHTTPClient(ts.Client(), WithResponseCache(NewMemoryCache()))
+	if _, err := client.Get(context.Background(), *u); !errors.Is(err, ErrNotCached) {
+		t.Errorf("bad error, want %v, got %v", ErrNotCached, err)
+	}
+}
+
+func TestHTTPClient_ContentSniffing(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		fixture     string
+		contentType string
+		expected    []byte
+	}{
+		{
+			name:        "test_zip_as_gzip_content_type",
+			fixture:     "eurofxref.zip",
+			contentType: "application/x-gzip",
+			expected:    readFixture(t, "eurofxref.zip"),
+		},
+		{
+			name:        "test_gzip_as_zip_content_type",
+			fixture:     "eurofxref.csv.gz",
+			contentType: "application/zip",
+			expected:    []byte(testCSV),
+		},
+		{
+			name:        "test_gzip_as_octet_stream",
+			fixture:     "eurofxref.csv.gz",
+			contentType: "application/octet-stream",
+			expected:    []byte(testCSV),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := readFixture(t, tc.fixture)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				_, _ = w.Write(body)
+			}))
+			defer ts.Close()
+
+			u, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("url parse: %v", err)
+			}
+
+			b, err := NewHTTPClient(ts.Client()).Get(context.Background(), *u)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, b); diff != "" {
+				t.Errorf("bad body (-want, +got): %s", diff)
+			}
+		})
+	}
+}
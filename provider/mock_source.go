@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	decimal "github.com/robotomize/gokuu/decimal"
 	label "github.com/robotomize/gokuu/label"
 )
 
@@ -65,6 +66,154 @@ func (mr *MockSourceMockRecorder) GetExchangeable() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeable", reflect.TypeOf((*MockSource)(nil).GetExchangeable))
 }
 
+// MockHistoricalSource is a mock of HistoricalSource interface.
+type MockHistoricalSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoricalSourceMockRecorder
+}
+
+// MockHistoricalSourceMockRecorder is the mock recorder for MockHistoricalSource.
+type MockHistoricalSourceMockRecorder struct {
+	mock *MockHistoricalSource
+}
+
+// NewMockHistoricalSource creates a new mock instance.
+func NewMockHistoricalSource(ctrl *gomock.Controller) *MockHistoricalSource {
+	mock := &MockHistoricalSource{ctrl: ctrl}
+	mock.recorder = &MockHistoricalSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoricalSource) EXPECT() *MockHistoricalSourceMockRecorder {
+	return m.recorder
+}
+
+// FetchLatest mocks base method.
+func (m *MockHistoricalSource) FetchLatest(ctx context.Context) ([]ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLatest", ctx)
+	ret0, _ := ret[0].([]ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchLatest indicates an expected call of FetchLatest.
+func (mr *MockHistoricalSourceMockRecorder) FetchLatest(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLatest", reflect.TypeOf((*MockHistoricalSource)(nil).FetchLatest), ctx)
+}
+
+// FetchOn mocks base method.
+func (m *MockHistoricalSource) FetchOn(ctx context.Context, date time.Time) ([]ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchOn", ctx, date)
+	ret0, _ := ret[0].([]ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchOn indicates an expected call of FetchOn.
+func (mr *MockHistoricalSourceMockRecorder) FetchOn(ctx, date interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchOn", reflect.TypeOf((*MockHistoricalSource)(nil).FetchOn), ctx, date)
+}
+
+// FetchRange mocks base method.
+func (m *MockHistoricalSource) FetchRange(ctx context.Context, from, to time.Time) ([]ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchRange", ctx, from, to)
+	ret0, _ := ret[0].([]ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchRange indicates an expected call of FetchRange.
+func (mr *MockHistoricalSourceMockRecorder) FetchRange(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchRange", reflect.TypeOf((*MockHistoricalSource)(nil).FetchRange), ctx, from, to)
+}
+
+// GetExchangeable mocks base method.
+func (m *MockHistoricalSource) GetExchangeable() []label.Symbol {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeable")
+	ret0, _ := ret[0].([]label.Symbol)
+	return ret0
+}
+
+// GetExchangeable indicates an expected call of GetExchangeable.
+func (mr *MockHistoricalSourceMockRecorder) GetExchangeable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeable", reflect.TypeOf((*MockHistoricalSource)(nil).GetExchangeable))
+}
+
+// MockChangingSource is a mock of ChangingSource interface.
+type MockChangingSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockChangingSourceMockRecorder
+}
+
+// MockChangingSourceMockRecorder is the mock recorder for MockChangingSource.
+type MockChangingSourceMockRecorder struct {
+	mock *MockChangingSource
+}
+
+// NewMockChangingSource creates a new mock instance.
+func NewMockChangingSource(ctrl *gomock.Controller) *MockChangingSource {
+	mock := &MockChangingSource{ctrl: ctrl}
+	mock.recorder = &MockChangingSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChangingSource) EXPECT() *MockChangingSourceMockRecorder {
+	return m.recorder
+}
+
+// ExchangeableVersion mocks base method.
+func (m *MockChangingSource) ExchangeableVersion() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeableVersion")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// ExchangeableVersion indicates an expected call of ExchangeableVersion.
+func (mr *MockChangingSourceMockRecorder) ExchangeableVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeableVersion", reflect.TypeOf((*MockChangingSource)(nil).ExchangeableVersion))
+}
+
+// FetchLatest mocks base method.
+func (m *MockChangingSource) FetchLatest(ctx context.Context) ([]ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLatest", ctx)
+	ret0, _ := ret[0].([]ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchLatest indicates an expected call of FetchLatest.
+func (mr *MockChangingSourceMockRecorder) FetchLatest(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLatest", reflect.TypeOf((*MockChangingSource)(nil).FetchLatest), ctx)
+}
+
+// GetExchangeable mocks base method.
+func (m *MockChangingSource) GetExchangeable() []label.Symbol {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeable")
+	ret0, _ := ret[0].([]label.Symbol)
+	return ret0
+}
+
+// GetExchangeable indicates an expected call of GetExchangeable.
+func (mr *MockChangingSourceMockRecorder) GetExchangeable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeable", reflect.TypeOf((*MockChangingSource)(nil).GetExchangeable))
+}
+
 // MockExchangeRate is a mock of ExchangeRate interface.
 type MockExchangeRate struct {
 	ctrl     *gomock.Controller
@@ -143,3 +292,96 @@ func (mr *MockExchangeRateMockRecorder) To() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "To", reflect.TypeOf((*MockExchangeRate)(nil).To))
 }
+
+// MockDecimalExchangeRate is a mock of DecimalExchangeRate interface.
+type MockDecimalExchangeRate struct {
+	ctrl     *gomock.Controller
+	recorder *MockDecimalExchangeRateMockRecorder
+}
+
+// MockDecimalExchangeRateMockRecorder is the mock recorder for MockDecimalExchangeRate.
+type MockDecimalExchangeRateMockRecorder struct {
+	mock *MockDecimalExchangeRate
+}
+
+// NewMockDecimalExchangeRate creates a new mock instance.
+func NewMockDecimalExchangeRate(ctrl *gomock.Controller) *MockDecimalExchangeRate {
+	mock := &MockDecimalExchangeRate{ctrl: ctrl}
+	mock.recorder = &MockDecimalExchangeRateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDecimalExchangeRate) EXPECT() *MockDecimalExchangeRateMockRecorder {
+	return m.recorder
+}
+
+// Decimal mocks base method.
+func (m *MockDecimalExchangeRate) Decimal() decimal.Decimal {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decimal")
+	ret0, _ := ret[0].(decimal.Decimal)
+	return ret0
+}
+
+// Decimal indicates an expected call of Decimal.
+func (mr *MockDecimalExchangeRateMockRecorder) Decimal() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decimal", reflect.TypeOf((*MockDecimalExchangeRate)(nil).Decimal))
+}
+
+// From mocks base method.
+func (m *MockDecimalExchangeRate) From() label.Currency {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "From")
+	ret0, _ := ret[0].(label.Currency)
+	return ret0
+}
+
+// From indicates an expected call of From.
+func (mr *MockDecimalExchangeRateMockRecorder) From() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "From", reflect.TypeOf((*MockDecimalExchangeRate)(nil).From))
+}
+
+// Rate mocks base method.
+func (m *MockDecimalExchangeRate) Rate() float64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rate")
+	ret0, _ := ret[0].(float64)
+	return ret0
+}
+
+// Rate indicates an expected call of Rate.
+func (mr *MockDecimalExchangeRateMockRecorder) Rate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rate", reflect.TypeOf((*MockDecimalExchangeRate)(nil).Rate))
+}
+
+// Time mocks base method.
+func (m *MockDecimalExchangeRate) Time() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Time")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// Time indicates an expected call of Time.
+func (mr *MockDecimalExchangeRateMockRecorder) Time() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Time", reflect.TypeOf((*MockDecimalExchangeRate)(nil).Time))
+}
+
+// To mocks base method.
+func (m *MockDecimalExchangeRate) To() label.Currency {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "To")
+	ret0, _ := ret[0].(label.Currency)
+	return ret0
+}
+
+// To indicates an expected call of To.
+func (mr *MockDecimalExchangeRateMockRecorder) To() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "To", reflect.TypeOf((*MockDecimalExchangeRate)(nil).To))
+}
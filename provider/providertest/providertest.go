@@ -0,0 +1,365 @@
+// Package providertest provides the conformance suite for the provider.Source implementations.
+// Run it against a custom source before registering it in the exchanger
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+const (
+	// DefaultTimeout the time a call of FetchLatest must complete in
+	DefaultTimeout = 30 * time.Second
+	// DefaultConcurrency the number of concurrent calls of FetchLatest
+	DefaultConcurrency = 8
+	// DefaultCancelTimeout the time a call of FetchLatest blocked on a request must return in after the cancellation
+	DefaultCancelTimeout = time.Second
+)
+
+// inFlightDelay the time the blocked call of FetchLatest is given to send the request before the cancellation
+const inFlightDelay = 100 * time.Millisecond
+
+// DefaultTolerance the max relative difference of the product of the reciprocal rates from 1
+var DefaultTolerance = decimal.RequireFromString("0.000001")
+
+var (
+	ErrNoExchangeable   = errors.New("no exchangeable currencies")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrDuplicateSymbol  = errors.New("duplicate exchangeable currency")
+	ErrNoRates          = errors.New("no exchange rates")
+	ErrNotExchangeable  = errors.New("currency is not exchangeable")
+	ErrSameCurrency     = errors.New("exchange rate of the currency to itself")
+	ErrNonPositiveRate  = errors.New("exchange rate is not positive")
+	ErrZeroTime         = errors.New("exchange rate time is zero")
+	ErrNotReciprocal    = errors.New("exchange rates are not reciprocal")
+	ErrContextIgnored   = errors.New("context cancellation ignored")
+	ErrInconsistentCall = errors.New("concurrent calls returned different results")
+	ErrNotBlocked       = errors.New("call returned while the requests hang")
+)
+
+// errSkipped the check is not configured by the options
+var errSkipped = errors.New("check skipped")
+
+type Option func(*options)
+
+type options struct {
+	timeout       time.Duration
+	concurrency   int
+	tolerance     decimal.Decimal
+	hang          func() (release func())
+	cancelTimeout time.Duration
+}
+
+// WithTimeout set the time a call of FetchLatest must complete in
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithConcurrency set the number of concurrent calls of FetchLatest
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithTolerance set the max relative difference of the product of the reciprocal rates from 1.
+// The sources rounding the published rates need a larger tolerance
+func WithTolerance(tolerance decimal.Decimal) Option {
+	return func(o *options) {
+		o.tolerance = tolerance
+	}
+}
+
+// WithHang check that cancelling the context aborts the request in flight. The hang function makes the requests
+// of the source block until the release function it returns is called, e.g. with the Latency fault of the gokuutest
+// server. FetchLatest must return an error within the cancel timeout after the cancellation
+func WithHang(hang func() (release func()), cancelTimeout time.Duration) Option {
+	return func(o *options) {
+		o.hang = hang
+		o.cancelTimeout = cancelTimeout
+	}
+}
+
+type check struct {
+	name string
+	fn   func(ctx context.Context, o options, src provider.Source) error
+}
+
+var checks = []check{
+	{name: "exchangeable", fn: checkExchangeable},
+	{name: "rates", fn: checkRates},
+	{name: "reciprocal", fn: checkReciprocal},
+	{name: "context_cancellation", fn: checkCancellation},
+	{name: "in_flight_cancellation", fn: checkInFlightCancellation},
+	{name: "concurrent_calls", fn: checkConcurrency},
+}
+
+// Run checks that the source lists the known currencies only once, returns the positive and reciprocal-consistent
+// exchange rates with the publication time only between the exchangeable currencies, gives up on the cancelled
+// context and is safe for concurrent calls. The abort of the request in flight is checked if WithHang is set.
+// Run the tests with -race to detect the data races
+func Run(t *testing.T, src provider.Source, opts ...Option) {
+	t.Helper()
+
+	o := options{
+		timeout:       DefaultTimeout,
+		concurrency:   DefaultConcurrency,
+		tolerance:     DefaultTolerance,
+		cancelTimeout: DefaultCancelTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for _, c := range checks {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+			defer cancel()
+
+			if err := c.fn(ctx, o, src); err != nil {
+				if errors.Is(err, errSkipped) {
+					t.Skip(err)
+				}
+
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func checkExchangeable(_ context.Context, _ options, src provider.Source) error {
+	symbols := src.GetExchangeable()
+	if len(symbols) == 0 {
+		return ErrNoExchangeable
+	}
+
+	var result *multierror.Error
+
+	seen := make(map[label.Symbol]struct{}, len(symbols))
+	for _, symbol := range symbols {
+		if _, ok := label.Currencies[symbol]; !ok {
+			result = multierror.Append(result, fmt.Errorf("%w: %s", ErrUnknownCurrency, symbol))
+		}
+
+		if _, ok := seen[symbol]; ok {
+			result = multierror.Append(result, fmt.Errorf("%w: %s", ErrDuplicateSymbol, symbol))
+		}
+
+		seen[symbol] = struct{}{}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func checkRates(ctx context.Context, _ options, src provider.Source) error {
+	rates, err := fetchLatest(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	exchangeable := make(map[label.Symbol]struct{})
+	for _, symbol := range src.GetExchangeable() {
+		exchangeable[symbol] = struct{}{}
+	}
+
+	var result *multierror.Error
+	for _, r := range rates {
+		pair := r.From().Symbol.String() + "-" + r.To().Symbol.String()
+
+		for _, symbol := range []label.Symbol{r.From().Symbol, r.To().Symbol} {
+			if _, ok := exchangeable[symbol]; !ok {
+				result = multierror.Append(result, fmt.Errorf("%w: %s in %s", ErrNotExchangeable, symbol, pair))
+			}
+		}
+
+		if r.From().Symbol == r.To().Symbol {
+			result = multierror.Append(result, fmt.Errorf("%w: %s", ErrSameCurrency, pair))
+		}
+
+		if rate := provider.RateDecimal(r); rate.Sign() <= 0 {
+			result = multierror.Append(result, fmt.Errorf("%w: %s %s", ErrNonPositiveRate, pair, rate))
+		}
+
+		if r.Time().IsZero() {
+			result = multierror.Append(result, fmt.Errorf("%w: %s", ErrZeroTime, pair))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// checkReciprocal checks that the product of the rates of the pair in both directions is 1
+func checkReciprocal(ctx context.Context, o options, src provider.Source) error {
+	rates, err := fetchLatest(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	type pair struct {
+		from, to label.Symbol
+	}
+
+	index := make(map[pair]decimal.Decimal, len(rates))
+	for _, r := range rates {
+		index[pair{from: r.From().Symbol, to: r.To().Symbol}] = provider.RateDecimal(r)
+	}
+
+	one := decimal.New(1, 0)
+
+	var result *multierror.Error
+	for p, rate := range index {
+		if p.from >= p.to {
+			continue
+		}
+
+		inverse, ok := index[pair{from: p.to, to: p.from}]
+		if !ok {
+			continue
+		}
+
+		if diff := rate.Mul(inverse).Sub(one).Abs(); diff.Cmp(o.tolerance) > 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"%w: %s-%s %s, %s-%s %s", ErrNotReciprocal, p.from, p.to, rate, p.to, p.from, inverse,
+			))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// checkCancellation checks that FetchLatest returns an error in time if the context is cancelled
+func checkCancellation(ctx context.Context, o options, src provider.Source) error {
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := src.FetchLatest(cancelled)
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			return fmt.Errorf("%w: no error returned", ErrContextIgnored)
+		}
+	case <-ctx.Done():
+		return fmt.Errorf("%w: no result in %s", ErrContextIgnored, o.timeout)
+	}
+
+	return nil
+}
+
+// checkInFlightCancellation checks that FetchLatest blocked on the request returns an error soon after the context
+// is cancelled
+func checkInFlightCancellation(ctx context.Context, o options, src provider.Source) error {
+	if o.hang == nil {
+		return fmt.Errorf("%w: no hang configured, see WithHang", errSkipped)
+	}
+
+	release := o.hang()
+	defer release()
+
+	inFlight, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := src.FetchLatest(inFlight)
+		errCh <- err
+	}()
+
+	delay := time.NewTimer(inFlightDelay)
+	defer delay.Stop()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("%w: %v", ErrNotBlocked, err)
+	case <-delay.C:
+	}
+
+	cancel()
+
+	deadline := time.NewTimer(o.cancelTimeout)
+	defer deadline.Stop()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			return fmt.Errorf("%w: no error returned for the aborted request", ErrContextIgnored)
+		}
+	case <-deadline.C:
+		return fmt.Errorf("%w: request in flight not aborted in %s", ErrContextIgnored, o.cancelTimeout)
+	}
+
+	return nil
+}
+
+// checkConcurrency checks that the concurrent calls of FetchLatest succeed with the same number of rates
+func checkConcurrency(ctx context.Context, o options, src provider.Source) error {
+	var wg sync.WaitGroup
+
+	counts := make([]int, o.concurrency)
+	errs := make([]error, o.concurrency)
+
+	for i := 0; i < o.concurrency; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rates, err := fetchLatest(ctx, src)
+			counts[i], errs[i] = len(rates), err
+		}()
+	}
+
+	wg.Wait()
+
+	var result *multierror.Error
+
+	first := -1
+	for i, err := range errs {
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		if first == -1 {
+			first = counts[i]
+		}
+
+		if counts[i] != first {
+			result = multierror.Append(
+				result, fmt.Errorf("%w: %d and %d rates", ErrInconsistentCall, first, counts[i]),
+			)
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func fetchLatest(ctx context.Context, src provider.Source) ([]provider.ExchangeRate, error) {
+	rates, err := src.FetchLatest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch latest: %w", err)
+	}
+
+	if len(rates) == 0 {
+		return nil, ErrNoRates
+	}
+
+	return rates, nil
+}
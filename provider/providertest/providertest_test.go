@@ -0,0 +1,309 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/gokuutest"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+	"github.com/robotomize/gokuu/provider/cae"
+	"github.com/robotomize/gokuu/provider/ecb"
+	"github.com/robotomize/gokuu/provider/rcb"
+)
+
+var published = time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)
+
+type testRate struct {
+	from, to label.Symbol
+	rate     string
+	time     time.Time
+}
+
+func (r testRate) Time() time.Time          { return r.time }
+func (r testRate) From() label.Currency     { return label.Currencies[r.from] }
+func (r testRate) To() label.Currency       { return label.Currencies[r.to] }
+func (r testRate) Rate() float64            { return r.Decimal().Float64() }
+func (r testRate) Decimal() decimal.Decimal { return decimal.RequireFromString(r.rate) }
+
+// testSource the source returning the exchange rates, it checks the context unless ignoreCtx.
+// The calls block while the source hangs unless noHang
+type testSource struct {
+	symbols   []label.Symbol
+	rates     []provider.ExchangeRate
+	ignoreCtx bool
+	noHang    bool
+
+	mtx   sync.Mutex
+	calls int
+	block chan struct{}
+}
+
+func (s *testSource) hang() func() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.noHang {
+		return func() {}
+	}
+
+	block := make(chan struct{})
+	s.block = block
+
+	return func() {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		s.block = nil
+		close(block)
+	}
+}
+
+func (s *testSource) GetExchangeable() []label.Symbol {
+	return s.symbols
+}
+
+func (s *testSource) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, error) {
+	if !s.ignoreCtx {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mtx.Lock()
+	block := s.block
+	s.mtx.Unlock()
+
+	if block != nil {
+		if s.ignoreCtx {
+			<-block
+		} else {
+			select {
+			case <-block:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.calls++
+
+	return s.rates, nil
+}
+
+func TestRun_BuiltIn(t *testing.T) {
+	t.Parallel()
+
+	srv := gokuutest.NewServer(t)
+
+	base, err := url.Parse(srv.URL())
+	if err != nil {
+		t.Fatalf("unable to parse server url: %v", err)
+	}
+
+	endpoint := func(path string) url.URL {
+		u := *base
+		u.Path = path
+		return u
+	}
+
+	testCases := []struct {
+		name     string
+		provider string
+		source   provider.Source
+	}{
+		{
+			name:     "test_ecb",
+			provider: gokuu.ProviderNameECB,
+			source:   ecb.NewSource(srv.Client(), ecb.WithBaseURL(*base)),
+		},
+		{
+			name:     "test_rcb",
+			provider: gokuu.ProviderNameRCB,
+			source:   rcb.NewSource(srv.Client(), rcb.WithEndpoint(endpoint(gokuutest.PathRCBDaily))),
+		},
+		{
+			name:     "test_cae",
+			provider: gokuu.ProviderNameCAE,
+			source:   cae.NewSource(srv.Client(), cae.WithEndpoint(endpoint(gokuutest.PathCAERates))),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			paths := gokuutest.ProviderPaths(tc.provider)
+			hang := func() func() {
+				srv.SetFault(gokuutest.Fault{Latency: time.Hour}, paths...)
+
+				return func() {
+					srv.SetFault(gokuutest.Fault{}, paths...)
+				}
+			}
+
+			Run(t, tc.source, WithTimeout(10*time.Second), WithHang(hang, time.Second))
+		})
+	}
+}
+
+func TestChecks(t *testing.T) {
+	t.Parallel()
+
+	symbols := []label.Symbol{label.USD, label.RUB}
+	valid := []provider.ExchangeRate{
+		testRate{from: label.USD, to: label.RUB, rate: "72.9781", time: published},
+		testRate{from: label.RUB, to: label.USD, rate: "0.0137027409592741", time: published},
+	}
+
+	testCases := []struct {
+		name        string
+		check       func(ctx context.Context, o options, src provider.Source) error
+		source      *testSource
+		expectedErr error
+	}{
+		{
+			name:   "test_exchangeable",
+			check:  checkExchangeable,
+			source: &testSource{symbols: symbols},
+		},
+		{
+			name:        "test_exchangeable_empty",
+			check:       checkExchangeable,
+			source:      &testSource{},
+			expectedErr: ErrNoExchangeable,
+		},
+		{
+			name:        "test_exchangeable_unknown",
+			check:       checkExchangeable,
+			source:      &testSource{symbols: []label.Symbol{label.USD, "ABC"}},
+			expectedErr: ErrUnknownCurrency,
+		},
+		{
+			name:        "test_exchangeable_duplicate",
+			check:       checkExchangeable,
+			source:      &testSource{symbols: []label.Symbol{label.USD, label.RUB, label.USD}},
+			expectedErr: ErrDuplicateSymbol,
+		},
+		{
+			name:   "test_rates",
+			check:  checkRates,
+			source: &testSource{symbols: symbols, rates: valid},
+		},
+		{
+			name:        "test_rates_empty",
+			check:       checkRates,
+			source:      &testSource{symbols: symbols},
+			expectedErr: ErrNoRates,
+		},
+		{
+			name:  "test_rates_not_exchangeable",
+			check: checkRates,
+			source: &testSource{symbols: symbols, rates: []provider.ExchangeRate{
+				testRate{from: label.USD, to: label.EUR, rate: "0.84", time: published},
+			}},
+			expectedErr: ErrNotExchangeable,
+		},
+		{
+			name:  "test_rates_same_currency",
+			check: checkRates,
+			source: &testSource{symbols: symbols, rates: []provider.ExchangeRate{
+				testRate{from: label.USD, to: label.USD, rate: "1", time: published},
+			}},
+			expectedErr: ErrSameCurrency,
+		},
+		{
+			name:  "test_rates_zero",
+			check: checkRates,
+			source: &testSource{symbols: symbols, rates: []provider.ExchangeRate{
+				testRate{from: label.USD, to: label.RUB, rate: "0", time: published},
+			}},
+			expectedErr: ErrNonPositiveRate,
+		},
+		{
+			name:  "test_rates_zero_time",
+			check: checkRates,
+			source: &testSource{symbols: symbols, rates: []provider.ExchangeRate{
+				testRate{from: label.USD, to: label.RUB, rate: "72.9781"},
+			}},
+			expectedErr: ErrZeroTime,
+		},
+		{
+			name:   "test_reciprocal",
+			check:  checkReciprocal,
+			source: &testSource{symbols: symbols, rates: valid},
+		},
+		{
+			name:  "test_not_reciprocal",
+			check: checkReciprocal,
+			source: &testSource{symbols: symbols, rates: []provider.ExchangeRate{
+				testRate{from: label.USD, to: label.RUB, rate: "72.9781", time: published},
+				testRate{from: label.RUB, to: label.USD, rate: "0.0145", time: published},
+			}},
+			expectedErr: ErrNotReciprocal,
+		},
+		{
+			name:   "test_cancellation",
+			check:  checkCancellation,
+			source: &testSource{symbols: symbols, rates: valid},
+		},
+		{
+			name:        "test_cancellation_ignored",
+			check:       checkCancellation,
+			source:      &testSource{symbols: symbols, rates: valid, ignoreCtx: true},
+			expectedErr: ErrContextIgnored,
+		},
+		{
+			name:   "test_in_flight_cancellation",
+			check:  checkInFlightCancellation,
+			source: &testSource{symbols: symbols, rates: valid},
+		},
+		{
+			name:        "test_in_flight_cancellation_ignored",
+			check:       checkInFlightCancellation,
+			source:      &testSource{symbols: symbols, rates: valid, ignoreCtx: true},
+			expectedErr: ErrContextIgnored,
+		},
+		{
+			name:        "test_in_flight_not_blocked",
+			check:       checkInFlightCancellation,
+			source:      &testSource{symbols: symbols, rates: valid, noHang: true},
+			expectedErr: ErrNotBlocked,
+		},
+		{
+			name:   "test_concurrency",
+			check:  checkConcurrency,
+			source: &testSource{symbols: symbols, rates: valid},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := options{
+				timeout:       time.Second,
+				concurrency:   DefaultConcurrency,
+				tolerance:     DefaultTolerance,
+				hang:          tc.source.hang,
+				cancelTimeout: 100 * time.Millisecond,
+			}
+
+			err := tc.check(context.Background(), o, tc.source)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("bad error, want %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
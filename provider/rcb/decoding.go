@@ -3,6 +3,7 @@ package rcb
 import (
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 )
 
@@ -13,5 +14,5 @@ type rubLatestRates struct {
 
 type rubExchangeRate struct {
 	symbol label.Symbol
-	rate   float64
+	rate   decimal.Decimal
 }
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 	"github.com/robotomize/gokuu/provider"
 	"github.com/robotomize/gokuu/provider/httputil"
@@ -14,6 +15,9 @@ import (
 
 const hostname = "cbr.ru"
 
+// DefaultEndpoint the URL of the daily exchange rates XML of the Central Bank of Russia
+var DefaultEndpoint = url.URL{Scheme: "https", Host: hostname, Path: "scripts/XML_daily.asp"}
+
 var exchangeableSymbols = []label.Symbol{
 	label.RUB, label.AUD, label.AZN, label.GBP, label.AMD, label.BYN, label.BGN, label.BRL, label.HUF, label.HKD, label.DKK, label.USD,
 	label.EUR, label.INR, label.KZT, label.CAD, label.KGS, label.CNY, label.MDL, label.NOK, label.PLN, label.RON, label.XDR,
@@ -26,23 +30,57 @@ type fetcher struct {
 	httputil.SourceHTTPClient
 }
 
-var _ provider.Source = (*source)(nil)
+var _ provider.HistoricalSource = (*source)(nil)
+
+type Option func(*options)
+
+type options struct {
+	endpoint  url.URL
+	httpOpts  []httputil.Option
+	precision int32
+}
+
+// WithEndpoint request the exchange rates from the mirror of the daily exchange rates XML of the Central Bank of Russia.
+// The date query parameter is added to the endpoint
+func WithEndpoint(u url.URL) Option {
+	return func(o *options) {
+		o.endpoint = u
+	}
+}
+
+// WithResponseCache send the conditional requests and give back the cached body if the resource is not modified
+func WithResponseCache(cache httputil.ResponseCache) Option {
+	return func(o *options) {
+		o.httpOpts = append(o.httpOpts, httputil.WithResponseCache(cache))
+	}
+}
+
+// WithDivisionPrecision set the number of decimal places of the cross rates, decimal.DivisionPrecision by default
+func WithDivisionPrecision(places int32) Option {
+	return func(o *options) {
+		o.precision = places
+	}
+}
+
+func NewSource(client *http.Client, opts ...Option) *source {
+	o := options{endpoint: DefaultEndpoint, precision: decimal.DivisionPrecision}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-func NewSource(client *http.Client) *source {
 	return &source{
 		client: fetcher{
-			u: &url.URL{
-				Scheme: "https",
-				Host:   hostname,
-				Path:   "scripts/XML_daily.asp",
-			},
-			SourceHTTPClient: httputil.NewHTTPClient(client),
+			u:                &o.endpoint,
+			SourceHTTPClient: httputil.NewHTTPClient(client, o.httpOpts...),
 		},
+		precision: o.precision,
 	}
 }
 
 type source struct {
 	client fetcher
+	// precision the number of decimal places of the cross rates
+	precision int32
 }
 
 func (s *source) GetExchangeable() []label.Symbol {
@@ -50,7 +88,17 @@ func (s *source) GetExchangeable() []label.Symbol {
 }
 
 func (s *source) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, error) {
-	list, err := s.fetchingPlan(ctx)
+	list, err := s.fetchingPlan(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("fetching plan: %w", err)
+	}
+
+	return list, nil
+}
+
+// FetchOn returns the exchange rates set by the Central Bank of Russia for the date
+func (s *source) FetchOn(ctx context.Context, date time.Time) ([]provider.ExchangeRate, error) {
+	list, err := s.fetchingPlan(ctx, date)
 	if err != nil {
 		return nil, fmt.Errorf("fetching plan: %w", err)
 	}
@@ -58,44 +106,67 @@ func (s *source) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, erro
 	return list, nil
 }
 
-func (s *source) fetchingPlan(ctx context.Context) ([]provider.ExchangeRate, error) {
-	currDate := time.Now().UTC().Format("02/01/2006")
-	query := s.client.u.Query()
-	query.Add("date_req", currDate)
-	s.client.u.RawQuery = query.Encode()
+// FetchRange requests the exchange rates day by day, see provider.FetchDays
+func (s *source) FetchRange(ctx context.Context, from, to time.Time) ([]provider.ExchangeRate, error) {
+	list, err := provider.FetchDays(ctx, from, to, s.fetchingPlan)
+	if err != nil {
+		return nil, fmt.Errorf("fetch days: %w", err)
+	}
 
-	b, err := s.client.Get(ctx, *s.client.u)
+	return list, nil
+}
+
+func (s *source) fetchingPlan(ctx context.Context, date time.Time) ([]provider.ExchangeRate, error) {
+	u := *s.client.u
+	query := u.Query()
+	query.Set("date_req", date.Format("02/01/2006"))
+	u.RawQuery = query.Encode()
+
+	b, err := s.client.Get(ctx, u)
 	if err != nil {
 		return nil, fmt.Errorf("fetching: %w", err)
 	}
 
-	list, err := s.decode(b)
+	list, err := decode(b, s.precision)
+	if err != nil {
+		return nil, &provider.DecodeError{Err: err}
+	}
+
+	return list, nil
+}
+
+// Decode decodes the daily exchange rates XML of the Central Bank of Russia, e.g. the file saved for offline use.
+// It returns the cross rates of all currencies published on the date of the document rounded
+// to decimal.DivisionPrecision decimal places
+func Decode(b []byte) ([]provider.ExchangeRate, error) {
+	list, err := decode(b, decimal.DivisionPrecision)
 	if err != nil {
-		return nil, fmt.Errorf("decode: %w", err)
+		return nil, &provider.DecodeError{Err: err}
 	}
 
 	return list, nil
 }
 
-func (s *source) decode(b []byte) ([]provider.ExchangeRate, error) {
+func decode(b []byte, precision int32) ([]provider.ExchangeRate, error) {
 	var list []provider.ExchangeRate
 
-	rubSymRates := map[label.Symbol]float64{
-		label.RUB: 1,
+	rubSymRates := map[label.Symbol]decimal.Decimal{
+		label.RUB: decimal.New(1, 0),
 	}
 
-	rubExchangeRates, err := decodeXML(b)
+	rubExchangeRates, err := decodeXML(b, precision)
 	if err != nil {
 		return nil, fmt.Errorf("decode xml: %w", err)
 	}
 
+	// The bank publishes the ruble price of a currency unit
 	for _, r := range rubExchangeRates.rates {
-		rubSymRates[r.symbol] = 1 / r.rate
+		rubSymRates[r.symbol] = r.rate
 	}
 
 	rubExchangeRates.rates = append(
 		rubExchangeRates.rates,
-		rubExchangeRate{symbol: label.RUB, rate: 1},
+		rubExchangeRate{symbol: label.RUB, rate: decimal.New(1, 0)},
 	)
 
 	for _, sym := range rubExchangeRates.rates {
@@ -115,7 +186,7 @@ func (s *source) decode(b []byte) ([]provider.ExchangeRate, error) {
 					time: rubExchangeRates.time,
 					from: ccy,
 					to:   ccy1,
-					rate: rubSymRates[ccy1.Symbol] / rubSymRates[ccy.Symbol],
+					rate: rubSymRates[ccy.Symbol].Div(rubSymRates[ccy1.Symbol], precision),
 				}
 
 				list = append(list, rate)
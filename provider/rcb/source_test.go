@@ -15,6 +15,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
 	"github.com/robotomize/gokuu/provider/httputil"
 )
 
@@ -102,12 +103,12 @@ func TestSource_FetchLatest(t *testing.T) {
 				{
 					from: label.Currencies[label.AUD],
 					to:   label.Currencies[label.AZN],
-					rate: 1.2572605824251075,
+					rate: 1.2572605824251077,
 				},
 				{
 					from: label.Currencies[label.AUD],
 					to:   label.Currencies[label.GBP],
-					rate: 0.5300481204449746,
+					rate: 0.5300481204449747,
 				},
 				{
 					from: label.Currencies[label.AUD],
@@ -117,7 +118,7 @@ func TestSource_FetchLatest(t *testing.T) {
 				{
 					from: label.Currencies[label.AZN],
 					to:   label.Currencies[label.AUD],
-					rate: 0.7953800620004469,
+					rate: 0.7953800620004468,
 				},
 				{
 					from: label.Currencies[label.AZN],
@@ -215,12 +216,12 @@ ValCurs>`))
 				{
 					from: label.Currencies[label.AUD],
 					to:   label.Currencies[label.AZN],
-					rate: 1.2572605824251075,
+					rate: 1.2572605824251077,
 				},
 				{
 					from: label.Currencies[label.AUD],
 					to:   label.Currencies[label.GBP],
-					rate: 0.5300481204449746,
+					rate: 0.5300481204449747,
 				},
 				{
 					from: label.Currencies[label.AUD],
@@ -230,7 +231,7 @@ ValCurs>`))
 				{
 					from: label.Currencies[label.AZN],
 					to:   label.Currencies[label.AUD],
-					rate: 0.7953800620004469,
+					rate: 0.7953800620004468,
 				},
 				{
 					from: label.Currencies[label.AZN],
@@ -323,6 +324,10 @@ ValCurs>`))
 					t.Errorf("fetch latest rates: %v", err)
 				}
 
+				if errors.Is(tc.err, errDecodeToken) != provider.IsDecodeError(err) {
+					t.Errorf("bad decode error: %v", err)
+				}
+
 				return
 			}
 
@@ -349,3 +354,147 @@ ValCurs>`))
 		})
 	}
 }
+
+func TestSource_FetchRange(t *testing.T) {
+	t.Parallel()
+
+	// The bank publishes on 30.07.2021 (Friday) and answers with the same rates for the weekend
+	published := map[string]string{
+		"29/07/2021": "29.07.2021",
+		"30/07/2021": "30.07.2021",
+		"31/07/2021": "30.07.2021",
+		"01/08/2021": "30.07.2021",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(strPattern, func(w http.ResponseWriter, r *http.Request) {
+		date, ok := published[r.URL.Query().Get("date_req")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, `<ValCurs Date="%s" name="Foreign Currency Market">
+    <Valute ID="R01235">
+        <NumCode>840</NumCode>
+        <CharCode>USD</CharCode>
+        <Nominal>1</Nominal>
+        <Name>Доллар США</Name>
+        <Value>72,9781</Value>
+    </Valute>
+</ValCurs>`, date)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + strPattern)
+	if err != nil {
+		t.Fatalf("unable to parse url: %v", err)
+	}
+
+	source := NewSource(srv.Client(), WithEndpoint(*u))
+
+	from, err := parseDatetime("29.07.2021")
+	if err != nil {
+		t.Fatalf("datetime in test data invalid")
+	}
+
+	rates, err := source.FetchRange(context.Background(), from, from.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("fetch range: %v", err)
+	}
+
+	dates := make(map[time.Time]int)
+	for _, r := range rates {
+		dates[r.Time()]++
+	}
+
+	if diff := cmp.Diff(2, len(dates)); diff != "" {
+		t.Errorf("bad publication dates (-want, +got):\n%s", diff)
+	}
+
+	onDate, err := source.FetchOn(context.Background(), from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("fetch on: %v", err)
+	}
+
+	friday := from.AddDate(0, 0, 1)
+	for _, r := range onDate {
+		if diff := cmp.Diff(friday, r.Time()); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+	}
+}
+
+func TestSource_FetchLatestNominal(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(strPattern, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<ValCurs Date="30.07.2021" name="Foreign Currency Market">
+    <Valute ID="R01235">
+        <NumCode>840</NumCode>
+        <CharCode>USD</CharCode>
+        <Nominal>1</Nominal>
+        <Name>Доллар США</Name>
+        <Value>72,9781</Value>
+    </Valute>
+    <Valute ID="R01820">
+        <NumCode>392</NumCode>
+        <CharCode>JPY</CharCode>
+        <Nominal>100</Nominal>
+        <Name>Японских иен</Name>
+        <Value>66,4738</Value>
+    </Valute>
+    <Valute ID="R01717">
+        <NumCode>860</NumCode>
+        <CharCode>UZS</CharCode>
+        <Nominal>10000</Nominal>
+        <Name>Узбекских сумов</Name>
+        <Value>68,6264</Value>
+    </Valute>
+</ValCurs>`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + strPattern)
+	if err != nil {
+		t.Fatalf("unable to parse url: %v", err)
+	}
+
+	source := NewSource(srv.Client(), WithEndpoint(*u))
+
+	rates, err := source.FetchLatest(context.Background())
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	expected := map[label.Symbol]map[label.Symbol]float64{
+		label.USD: {label.JPY: 72.9781 / 0.664738, label.UZS: 72.9781 / 0.00686264},
+		label.JPY: {label.RUB: 0.664738, label.USD: 0.664738 / 72.9781},
+		label.UZS: {label.RUB: 0.00686264},
+		label.RUB: {label.JPY: 1 / 0.664738, label.UZS: 1 / 0.00686264},
+	}
+
+	found := 0
+	for _, r := range rates {
+		v, ok := expected[r.From().Symbol][r.To().Symbol]
+		if !ok {
+			continue
+		}
+
+		found++
+		if diff := cmp.Diff(v, r.Rate(), cmpopts.EquateApprox(1e-9, 0)); diff != "" {
+			t.Errorf("test %s-%s, mismatch (-want, +got):\n%s", r.From().Symbol, r.To().Symbol, diff)
+		}
+	}
+
+	if diff := cmp.Diff(7, found); diff != "" {
+		t.Errorf("bad expected (-want, +got): %s", diff)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 	"golang.org/x/text/encoding/charmap"
 )
@@ -24,8 +25,9 @@ var xmlNodePool = sync.Pool{
 	New: func() interface{} { return &XMLNode{} },
 }
 
-// decodeXML returns the decoding function. decodeXML parses xml in streaming mode and returns currency pairs by date
-func decodeXML(b []byte) (rubLatestRates, error) {
+// decodeXML returns the decoding function. decodeXML parses xml in streaming mode and returns currency pairs by date.
+// The rates per unit are rounded to the precision decimal places
+func decodeXML(b []byte, precision int32) (rubLatestRates, error) {
 	var dailyRates rubLatestRates
 	decoder := xml.NewDecoder(bytes.NewReader(b))
 	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
@@ -76,23 +78,29 @@ TokenLoop:
 				dailyRates.time = time.Time(currNode.Time)
 
 				for _, r := range currNode.Rates {
-					v, err := strconv.ParseFloat(strings.Replace(r.Value, ",", ".", -1), 64)
+					v, err := decimal.NewFromString(strings.TrimSpace(strings.Replace(r.Value, ",", ".", -1)))
 					if err != nil {
-						return dailyRates, fmt.Errorf("strconv.ParseFloat: %w", err)
+						return dailyRates, fmt.Errorf("decimal.NewFromString: %w", err)
 					}
 
-					if v <= 0 {
+					if v.Sign() <= 0 {
 						return dailyRates, errAttributeNotValid
 					}
 
+					nominal, err := r.nominal()
+					if err != nil {
+						return dailyRates, err
+					}
+
 					if _, ok := label.Currencies[r.Currency]; !ok {
 						continue
 					}
 
+					// The bank quotes some currencies per 10, 100 or 10000 units, rates are normalized per unit
 					dailyRates.rates = append(
 						dailyRates.rates, rubExchangeRate{
 							symbol: r.Currency,
-							rate:   v,
+							rate:   v.Div(decimal.New(nominal, 0), precision),
 						},
 					)
 				}
@@ -121,10 +129,30 @@ func (x *XMLAttrTime) UnmarshalXMLAttr(attr xml.Attr) error {
 
 type XMLCcyRate struct {
 	Currency label.Symbol `xml:"CharCode"`
+	Nominal  string       `xml:"Nominal"`
 	Value    string       `xml:"Value"`
 	Rate     float64
 }
 
+// nominal returns the number of currency units the value is quoted for. The missing element means a single unit
+func (x XMLCcyRate) nominal() (int64, error) {
+	token := strings.TrimSpace(x.Nominal)
+	if token == "" {
+		return 1, nil
+	}
+
+	n, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: nominal %q", errAttributeNotValid, x.Nominal)
+	}
+
+	if n <= 0 {
+		return 0, errAttributeNotValid
+	}
+
+	return n, nil
+}
+
 type XMLNode struct {
 	Time  XMLAttrTime  `xml:"Date,attr"`
 	Rates []XMLCcyRate `xml:"Valute"`
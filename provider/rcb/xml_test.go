@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 )
 
@@ -102,7 +103,7 @@ func TestDataMatchingDecodeXML(t *testing.T) {
 			tc.name, func(t *testing.T) {
 				t.Parallel()
 
-				result, err := decodeXML(tc.bytes)
+				result, err := decodeXML(tc.bytes, decimal.DivisionPrecision)
 				if err != nil {
 					t.Fatalf("decoding XML: %v", err)
 				}
@@ -122,7 +123,7 @@ func TestDataMatchingDecodeXML(t *testing.T) {
 						t.Errorf("can not find symbol %s in result set", r.symbol.String())
 					}
 
-					if diff := cmp.Diff(v, r.rate); diff != "" {
+					if diff := cmp.Diff(v, r.rate.Float64()); diff != "" {
 						t.Errorf("bad csv (-want, +got): %s", diff)
 					}
 				}
@@ -254,7 +255,7 @@ func TestAttrValidationDecodeXML(t *testing.T) {
 			tc.name, func(t *testing.T) {
 				t.Parallel()
 
-				if _, err := decodeXML(tc.bytes); err != nil && tc.err == nil {
+				if _, err := decodeXML(tc.bytes, decimal.DivisionPrecision); err != nil && tc.err == nil {
 					t.Errorf("got: %v, want: %v", err, tc.err)
 				}
 			},
@@ -341,7 +342,7 @@ func TestXMLMarkupDecodeXML(t *testing.T) {
 			tc.name, func(t *testing.T) {
 				t.Parallel()
 
-				_, err := decodeXML(tc.bytes)
+				_, err := decodeXML(tc.bytes, decimal.DivisionPrecision)
 
 				if !errors.Is(err, tc.err) {
 					diff := cmp.Diff(tc.err, err, cmpopts.EquateErrors())
@@ -351,3 +352,140 @@ func TestXMLMarkupDecodeXML(t *testing.T) {
 		)
 	}
 }
+
+func TestNominalDecodeXML(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected map[label.Symbol]float64
+		bytes    []byte
+	}{
+		{
+			name: "test_high_nominal",
+			expected: map[label.Symbol]float64{
+				label.USD: 72.9781,
+				label.JPY: 0.664738,
+				label.HUF: 0.241046,
+				label.KZT: 0.171442,
+				label.UZS: 0.00686264,
+				label.AMD: 0.148478,
+			},
+			bytes: []byte(`<ValCurs Date="30.07.2021" name="Foreign Currency Market">
+    <Valute ID="R01235">
+        <NumCode>840</NumCode>
+        <CharCode>USD</CharCode>
+        <Nominal>1</Nominal>
+        <Name>Доллар США</Name>
+        <Value>72,9781</Value>
+    </Valute>
+    <Valute ID="R01820">
+        <NumCode>392</NumCode>
+        <CharCode>JPY</CharCode>
+        <Nominal>100</Nominal>
+        <Name>Японских иен</Name>
+        <Value>66,4738</Value>
+    </Valute>
+    <Valute ID="R01135">
+        <NumCode>348</NumCode>
+        <CharCode>HUF</CharCode>
+        <Nominal>100</Nominal>
+        <Name>Венгерских форинтов</Name>
+        <Value>24,1046</Value>
+    </Valute>
+    <Valute ID="R01335">
+        <NumCode>398</NumCode>
+        <CharCode>KZT</CharCode>
+        <Nominal>100</Nominal>
+        <Name>Казахстанских тенге</Name>
+        <Value>17,1442</Value>
+    </Valute>
+    <Valute ID="R01717">
+        <NumCode>860</NumCode>
+        <CharCode>UZS</CharCode>
+        <Nominal>10000</Nominal>
+        <Name>Узбекских сумов</Name>
+        <Value>68,6264</Value>
+    </Valute>
+    <Valute ID="R01060">
+        <NumCode>051</NumCode>
+        <CharCode>AMD</CharCode>
+        <Nominal>100</Nominal>
+        <Name>Армянских драмов</Name>
+        <Value>14,8478</Value>
+    </Valute>
+</ValCurs>`),
+		},
+		{
+			name: "test_missing_nominal",
+			expected: map[label.Symbol]float64{
+				label.USD: 72.9781,
+			},
+			bytes: []byte(`<ValCurs Date="30.07.2021" name="Foreign Currency Market">
+    <Valute ID="R01235">
+        <NumCode>840</NumCode>
+        <CharCode>USD</CharCode>
+        <Name>Доллар США</Name>
+        <Value>72,9781</Value>
+    </Valute>
+</ValCurs>`),
+		},
+		{
+			name: "test_invalid_nominal_0",
+			err:  errAttributeNotValid,
+			bytes: []byte(`<ValCurs Date="30.07.2021" name="Foreign Currency Market">
+    <Valute ID="R01820">
+        <NumCode>392</NumCode>
+        <CharCode>JPY</CharCode>
+        <Nominal>0</Nominal>
+        <Name>Японских иен</Name>
+        <Value>66,4738</Value>
+    </Valute>
+</ValCurs>`),
+		},
+		{
+			name: "test_invalid_nominal_1",
+			err:  errAttributeNotValid,
+			bytes: []byte(`<ValCurs Date="30.07.2021" name="Foreign Currency Market">
+    <Valute ID="R01820">
+        <NumCode>392</NumCode>
+        <CharCode>JPY</CharCode>
+        <Nominal>hundred</Nominal>
+        <Name>Японских иен</Name>
+        <Value>66,4738</Value>
+    </Valute>
+</ValCurs>`),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(
+			tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				result, err := decodeXML(tc.bytes, decimal.DivisionPrecision)
+				if !errors.Is(err, tc.err) {
+					diff := cmp.Diff(tc.err, err, cmpopts.EquateErrors())
+					t.Fatalf("mismatch (-want, +got):\n%s", diff)
+				}
+
+				if diff := cmp.Diff(len(tc.expected), len(result.rates)); diff != "" {
+					t.Errorf("bad xml (-want, +got): %s", diff)
+				}
+
+				for _, r := range result.rates {
+					v, ok := tc.expected[r.symbol]
+					if !ok {
+						t.Errorf("can not find symbol %s in result set", r.symbol.String())
+					}
+
+					if diff := cmp.Diff(v, r.rate.Float64()); diff != "" {
+						t.Errorf("bad xml (-want, +got): %s", diff)
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,149 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+var errBadHeader = errors.New("bad csv header")
+
+// csvHeader the columns of the CSV snapshot. The providers are separated by the semicolon
+var csvHeader = []string{"date", "from", "to", "rate", "source", "providers", "stale", "fetched_at"}
+
+const csvProvidersSep = ";"
+
+func encodeCSV(w io.Writer, s Snapshot) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("encode csv: %w", err)
+	}
+
+	fetchedAt := formatTime(s.FetchedAt)
+	for _, r := range s.Rates {
+		if err := cw.Write([]string{
+			formatTime(r.Date),
+			string(r.From),
+			string(r.To),
+			r.Rate.String(),
+			r.Source,
+			strings.Join(r.Providers, csvProvidersSep),
+			strconv.FormatBool(r.Stale),
+			fetchedAt,
+		}); err != nil {
+			return fmt.Errorf("encode csv: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("encode csv: %w", err)
+	}
+
+	return nil
+}
+
+func decodeCSV(b []byte) (Snapshot, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))))
+	header, err := cr.Read()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("read header: %w", err)
+	}
+
+	if len(header) != len(csvHeader) {
+		return Snapshot{}, fmt.Errorf("%w: %d columns, want %d", errBadHeader, len(header), len(csvHeader))
+	}
+
+	for i, name := range csvHeader {
+		if strings.TrimSpace(header[i]) != name {
+			return Snapshot{}, fmt.Errorf("%w: column %d is %q, want %q", errBadHeader, i+1, header[i], name)
+		}
+	}
+
+	s := Snapshot{Rates: make([]Rate, 0)}
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return Snapshot{}, fmt.Errorf("read record: %w", err)
+		}
+
+		r, fetchedAt, err := parseCSVRecord(record)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("record %d: %w", row, err)
+		}
+
+		if fetchedAt.After(s.FetchedAt) {
+			s.FetchedAt = fetchedAt
+		}
+
+		s.Rates = append(s.Rates, r)
+	}
+
+	return s, nil
+}
+
+func parseCSVRecord(record []string) (Rate, time.Time, error) {
+	date, err := parseTime(record[0])
+	if err != nil {
+		return Rate{}, time.Time{}, fmt.Errorf("parse date: %w", err)
+	}
+
+	rate, err := decimal.NewFromString(record[3])
+	if err != nil {
+		return Rate{}, time.Time{}, fmt.Errorf("parse rate: %w", err)
+	}
+
+	stale, err := strconv.ParseBool(record[6])
+	if err != nil {
+		return Rate{}, time.Time{}, fmt.Errorf("parse stale: %w", err)
+	}
+
+	fetchedAt, err := parseTime(record[7])
+	if err != nil {
+		return Rate{}, time.Time{}, fmt.Errorf("parse fetch time: %w", err)
+	}
+
+	r := Rate{
+		From:   label.Symbol(record[1]),
+		To:     label.Symbol(record[2]),
+		Rate:   rate,
+		Date:   date,
+		Source: record[4],
+		Stale:  stale,
+	}
+
+	if record[5] != "" {
+		r.Providers = strings.Split(record[5], csvProvidersSep)
+	}
+
+	return r, fetchedAt, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseTime parses the time written by formatTime, the empty string is the zero time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -0,0 +1,31 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func encodeJSON(w io.Writer, s Snapshot) error {
+	if s.Rates == nil {
+		s.Rates = make([]Rate, 0)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+
+	return nil
+}
+
+func decodeJSON(b []byte) (Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Snapshot{}, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,163 @@
+// Package snapshot is the file format of the exchange rates exported by gokuu. The snapshots are written
+// in JSON, CSV or the ECB gesmes XML and can be replayed offline as a source of exchange rates
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown snapshot format")
+	ErrInvalidRate   = errors.New("invalid exchange rate")
+	// ErrNoEURRate is returned by Encode in FormatXML for the currencies with no exchange rate from EUR
+	// on the day, directly or through the other currencies
+	ErrNoEURRate = errors.New("no exchange rate from EUR")
+)
+
+// Format the encoding of the snapshot
+type Format byte
+
+const (
+	FormatJSON Format = iota
+	// FormatCSV one exchange rate per row with the header
+	FormatCSV
+	// FormatXML the ECB gesmes document. Only the exchange rates from EUR can be written in it, the rates
+	// between other currencies are crossed with the rates to EUR of the same day. The sources are not kept
+	FormatXML
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatCSV:
+		return "csv"
+	case FormatXML:
+		return "xml"
+	default:
+		return fmt.Sprintf("format(%d)", byte(f))
+	}
+}
+
+// Snapshot the exchange rates fetched at once
+type Snapshot struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Rates     []Rate    `json:"rates"`
+}
+
+// Rate the exchange rate of the currency pair
+type Rate struct {
+	From label.Symbol    `json:"from"`
+	To   label.Symbol    `json:"to"`
+	Rate decimal.Decimal `json:"rate"`
+	// Date the publication date of the exchange rate
+	Date time.Time `json:"date"`
+	// Source the provider the exchange rate was taken from
+	Source string `json:"source,omitempty"`
+	// Providers the providers whose quotes took part in the exchange rate
+	Providers []string `json:"providers,omitempty"`
+	Stale     bool     `json:"stale,omitempty"`
+}
+
+// validate checks the currency pair and the rate
+func (r Rate) validate() error {
+	if len(r.From) != 3 || len(r.To) != 3 || r.Rate.Sign() <= 0 {
+		return fmt.Errorf("%w: %s-%s %s", ErrInvalidRate, r.From, r.To, r.Rate)
+	}
+
+	return nil
+}
+
+// Encode writes the snapshot in the format. The exchange rates are sorted by date and currency pair,
+// so the same snapshot is always encoded the same way
+func Encode(w io.Writer, s Snapshot, format Format) error {
+	rates := make([]Rate, len(s.Rates))
+	copy(rates, s.Rates)
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		if !rates[i].Date.Equal(rates[j].Date) {
+			return rates[i].Date.Before(rates[j].Date)
+		}
+
+		if rates[i].From != rates[j].From {
+			return rates[i].From < rates[j].From
+		}
+
+		return rates[i].To < rates[j].To
+	})
+
+	s.Rates = rates
+
+	switch format {
+	case FormatJSON:
+		return encodeJSON(w, s)
+	case FormatCSV:
+		return encodeCSV(w, s)
+	case FormatXML:
+		return encodeXML(w, s)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// Decode reads the snapshot in any of the formats, the format is detected by the content
+func Decode(r io.Reader) (Snapshot, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("read: %w", err)
+	}
+
+	format, err := detect(b)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var s Snapshot
+	switch format {
+	case FormatJSON:
+		s, err = decodeJSON(b)
+	case FormatCSV:
+		s, err = decodeCSV(b)
+	default:
+		s, err = decodeXML(b)
+	}
+
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("decode %s: %w", format, err)
+	}
+
+	for _, r := range s.Rates {
+		if err := r.validate(); err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	return s, nil
+}
+
+// detect returns the format of the document by its first significant character
+func detect(b []byte) (Format, error) {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	b = bytes.TrimSpace(b)
+
+	if len(b) == 0 {
+		return 0, fmt.Errorf("%w: empty document", ErrUnknownFormat)
+	}
+
+	switch b[0] {
+	case '{':
+		return FormatJSON, nil
+	case '<':
+		return FormatXML, nil
+	default:
+		return FormatCSV, nil
+	}
+}
@@ -0,0 +1,292 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+var (
+	day1 = time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+	day2 = time.Date(2021, 10, 5, 0, 0, 0, 0, time.UTC)
+)
+
+func testSnapshot() Snapshot {
+	return Snapshot{
+		FetchedAt: time.Date(2021, 10, 5, 16, 30, 0, 0, time.UTC),
+		Rates: []Rate{
+			{
+				From:      label.EUR,
+				To:        label.USD,
+				Rate:      decimal.RequireFromString("1.1593"),
+				Date:      day2,
+				Source:    "ecb",
+				Providers: []string{"ecb", "cae"},
+			},
+			{
+				From:      label.USD,
+				To:        label.RUB,
+				Rate:      decimal.RequireFromString("72.5048"),
+				Date:      day2,
+				Source:    "rcb",
+				Providers: []string{"rcb"},
+				Stale:     true,
+			},
+			{
+				From:      label.EUR,
+				To:        label.JPY,
+				Rate:      decimal.RequireFromString("129.29"),
+				Date:      day1,
+				Source:    "ecb",
+				Providers: []string{"ecb"},
+			},
+		},
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	// the rates are sorted by date and pair
+	sorted := testSnapshot()
+	sorted.Rates = []Rate{sorted.Rates[2], sorted.Rates[0], sorted.Rates[1]}
+
+	testCases := []struct {
+		name     string
+		format   Format
+		expected Snapshot
+	}{
+		{
+			name:     "test_json",
+			format:   FormatJSON,
+			expected: sorted,
+		},
+		{
+			name:     "test_csv",
+			format:   FormatCSV,
+			expected: sorted,
+		},
+		{
+			name:   "test_xml",
+			format: FormatXML,
+			// the rates are crossed to the rates from EUR without the sources, the latest day is written first
+			expected: Snapshot{
+				Rates: []Rate{
+					{From: label.EUR, To: label.RUB, Rate: decimal.RequireFromString("84.05481464"), Date: day2},
+					{From: label.EUR, To: label.USD, Rate: decimal.RequireFromString("1.1593"), Date: day2},
+					{From: label.EUR, To: label.JPY, Rate: decimal.RequireFromString("129.29"), Date: day1},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var first bytes.Buffer
+			if err := Encode(&first, testSnapshot(), tc.format); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			got, err := Decode(bytes.NewReader(first.Bytes()))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("bad snapshot (-want, +got): %s", diff)
+			}
+
+			// the decoded snapshot is encoded the same way
+			var second bytes.Buffer
+			if err := Encode(&second, got, tc.format); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			if tc.format != FormatXML {
+				if diff := cmp.Diff(first.String(), second.String()); diff != "" {
+					t.Errorf("unstable encoding (-want, +got): %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestEncode_ZeroTimes(t *testing.T) {
+	t.Parallel()
+
+	// the zero date and fetch time are written empty to csv
+	expected := Snapshot{
+		Rates: []Rate{
+			{
+				From:      label.EUR,
+				To:        label.USD,
+				Rate:      decimal.RequireFromString("1.1593"),
+				Source:    "ecb",
+				Providers: []string{"ecb"},
+			},
+		},
+	}
+
+	for _, format := range []Format{FormatJSON, FormatCSV} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, expected, format); err != nil {
+			t.Fatalf("%s: encode: %v", format, err)
+		}
+
+		got, err := Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: decode: %v", format, err)
+		}
+
+		if diff := cmp.Diff(expected, got); diff != "" {
+			t.Errorf("%s: bad snapshot (-want, +got): %s", format, diff)
+		}
+	}
+}
+
+func TestEncode_XMLCrossRates(t *testing.T) {
+	t.Parallel()
+
+	rub := func(to label.Symbol, rate string, date time.Time) Rate {
+		return Rate{From: label.RUB, To: to, Rate: decimal.RequireFromString(rate), Date: date, Source: "rcb"}
+	}
+
+	testCases := []struct {
+		name        string
+		rates       []Rate
+		expected    []Rate
+		expectedErr error
+	}{
+		{
+			name: "test_to_eur",
+			rates: []Rate{
+				rub(label.EUR, "0.0125", day2),
+				rub(label.USD, "0.0145", day2),
+				rub(label.JPY, "1.6", day2),
+			},
+			expected: []Rate{
+				{From: label.EUR, To: label.JPY, Rate: decimal.RequireFromString("128"), Date: day2},
+				{From: label.EUR, To: label.RUB, Rate: decimal.RequireFromString("80"), Date: day2},
+				{From: label.EUR, To: label.USD, Rate: decimal.RequireFromString("1.16"), Date: day2},
+			},
+		},
+		{
+			name: "test_through_currency",
+			rates: []Rate{
+				{From: label.EUR, To: label.USD, Rate: decimal.RequireFromString("1.16"), Date: day2},
+				{From: label.JPY, To: label.USD, Rate: decimal.RequireFromString("0.008"), Date: day2},
+				rub(label.JPY, "1.6", day2),
+			},
+			expected: []Rate{
+				{From: label.EUR, To: label.JPY, Rate: decimal.RequireFromString("145"), Date: day2},
+				{From: label.EUR, To: label.RUB, Rate: decimal.RequireFromString("90.625"), Date: day2},
+				{From: label.EUR, To: label.USD, Rate: decimal.RequireFromString("1.16"), Date: day2},
+			},
+		},
+		{
+			name: "test_no_eur_rate_on_day",
+			rates: []Rate{
+				rub(label.EUR, "0.0125", day1),
+				rub(label.USD, "0.0145", day2),
+			},
+			expectedErr: ErrNoEURRate,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			err := Encode(&buf, Snapshot{Rates: tc.rates}, FormatXML)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got: %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got.Rates); diff != "" {
+				t.Errorf("bad rates (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		doc         string
+		expectedErr error
+	}{
+		{
+			name:        "test_empty",
+			doc:         " \n",
+			expectedErr: ErrUnknownFormat,
+		},
+		{
+			name:        "test_bad_csv_header",
+			doc:         "from,to,rate\nEUR,USD,1.1593\n",
+			expectedErr: errBadHeader,
+		},
+		{
+			name:        "test_invalid_rate",
+			doc:         `{"rates": [{"from": "EUR", "to": "USD", "rate": "-1", "date": "2021-10-05T00:00:00Z"}]}`,
+			expectedErr: ErrInvalidRate,
+		},
+	}
+
+	for _, tc := range testCases {
+		if _, err := Decode(strings.NewReader(tc.doc)); !errors.Is(err, tc.expectedErr) {
+			t.Errorf("%s: bad error, want %v, got %v", tc.name, tc.expectedErr, err)
+		}
+	}
+}
+
+func TestDecode_ECB(t *testing.T) {
+	t.Parallel()
+
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time='2021-10-05'>
+			<Cube currency='USD' rate='1.1593'/>
+			<Cube currency='ZZZ' rate='1'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	got, err := Decode(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	expected := Snapshot{
+		Rates: []Rate{{From: label.EUR, To: label.USD, Rate: decimal.RequireFromString("1.1593"), Date: day2}},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("bad snapshot (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+var (
+	_ provider.HistoricalSource    = (*source)(nil)
+	_ provider.DecimalExchangeRate = (*ExchangeRate)(nil)
+)
+
+type ExchangeRate struct {
+	time time.Time
+	from label.Currency
+	to   label.Currency
+	rate decimal.Decimal
+}
+
+func (e ExchangeRate) Time() time.Time {
+	return e.time
+}
+
+func (e ExchangeRate) From() label.Currency {
+	return e.from
+}
+
+func (e ExchangeRate) To() label.Currency {
+	return e.to
+}
+
+func (e ExchangeRate) Rate() float64 {
+	return e.rate.Float64()
+}
+
+func (e ExchangeRate) Decimal() decimal.Decimal {
+	return e.rate
+}
+
+// Open reads the snapshot file in any of the formats and returns the source replaying it
+func Open(path string) (*source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+
+	defer f.Close()
+
+	s, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot %s: %w", path, err)
+	}
+
+	return NewSource(s), nil
+}
+
+// NewSource returns the source replaying the snapshot. FetchLatest gives back the latest exchange rate of every pair,
+// the historical requests are answered by the publication dates of the snapshot.
+// The currencies unknown to gokuu are skipped
+func NewSource(s Snapshot) *source {
+	src := &source{}
+
+	var rates, days []provider.ExchangeRate
+	uniq := make(map[label.Symbol]struct{})
+	for _, r := range s.Rates {
+		from, ok := label.Currencies[r.From]
+		if !ok {
+			continue
+		}
+
+		to, ok := label.Currencies[r.To]
+		if !ok {
+			continue
+		}
+
+		rates = append(rates, ExchangeRate{time: r.Date, from: from, to: to, rate: r.Rate})
+		days = append(days, ExchangeRate{time: provider.Day(r.Date), from: from, to: to, rate: r.Rate})
+
+		for _, symbol := range []label.Symbol{r.From, r.To} {
+			if _, ok := uniq[symbol]; !ok {
+				uniq[symbol] = struct{}{}
+				src.exchangeable = append(src.exchangeable, symbol)
+			}
+		}
+	}
+
+	src.latest = latest(rates)
+	src.history = provider.NewHistory(days)
+
+	return src
+}
+
+type source struct {
+	exchangeable []label.Symbol
+	latest       []provider.ExchangeRate
+	history      provider.History
+}
+
+func (s *source) GetExchangeable() []label.Symbol {
+	return s.exchangeable
+}
+
+func (s *source) FetchLatest(context.Context) ([]provider.ExchangeRate, error) {
+	list := make([]provider.ExchangeRate, len(s.latest))
+	copy(list, s.latest)
+
+	return list, nil
+}
+
+func (s *source) FetchOn(_ context.Context, date time.Time) ([]provider.ExchangeRate, error) {
+	day, ok := s.history.On(date)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", provider.ErrHistoryNotFound, date.Format("2006-01-02"))
+	}
+
+	list := make([]provider.ExchangeRate, len(day.Rates))
+	copy(list, day.Rates)
+
+	return list, nil
+}
+
+func (s *source) FetchRange(_ context.Context, from, to time.Time) ([]provider.ExchangeRate, error) {
+	list := s.history.Range(from, to).Flatten()
+	if len(list) == 0 {
+		return nil, fmt.Errorf(
+			"%w: range from %s to %s", provider.ErrHistoryNotFound, from.Format("2006-01-02"), to.Format("2006-01-02"),
+		)
+	}
+
+	return list, nil
+}
+
+// latest returns the latest exchange rate of every pair keeping the order of the rates
+func latest(rates []provider.ExchangeRate) []provider.ExchangeRate {
+	type pair struct {
+		from, to label.Symbol
+	}
+
+	idx := make(map[pair]int, len(rates))
+	list := make([]provider.ExchangeRate, 0, len(rates))
+
+	for _, r := range rates {
+		key := pair{from: r.From().Symbol, to: r.To().Symbol}
+		i, ok := idx[key]
+		if !ok {
+			idx[key] = len(list)
+			list = append(list, r)
+
+			continue
+		}
+
+		if r.Time().After(list[i].Time()) {
+			list[i] = r
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,110 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+type testRate struct {
+	Date string
+	From label.Symbol
+	To   label.Symbol
+	Rate string
+}
+
+func toTestRates(rates []provider.ExchangeRate) []testRate {
+	list := make([]testRate, len(rates))
+	for i, r := range rates {
+		list[i] = testRate{
+			Date: r.Time().Format("2006-01-02"),
+			From: r.From().Symbol,
+			To:   r.To().Symbol,
+			Rate: provider.RateDecimal(r).String(),
+		}
+	}
+
+	return list
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	s := testSnapshot()
+	s.Rates = append(s.Rates, Rate{From: label.EUR, To: label.USD, Rate: decimal.RequireFromString("1.1624"), Date: day1})
+
+	path := filepath.Join(t.TempDir(), "snapshot.csv")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := Encode(f, s, FormatCSV); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	f.Close()
+
+	src, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if diff := cmp.Diff([]label.Symbol{label.EUR, label.JPY, label.USD, label.RUB}, src.GetExchangeable()); diff != "" {
+		t.Errorf("bad exchangeable (-want, +got): %s", diff)
+	}
+
+	latest, err := src.FetchLatest(ctx)
+	if err != nil {
+		t.Fatalf("fetch latest: %v", err)
+	}
+
+	expected := []testRate{
+		{Date: "2021-10-04", From: label.EUR, To: label.JPY, Rate: "129.29"},
+		{Date: "2021-10-05", From: label.EUR, To: label.USD, Rate: "1.1593"},
+		{Date: "2021-10-05", From: label.USD, To: label.RUB, Rate: "72.5048"},
+	}
+	if diff := cmp.Diff(expected, toTestRates(latest)); diff != "" {
+		t.Errorf("bad latest rates (-want, +got): %s", diff)
+	}
+
+	// the rates of the last publication before the weekend
+	on, err := src.FetchOn(ctx, day1.AddDate(0, 0, -1))
+	if !errors.Is(err, provider.ErrHistoryNotFound) {
+		t.Errorf("bad error before the first day, want %v, got %v", provider.ErrHistoryNotFound, err)
+	}
+
+	if on, err = src.FetchOn(ctx, day2.AddDate(0, 0, 4)); err != nil {
+		t.Fatalf("fetch on: %v", err)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-05", From: label.EUR, To: label.USD, Rate: "1.1593"},
+		{Date: "2021-10-05", From: label.USD, To: label.RUB, Rate: "72.5048"},
+	}
+	if diff := cmp.Diff(expected, toTestRates(on)); diff != "" {
+		t.Errorf("bad rates on date (-want, +got): %s", diff)
+	}
+
+	rng, err := src.FetchRange(ctx, day1, day1)
+	if err != nil {
+		t.Fatalf("fetch range: %v", err)
+	}
+
+	expected = []testRate{
+		{Date: "2021-10-04", From: label.EUR, To: label.JPY, Rate: "129.29"},
+		{Date: "2021-10-04", From: label.EUR, To: label.USD, Rate: "1.1624"},
+	}
+	if diff := cmp.Diff(expected, toTestRates(rng)); diff != "" {
+		t.Errorf("bad range (-want, +got): %s", diff)
+	}
+}
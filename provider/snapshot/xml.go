@@ -0,0 +1,204 @@
+package snapshot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+const (
+	gesmesNamespace    = "http://www.gesmes.org/xml/2002-08-01"
+	eurofxrefNamespace = "http://www.ecb.int/vocabulary/2002-08-01/eurofxref"
+	xmlDateLayout      = "2006-01-02"
+)
+
+// xmlEnvelope the ECB gesmes document with the daily cubes of the exchange rates from EUR
+type xmlEnvelope struct {
+	XMLName   xml.Name `xml:"gesmes:Envelope"`
+	Gesmes    string   `xml:"xmlns:gesmes,attr"`
+	Eurofxref string   `xml:"xmlns,attr"`
+	Subject   string   `xml:"gesmes:subject"`
+	Sender    string   `xml:"gesmes:Sender>gesmes:name"`
+	Days      []xmlDay `xml:"Cube>Cube"`
+}
+
+// xmlDocument reads the daily cubes of the gesmes document regardless of the namespaces
+type xmlDocument struct {
+	Days []xmlDay `xml:"Cube>Cube"`
+}
+
+type xmlDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []xmlRate `xml:"Cube"`
+}
+
+type xmlRate struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+// encodeXML writes the exchange rates from EUR by day, the latest day first as the ECB does. The rates between
+// other currencies are converted to the rates from EUR with the rates of the same day, see eurRates
+func encodeXML(w io.Writer, s Snapshot) error {
+	doc := xmlEnvelope{
+		Gesmes:    gesmesNamespace,
+		Eurofxref: eurofxrefNamespace,
+		Subject:   "Reference rates",
+		Sender:    "gokuu",
+		Days:      make([]xmlDay, 0),
+	}
+
+	idx := make(map[time.Time]int)
+	groups := make([][]Rate, 0)
+	for _, r := range s.Rates {
+		day := provider.Day(r.Date)
+		i, ok := idx[day]
+		if !ok {
+			i = len(doc.Days)
+			idx[day] = i
+			doc.Days = append(doc.Days, xmlDay{Time: day.Format(xmlDateLayout)})
+			groups = append(groups, nil)
+		}
+
+		groups[i] = append(groups[i], r)
+	}
+
+	for i, group := range groups {
+		rates, err := eurRates(group)
+		if err != nil {
+			return fmt.Errorf("encode xml of %s: %w", doc.Days[i].Time, err)
+		}
+
+		for _, r := range rates {
+			doc.Days[i].Rates = append(doc.Days[i].Rates, xmlRate{Currency: string(r.To), Rate: r.Rate.String()})
+		}
+	}
+
+	sort.Slice(doc.Days, func(i, j int) bool {
+		return doc.Days[i].Time > doc.Days[j].Time
+	})
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("encode xml: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode xml: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("encode xml: %w", err)
+	}
+
+	return nil
+}
+
+// eurRates returns the exchange rates from EUR of a day sorted by currency. The rates between other currencies
+// are crossed with the rates from or to EUR of the day, a currency without such a rate fails with ErrNoEURRate.
+// The rates from EUR given in the snapshot are preferred to the crossed ones
+func eurRates(rates []Rate) ([]Rate, error) {
+	one := decimal.New(1, 0)
+	eur := make(map[label.Symbol]decimal.Decimal)
+
+	rateOf := func(symbol label.Symbol) (decimal.Decimal, bool) {
+		if symbol == label.EUR {
+			return one, true
+		}
+
+		rate, ok := eur[symbol]
+
+		return rate, ok
+	}
+
+	set := func(symbol label.Symbol, rate decimal.Decimal) {
+		if _, ok := rateOf(symbol); !ok {
+			eur[symbol] = rate
+		}
+	}
+
+	pending := make([]Rate, 0)
+	for _, r := range rates {
+		if r.From == label.EUR {
+			set(r.To, r.Rate)
+			continue
+		}
+
+		pending = append(pending, r)
+	}
+
+	// every pass crosses the rates with a currency reached by the previous passes
+	for len(pending) > 0 {
+		left := make([]Rate, 0)
+		for _, r := range pending {
+			if from, ok := rateOf(r.From); ok {
+				set(r.To, from.Mul(r.Rate))
+				continue
+			}
+
+			if to, ok := rateOf(r.To); ok {
+				set(r.From, to.Div(r.Rate, decimal.DivisionPrecision))
+				continue
+			}
+
+			left = append(left, r)
+		}
+
+		if len(left) == len(pending) {
+			return nil, fmt.Errorf("%w: %s-%s", ErrNoEURRate, left[0].From, left[0].To)
+		}
+
+		pending = left
+	}
+
+	list := make([]Rate, 0, len(eur))
+	for symbol, rate := range eur {
+		list = append(list, Rate{From: label.EUR, To: symbol, Rate: rate})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].To < list[j].To
+	})
+
+	return list, nil
+}
+
+// decodeXML reads the exchange rates from EUR. The currencies unknown to gokuu are skipped
+func decodeXML(b []byte) (Snapshot, error) {
+	var doc xmlDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return Snapshot{}, err
+	}
+
+	s := Snapshot{Rates: make([]Rate, 0)}
+	for _, d := range doc.Days {
+		day, err := time.Parse(xmlDateLayout, d.Time)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("parse time: %w", err)
+		}
+
+		for _, r := range d.Rates {
+			symbol := label.Symbol(r.Currency)
+			if _, ok := label.Currencies[symbol]; !ok {
+				continue
+			}
+
+			rate, err := decimal.NewFromString(r.Rate)
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("parse rate of %s: %w", symbol, err)
+			}
+
+			s.Rates = append(s.Rates, Rate{From: label.EUR, To: symbol, Rate: rate, Date: day})
+		}
+	}
+
+	return s, nil
+}
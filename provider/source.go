@@ -2,11 +2,36 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/robotomize/gokuu/decimal"
 	"github.com/robotomize/gokuu/label"
 )
 
+// ErrHistoryNotFound is returned by historical sources when no exchange rates were published for the requested dates
+var ErrHistoryNotFound = errors.New("exchange rates for the requested date not found")
+
+// DecodeError is returned by the sources when the fetched document can not be decoded.
+// Requesting the same document again gives the same error, so the exchanger does not retry it by default
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "decode: " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// IsDecodeError reports whether any error in the chain of err is a DecodeError
+func IsDecodeError(err error) bool {
+	var decodeErr *DecodeError
+	return errors.As(err, &decodeErr)
+}
+
 // Source is an interface for getting data from external sources. Source takes care of receiving data,
 // working with proxies and giving back exchange rates
 //
@@ -19,6 +44,28 @@ type Source interface {
 	GetExchangeable() []label.Symbol
 }
 
+// HistoricalSource is an optional interface for sources that publish exchange rates for past dates.
+// The exchanger checks whether the registered Source implements it
+type HistoricalSource interface {
+	Source
+
+	// FetchOn returns the exchange rates in effect on the date. If there was no publication on that day
+	// (weekends, holidays), the rates of the last publication before the date are returned
+	FetchOn(ctx context.Context, date time.Time) ([]ExchangeRate, error)
+
+	// FetchRange returns all exchange rates published between from and to inclusive
+	FetchRange(ctx context.Context, from, to time.Time) ([]ExchangeRate, error)
+}
+
+// ChangingSource is an optional interface for sources whose exchangeable currencies change at runtime, like
+// the files dropped to a directory. The exchanger reads the currencies of the source again once the version changes
+type ChangingSource interface {
+	Source
+
+	// ExchangeableVersion returns the version of the exchangeable currencies, it changes along with them
+	ExchangeableVersion() uint64
+}
+
 // ExchangeRate represents the exchange rate of a particular currency pair
 type ExchangeRate interface {
 	// Time - date on which the exchange rate was issued
@@ -28,3 +75,20 @@ type ExchangeRate interface {
 	To() label.Currency
 	Rate() float64
 }
+
+// DecimalExchangeRate is an optional interface for exchange rates that keep the exact decimal value of the rate.
+// The built-in sources parse the rates from the published strings without going through float64
+type DecimalExchangeRate interface {
+	ExchangeRate
+	Decimal() decimal.Decimal
+}
+
+// RateDecimal returns the exact rate if the exchange rate implements DecimalExchangeRate,
+// otherwise the float rate is converted to decimal
+func RateDecimal(r ExchangeRate) decimal.Decimal {
+	if d, ok := r.(DecimalExchangeRate); ok {
+		return d.Decimal()
+	}
+
+	return decimal.NewFromFloat(r.Rate())
+}
@@ -0,0 +1,216 @@
+package gokuu
+
+import (
+	"sort"
+
+	"github.com/robotomize/gokuu/internal/singleflight"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+type Prior int32
+
+// Provider the registered source of the exchange rates. The values are never modified after the registration,
+// a change of the priority or the state replaces the provider
+type Provider struct {
+	name     string
+	prior    Prior
+	disabled bool
+	policy   fetchPolicy
+	breaker  *breaker
+	// flight shares the concurrent requests of the provider, it is shared by the copies of the provider
+	flight *singleflight.Group
+	provider.Source
+}
+
+// ProviderState the registered provider with its priority and state
+type ProviderState struct {
+	Name    string
+	Prior   Prior
+	Enabled bool
+}
+
+// registry the enabled providers and the exchangeable currencies at the time of the fetch.
+// The slices are replaced on every change of the providers, so the fetches read them without the lock
+type registry struct {
+	providers    []*Provider
+	exchangeable []label.Symbol
+	// version of the providers, it is incremented on every change
+	version uint64
+}
+
+func (r registry) isExchangeable(from, to label.Symbol) struct {
+	from bool
+	to   bool
+} {
+	res := struct {
+		from bool
+		to   bool
+	}{}
+
+	for _, symbol := range r.exchangeable {
+		if symbol == from {
+			res.from = true
+		}
+
+		if symbol == to {
+			res.to = true
+		}
+
+		if res.from && res.to {
+			return res
+		}
+	}
+
+	return res
+}
+
+// state returns the current registry of the providers
+func (e *exchanger) state() registry {
+	e.verifyExchangeable()
+
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	r := registry{
+		providers:    make([]*Provider, 0, len(e.providers)),
+		exchangeable: e.exchangeable,
+		version:      e.version,
+	}
+
+	for _, p := range e.providers {
+		if !p.disabled {
+			r.providers = append(r.providers, p)
+		}
+	}
+
+	return r
+}
+
+// Providers returns the registered providers from the highest priority
+func (e *exchanger) Providers() []ProviderState {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	states := make([]ProviderState, len(e.providers))
+	for i, p := range e.providers {
+		states[i] = ProviderState{
+			Name:    p.name,
+			Prior:   p.prior,
+			Enabled: !p.disabled,
+		}
+	}
+
+	return states
+}
+
+// Delete providers by name
+func (e *exchanger) Delete(names ...string) {
+	excluded := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		excluded[name] = struct{}{}
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	providers := make([]*Provider, 0, len(e.providers))
+	for _, p := range e.providers {
+		if _, ok := excluded[p.name]; !ok {
+			providers = append(providers, p)
+		}
+	}
+
+	e.setProviders(providers)
+}
+
+// ChangePrior change provider priority
+func (e *exchanger) ChangePrior(name string, prior Prior) {
+	e.replace(name, func(p *Provider) {
+		p.prior = prior
+	})
+}
+
+// Enable returns the disabled provider to the fetches
+func (e *exchanger) Enable(name string) {
+	e.replace(name, func(p *Provider) {
+		p.disabled = false
+	})
+}
+
+// Disable excludes the provider from the fetches and the exchangeable currencies until Enable is called.
+// Unlike Delete it keeps the provider and its priority
+func (e *exchanger) Disable(name string) {
+	e.replace(name, func(p *Provider) {
+		p.disabled = true
+	})
+}
+
+// Register allows you to add your own provider of exchange rate data.
+// The options override the timeout and the retries of the exchanger for this provider
+func (e *exchanger) Register(name string, source provider.Source, prior Prior, opts ...ProviderOption) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	providers := make([]*Provider, len(e.providers), len(e.providers)+1)
+	copy(providers, e.providers)
+
+	e.setProviders(append(providers, &Provider{
+		name:    name,
+		Source:  source,
+		prior:   prior,
+		policy:  e.newFetchPolicy(name, opts...),
+		breaker: &breaker{},
+		flight:  &singleflight.Group{},
+	}))
+}
+
+// newFetchPolicy applies the options set by WithProviderOptions for the provider and opts
+func (e *exchanger) newFetchPolicy(name string, opts ...ProviderOption) fetchPolicy {
+	var p fetchPolicy
+	for _, opt := range append(e.providerOpts[name], opts...) {
+		opt(&p)
+	}
+
+	return p
+}
+
+// replace copies the providers with the name and applies fn to the copies
+func (e *exchanger) replace(name string, fn func(p *Provider)) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	providers := make([]*Provider, len(e.providers))
+	for i, p := range e.providers {
+		if p.name == name {
+			cp := *p
+			fn(&cp)
+			p = &cp
+		}
+
+		providers[i] = p
+	}
+
+	e.setProviders(providers)
+}
+
+// setProviders replaces the providers, updates the exchangeable currencies and drops the cached rates.
+// The write lock must be held
+func (e *exchanger) setProviders(providers []*Provider) {
+	sortProviders(providers)
+
+	e.providers = providers
+	e.version++
+	e.updateExchangeable()
+
+	if e.cache != nil {
+		e.cache.invalidate()
+	}
+}
+
+// sortProviders sorts the providers from the highest priority, the providers with equal priority keep their order
+func sortProviders(providers []*Provider) {
+	sort.SliceStable(providers, func(i, j int) bool {
+		return providers[i].prior > providers[j].prior
+	})
+}
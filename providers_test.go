@@ -0,0 +1,309 @@
+package gokuu
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+// staticSource returns the same exchange rates on every fetch
+type staticSource struct {
+	rates []ExchangeRate
+}
+
+func newStaticSource(rates ...ExchangeRate) *staticSource {
+	for i := range rates {
+		rates[i].time = time.Now()
+	}
+
+	return &staticSource{rates: rates}
+}
+
+func (s *staticSource) GetExchangeable() []label.Symbol {
+	symbols := make([]label.Symbol, 0, len(s.rates)*2)
+	for _, r := range s.rates {
+		symbols = append(symbols, r.from.Symbol, r.to.Symbol)
+	}
+
+	return symbols
+}
+
+func (s *staticSource) FetchLatest(context.Context) ([]provider.ExchangeRate, error) {
+	list := make([]provider.ExchangeRate, len(s.rates))
+	for i, r := range s.rates {
+		list[i] = r
+	}
+
+	return list, nil
+}
+
+// changingSource the static source with the rates replaced by the test, see provider.ChangingSource
+type changingSource struct {
+	mtx     sync.Mutex
+	source  *staticSource
+	version uint64
+}
+
+func (s *changingSource) GetExchangeable() []label.Symbol {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.source.GetExchangeable()
+}
+
+func (s *changingSource) FetchLatest(ctx context.Context) ([]provider.ExchangeRate, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.source.FetchLatest(ctx)
+}
+
+func (s *changingSource) ExchangeableVersion() uint64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.version
+}
+
+func (s *changingSource) set(source *staticSource) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.source = source
+	s.version++
+}
+
+func sortedSymbols(symbols []label.Symbol) []label.Symbol {
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i] < symbols[j]
+	})
+
+	return symbols
+}
+
+func fetchedProviders(resp LatestResponse) []string {
+	names := make([]string, 0, len(resp.Info))
+	for _, info := range resp.Info {
+		names = append(names, info.Name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func TestExchanger_Providers(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		change               func(e *exchanger)
+		expected             []ProviderState
+		expectedExchangeable []label.Symbol
+		expectedFetched      []string
+	}{
+		{
+			name:   "test_register",
+			change: func(e *exchanger) {},
+			expected: []ProviderState{
+				{Name: "two", Prior: 1, Enabled: true},
+				{Name: "one", Prior: 0, Enabled: true},
+			},
+			expectedExchangeable: []label.Symbol{label.DKK, label.EUR, label.RUB, label.USD},
+			expectedFetched:      []string{"one", "two"},
+		},
+		{
+			name: "test_delete",
+			change: func(e *exchanger) {
+				e.Delete("two")
+			},
+			expected:             []ProviderState{{Name: "one", Prior: 0, Enabled: true}},
+			expectedExchangeable: []label.Symbol{label.RUB, label.USD},
+			expectedFetched:      []string{"one"},
+		},
+		{
+			name: "test_delete_unknown",
+			change: func(e *exchanger) {
+				e.Delete("three")
+			},
+			expected: []ProviderState{
+				{Name: "two", Prior: 1, Enabled: true},
+				{Name: "one", Prior: 0, Enabled: true},
+			},
+			expectedExchangeable: []label.Symbol{label.DKK, label.EUR, label.RUB, label.USD},
+			expectedFetched:      []string{"one", "two"},
+		},
+		{
+			name: "test_change_prior",
+			change: func(e *exchanger) {
+				e.ChangePrior("one", 2)
+			},
+			expected: []ProviderState{
+				{Name: "one", Prior: 2, Enabled: true},
+				{Name: "two", Prior: 1, Enabled: true},
+			},
+			expectedExchangeable: []label.Symbol{label.DKK, label.EUR, label.RUB, label.USD},
+			expectedFetched:      []string{"one", "two"},
+		},
+		{
+			name: "test_disable",
+			change: func(e *exchanger) {
+				e.Disable("one")
+			},
+			expected: []ProviderState{
+				{Name: "two", Prior: 1, Enabled: true},
+				{Name: "one", Prior: 0, Enabled: false},
+			},
+			expectedExchangeable: []label.Symbol{label.DKK, label.EUR},
+			expectedFetched:      []string{"two"},
+		},
+		{
+			name: "test_enable",
+			change: func(e *exchanger) {
+				e.Disable("one")
+				e.Enable("one")
+			},
+			expected: []ProviderState{
+				{Name: "two", Prior: 1, Enabled: true},
+				{Name: "one", Prior: 0, Enabled: true},
+			},
+			expectedExchangeable: []label.Symbol{label.DKK, label.EUR, label.RUB, label.USD},
+			expectedFetched:      []string{"one", "two"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		for _, cached := range []bool{false, true} {
+			cached := cached
+			t.Run(fmt.Sprintf("%s_cached_%t", tc.name, cached), func(t *testing.T) {
+				t.Parallel()
+
+				ctx := context.Background()
+
+				opts := []Option{WithRetryNum(0)}
+				if cached {
+					opts = append(opts, WithCache(0))
+				}
+
+				e := New(http.DefaultClient, opts...)
+				e.providers = make([]*Provider, 0)
+				e.Register("one", newStaticSource(testRate(label.USD, label.RUB, "72.9781", 0)), 0)
+				e.Register("two", newStaticSource(testRate(label.EUR, label.DKK, "7.4362", 0)), 1)
+
+				// the rates fetched before the change must not be served after it
+				e.GetLatest(ctx)
+
+				tc.change(e)
+
+				if diff := cmp.Diff(tc.expected, e.Providers()); diff != "" {
+					t.Errorf("bad providers (-want, +got): %s", diff)
+				}
+
+				if diff := cmp.Diff(tc.expectedExchangeable, sortedSymbols(e.GetExchangeable())); diff != "" {
+					t.Errorf("bad exchangeable (-want, +got): %s", diff)
+				}
+
+				if diff := cmp.Diff(tc.expectedFetched, fetchedProviders(e.GetLatest(ctx))); diff != "" {
+					t.Errorf("bad fetched providers (-want, +got): %s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestExchanger_ProvidersConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const iterations = 50
+
+	ctx := context.Background()
+
+	e := New(http.DefaultClient, WithRetryNum(0), WithCache(0))
+	e.providers = make([]*Provider, 0)
+	e.Register("static", newStaticSource(testRate(label.USD, label.RUB, "72.9781", 0)), 0)
+
+	churn := newStaticSource(testRate(label.EUR, label.DKK, "7.4362", 0))
+
+	ops := []func(i int){
+		func(i int) { e.Register("churn", churn, Prior(i%3)) },
+		func(int) { e.Delete("churn") },
+		func(i int) { e.ChangePrior("static", Prior(i%5)) },
+		func(int) { e.Disable("churn") },
+		func(int) { e.Enable("churn") },
+		func(int) { e.Providers() },
+		func(int) { e.GetExchangeable() },
+		func(int) { e.GetLatest(ctx) },
+		func(int) { e.GetOn(ctx, time.Now()) },
+		func(int) { e.refresh(ctx) },
+		func(int) { _, _ = e.Convert(ctx, ConvOpt{From: label.USD, To: label.RUB, Value: 1}) },
+	}
+
+	var wg sync.WaitGroup
+	for _, op := range ops {
+		op := op
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				op(i)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	e.Delete("churn")
+	e.ChangePrior("static", 0)
+
+	if diff := cmp.Diff([]ProviderState{{Name: "static", Enabled: true}}, e.Providers()); diff != "" {
+		t.Errorf("bad providers (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff([]label.Symbol{label.RUB, label.USD}, sortedSymbols(e.GetExchangeable())); diff != "" {
+		t.Errorf("bad exchangeable (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"static"}, fetchedProviders(e.GetLatest(ctx))); diff != "" {
+		t.Errorf("bad fetched providers (-want, +got): %s", diff)
+	}
+}
+
+func TestExchanger_ChangingSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source := &changingSource{source: newStaticSource(testRate(label.USD, label.RUB, "72.9781", 0))}
+
+	e := New(http.DefaultClient, WithRetryNum(0), WithCache(0))
+	e.providers = make([]*Provider, 0)
+	e.Register("changing", source, 0)
+
+	if diff := cmp.Diff(1, len(e.GetLatest(ctx).Result)); diff != "" {
+		t.Fatalf("bad results (-want, +got): %s", diff)
+	}
+
+	source.set(newStaticSource(
+		testRate(label.USD, label.RUB, "72.9781", 0),
+		testRate(label.EUR, label.DKK, "7.4362", 0),
+	))
+
+	expected := []label.Symbol{label.DKK, label.EUR, label.RUB, label.USD}
+	if diff := cmp.Diff(expected, sortedSymbols(e.GetExchangeable())); diff != "" {
+		t.Errorf("bad exchangeable (-want, +got): %s", diff)
+	}
+
+	// the rates cached before the change are dropped
+	if diff := cmp.Diff(2, len(e.GetLatest(ctx).Result)); diff != "" {
+		t.Errorf("bad results (-want, +got): %s", diff)
+	}
+}
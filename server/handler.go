@@ -0,0 +1,371 @@
+// Package server exposes the gokuu exchanger as a JSON REST API
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusDown     = "down"
+)
+
+type Rate struct {
+	From      label.Symbol `json:"from"`
+	To        label.Symbol `json:"to"`
+	Rate      string       `json:"rate"`
+	Date      time.Time    `json:"date"`
+	Providers []string     `json:"providers"`
+	Stale     bool         `json:"stale,omitempty"`
+	// Fallback the last known good exchange rate served because the providers failed, see gokuu.WithFallback
+	Fallback bool `json:"fallback,omitempty"`
+	// Age the seconds elapsed since the fallback exchange rate was fetched
+	Age int64 `json:"age,omitempty"`
+}
+
+type LatestResponse struct {
+	FetchedAt  time.Time      `json:"fetched_at"`
+	Rates      []Rate         `json:"rates"`
+	Unreceived []label.Symbol `json:"unreceived,omitempty"`
+}
+
+type ConversionResponse struct {
+	Value  string         `json:"value"`
+	From   label.Symbol   `json:"from"`
+	To     label.Symbol   `json:"to"`
+	Rate   string         `json:"rate"`
+	Amount string         `json:"amount"`
+	Date   time.Time      `json:"date"`
+	Path   []label.Symbol `json:"path"`
+}
+
+type Symbol struct {
+	Symbol label.Symbol `json:"symbol"`
+	Name   string       `json:"name"`
+}
+
+type Provider struct {
+	Name        string     `json:"name"`
+	OK          bool       `json:"ok"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Stale       bool       `json:"stale,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	// Circuit the state of the circuit breaker if it is not closed
+	Circuit string `json:"circuit,omitempty"`
+}
+
+type HealthResponse struct {
+	Status    string     `json:"status"`
+	Providers []Provider `json:"providers"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler returns the handler serving the exchange rates of the exchanger on the paths
+// /latest, /convert, /symbols, /providers and /healthz. Mount it with http.StripPrefix to serve under a prefix.
+// Create the exchanger with gokuu.WithCache or gokuu.WithAutoRefresh to serve the cached rates
+func NewHandler(e gokuu.Exchanger) http.Handler {
+	h := &handler{exchanger: e}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest", h.handleLatest)
+	mux.HandleFunc("/convert", h.handleConvert)
+	mux.HandleFunc("/symbols", h.handleSymbols)
+	mux.HandleFunc("/providers", h.handleProviders)
+	mux.HandleFunc("/healthz", h.handleHealth)
+
+	return allowGet(mux)
+}
+
+type handler struct {
+	exchanger gokuu.Exchanger
+
+	// the encoded latest exchange rates of the last snapshot
+	mtx       sync.Mutex
+	fetchedAt time.Time
+	latest    []byte
+	etag      string
+}
+
+func allowGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *handler) handleLatest(w http.ResponseWriter, r *http.Request) {
+	resp := h.exchanger.GetLatest(r.Context())
+
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		body, etag, err := h.encodeLatest(resp)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeCached(w, r, resp.FetchedAt, etag, body)
+		return
+	}
+
+	symbol, err := parseSymbol(from)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	latest := newLatestResponse(resp, symbol)
+
+	body, err := json.Marshal(latest)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeCached(w, r, resp.FetchedAt, etagOf(body), body)
+}
+
+// encodeLatest encodes all exchange rates once per snapshot
+func (h *handler) encodeLatest(resp gokuu.LatestResponse) ([]byte, string, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.latest != nil && h.fetchedAt.Equal(resp.FetchedAt) {
+		return h.latest, h.etag, nil
+	}
+
+	body, err := json.Marshal(newLatestResponse(resp, ""))
+	if err != nil {
+		return nil, "", fmt.Errorf("json marshal: %w", err)
+	}
+
+	h.fetchedAt, h.latest, h.etag = resp.FetchedAt, body, etagOf(body)
+
+	return h.latest, h.etag, nil
+}
+
+func (h *handler) handleConvert(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, err := parseSymbol(query.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	to, err := parseSymbol(query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	amount := query.Get("amount")
+	if amount == "" {
+		amount = "1"
+	}
+
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	conv, err := h.exchanger.Convert(r.Context(), gokuu.ConvOpt{From: from, To: to, DecimalValue: &value})
+	if err != nil {
+		switch {
+		case errors.Is(err, gokuu.ErrCurrencyNotFound):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, gokuu.ErrConversionRate):
+			writeError(w, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			writeError(w, http.StatusServiceUnavailable, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ConversionResponse{
+		Value:  value.String(),
+		From:   conv.From.Symbol,
+		To:     conv.To.Symbol,
+		Rate:   conv.Rate.String(),
+		Amount: conv.Amount.String(),
+		Date:   conv.Date,
+		Path:   conv.Path,
+	})
+}
+
+func (h *handler) handleSymbols(w http.ResponseWriter, _ *http.Request) {
+	exchangeable := h.exchanger.GetExchangeable()
+
+	symbols := make([]Symbol, len(exchangeable))
+	for i, symbol := range exchangeable {
+		symbols[i] = Symbol{Symbol: symbol, Name: label.Currencies[symbol].Name}
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].Symbol < symbols[j].Symbol
+	})
+
+	writeJSON(w, http.StatusOK, symbols)
+}
+
+func (h *handler) handleProviders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, newProviders(h.exchanger.GetLatest(r.Context()).Info))
+}
+
+// handleHealth reports ok if all providers responded, degraded if some of them and down with 503 if none
+func (h *handler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	providers := newProviders(h.exchanger.GetLatest(r.Context()).Info)
+
+	healthy := 0
+	for _, p := range providers {
+		if p.OK {
+			healthy++
+		}
+	}
+
+	resp := HealthResponse{Status: HealthStatusOK, Providers: providers}
+	code := http.StatusOK
+
+	switch {
+	case healthy == 0:
+		resp.Status = HealthStatusDown
+		code = http.StatusServiceUnavailable
+	case healthy < len(providers):
+		resp.Status = HealthStatusDegraded
+	}
+
+	writeJSON(w, code, resp)
+}
+
+func newLatestResponse(resp gokuu.LatestResponse, from label.Symbol) LatestResponse {
+	latest := LatestResponse{
+		FetchedAt:  resp.FetchedAt,
+		Rates:      make([]Rate, 0, len(resp.Result)),
+		Unreceived: resp.Unreceived,
+	}
+
+	for _, r := range resp.Result {
+		if from != "" && r.From().Symbol != from {
+			continue
+		}
+
+		age, fallback := r.Fallback()
+		latest.Rates = append(latest.Rates, Rate{
+			From:      r.From().Symbol,
+			To:        r.To().Symbol,
+			Rate:      r.Decimal().String(),
+			Date:      r.Time(),
+			Providers: r.Providers(),
+			Stale:     r.Stale(),
+			Fallback:  fallback,
+			Age:       int64(age / time.Second),
+		})
+	}
+
+	sort.Slice(latest.Rates, func(i, j int) bool {
+		if latest.Rates[i].From != latest.Rates[j].From {
+			return latest.Rates[i].From < latest.Rates[j].From
+		}
+
+		return latest.Rates[i].To < latest.Rates[j].To
+	})
+
+	return latest
+}
+
+func newProviders(info []gokuu.SourceInfo) []Provider {
+	providers := make([]Provider, len(info))
+	for i, s := range info {
+		providers[i] = Provider{
+			Name:  s.Name,
+			OK:    s.Status == gokuu.ProviderRespStatusOK,
+			Stale: s.Stale,
+			Error: s.ErrorMessage,
+		}
+
+		if !s.PublishedAt.IsZero() {
+			published := s.PublishedAt
+			providers[i].PublishedAt = &published
+		}
+
+		if s.Circuit != gokuu.CircuitStateClosed {
+			providers[i].Circuit = s.Circuit.String()
+		}
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name < providers[j].Name
+	})
+
+	return providers
+}
+
+func parseSymbol(s string) (label.Symbol, error) {
+	if s == "" {
+		return "", errors.New("currency symbol is required")
+	}
+
+	symbol := label.Symbol(strings.ToUpper(s))
+	if _, ok := label.Currencies[symbol]; !ok {
+		return "", gokuu.ErrCurrencyNotFound
+	}
+
+	return symbol, nil
+}
+
+func etagOf(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// writeCached writes the body with the ETag and Last-Modified headers or 304 if the client has it
+func writeCached(w http.ResponseWriter, r *http.Request, modified time.Time, etag string, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+
+	http.ServeContent(w, r, "", modified, bytes.NewReader(body))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		code = http.StatusInternalServerError
+		body, _ = json.Marshal(ErrorResponse{Error: err.Error()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, ErrorResponse{Error: err.Error()})
+}
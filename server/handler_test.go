@@ -0,0 +1,302 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+var published = time.Date(2021, 7, 30, 0, 0, 0, 0, time.UTC)
+
+func newTestExchanger(t *testing.T, sources map[string]error) gokuu.Exchanger {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+
+	e := gokuu.New(http.DefaultClient, gokuu.WithCache(time.Hour), gokuu.WithRetryNum(0))
+	e.Delete(gokuu.ProviderNameECB, gokuu.ProviderNameRCB, gokuu.ProviderNameCAE)
+
+	for name, err := range sources {
+		rate := provider.NewMockDecimalExchangeRate(ctrl)
+		rate.EXPECT().From().Return(label.Currencies[label.USD]).AnyTimes()
+		rate.EXPECT().To().Return(label.Currencies[label.RUB]).AnyTimes()
+		rate.EXPECT().Decimal().Return(decimal.RequireFromString("72.9781")).AnyTimes()
+		rate.EXPECT().Time().Return(published).AnyTimes()
+
+		source := provider.NewMockSource(ctrl)
+		source.EXPECT().GetExchangeable().Return([]label.Symbol{label.USD, label.RUB}).AnyTimes()
+		if err != nil {
+			source.EXPECT().FetchLatest(gomock.Any()).Return(nil, err).AnyTimes()
+		} else {
+			source.EXPECT().FetchLatest(gomock.Any()).Return([]provider.ExchangeRate{rate}, nil).AnyTimes()
+		}
+
+		e.Register(name, source, 0)
+	}
+
+	return e
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		sources      map[string]error
+		method       string
+		target       string
+		expectedCode int
+		expected     interface{}
+	}{
+		{
+			name:         "test_latest",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/latest?from=usd",
+			expectedCode: http.StatusOK,
+			expected: &LatestResponse{
+				Rates: []Rate{
+					{
+						From:      label.USD,
+						To:        label.RUB,
+						Rate:      "72.9781",
+						Date:      published,
+						Providers: []string{gokuu.ProviderNameRCB},
+					},
+				},
+				Unreceived: []label.Symbol{label.RUB},
+			},
+		},
+		{
+			name:         "test_latest_unknown_symbol",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/latest?from=ABC",
+			expectedCode: http.StatusBadRequest,
+			expected:     &ErrorResponse{Error: gokuu.ErrCurrencyNotFound.Error()},
+		},
+		{
+			name:         "test_convert",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/convert?from=USD&to=RUB&amount=10.5",
+			expectedCode: http.StatusOK,
+			expected: &ConversionResponse{
+				Value:  "10.5",
+				From:   label.USD,
+				To:     label.RUB,
+				Rate:   "72.9781",
+				Amount: "766.27",
+				Date:   published,
+				Path:   []label.Symbol{label.USD, label.RUB},
+			},
+		},
+		{
+			name:         "test_convert_invalid_amount",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/convert?from=USD&to=RUB&amount=ten",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "test_convert_not_exchangeable",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/convert?from=USD&to=GBP",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "test_convert_without_rate",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/convert?from=RUB&to=USD",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "test_symbols",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/symbols",
+			expectedCode: http.StatusOK,
+			expected: &[]Symbol{
+				{Symbol: label.RUB, Name: label.Currencies[label.RUB].Name},
+				{Symbol: label.USD, Name: label.Currencies[label.USD].Name},
+			},
+		},
+		{
+			name: "test_providers",
+			sources: map[string]error{
+				gokuu.ProviderNameRCB: nil,
+				gokuu.ProviderNameECB: errors.New("unavailable"),
+			},
+			target:       "/providers",
+			expectedCode: http.StatusOK,
+			expected: &[]Provider{
+				{Name: gokuu.ProviderNameECB, Error: "fetch: unavailable"},
+				{Name: gokuu.ProviderNameRCB, OK: true, PublishedAt: &published},
+			},
+		},
+		{
+			name:         "test_health_ok",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			target:       "/healthz",
+			expectedCode: http.StatusOK,
+			expected: &HealthResponse{
+				Status:    HealthStatusOK,
+				Providers: []Provider{{Name: gokuu.ProviderNameRCB, OK: true, PublishedAt: &published}},
+			},
+		},
+		{
+			name: "test_health_degraded",
+			sources: map[string]error{
+				gokuu.ProviderNameRCB: nil,
+				gokuu.ProviderNameECB: errors.New("unavailable"),
+			},
+			target:       "/healthz",
+			expectedCode: http.StatusOK,
+			expected: &HealthResponse{
+				Status: HealthStatusDegraded,
+				Providers: []Provider{
+					{Name: gokuu.ProviderNameECB, Error: "fetch: unavailable"},
+					{Name: gokuu.ProviderNameRCB, OK: true, PublishedAt: &published},
+				},
+			},
+		},
+		{
+			name:         "test_health_down",
+			sources:      map[string]error{gokuu.ProviderNameECB: errors.New("unavailable")},
+			target:       "/healthz",
+			expectedCode: http.StatusServiceUnavailable,
+			expected: &HealthResponse{
+				Status:    HealthStatusDown,
+				Providers: []Provider{{Name: gokuu.ProviderNameECB, Error: "fetch: unavailable"}},
+			},
+		},
+		{
+			name:         "test_method_not_allowed",
+			sources:      map[string]error{gokuu.ProviderNameRCB: nil},
+			method:       http.MethodPost,
+			target:       "/latest",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := NewHandler(newTestExchanger(t, tc.sources))
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(method, tc.target, nil))
+
+			if diff := cmp.Diff(tc.expectedCode, w.Code); diff != "" {
+				t.Fatalf("bad status code (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff("application/json", w.Header().Get("Content-Type")); diff != "" {
+				t.Errorf("bad content type (-want, +got): %s", diff)
+			}
+
+			if tc.expected == nil {
+				return
+			}
+
+			got := newOf(tc.expected)
+			if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+				t.Fatalf("json unmarshal: %v", err)
+			}
+
+			if latest, ok := got.(*LatestResponse); ok {
+				latest.FetchedAt = time.Time{}
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("bad body (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func newOf(v interface{}) interface{} {
+	switch v.(type) {
+	case *LatestResponse:
+		return &LatestResponse{}
+	case *ConversionResponse:
+		return &ConversionResponse{}
+	case *[]Symbol:
+		return &[]Symbol{}
+	case *[]Provider:
+		return &[]Provider{}
+	case *HealthResponse:
+		return &HealthResponse{}
+	default:
+		return &ErrorResponse{}
+	}
+}
+
+func TestHandler_LatestConditional(t *testing.T) {
+	t.Parallel()
+
+	h := NewHandler(newTestExchanger(t, map[string]error{gokuu.ProviderNameRCB: nil}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/latest", nil))
+
+	if diff := cmp.Diff(http.StatusOK, w.Code); diff != "" {
+		t.Fatalf("bad status code (-want, +got): %s", diff)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("ETag header is empty")
+	}
+
+	lastModified := w.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatalf("Last-Modified header is empty")
+	}
+
+	testCases := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{
+			name:   "test_if_none_match",
+			header: "If-None-Match",
+			value:  etag,
+		},
+		{
+			name:   "test_if_modified_since",
+			header: "If-Modified-Since",
+			value:  lastModified,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/latest", nil)
+			r.Header.Set(tc.header, tc.value)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if diff := cmp.Diff(http.StatusNotModified, w.Code); diff != "" {
+				t.Errorf("bad status code (-want, +got): %s", diff)
+			}
+		})
+	}
+}
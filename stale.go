@@ -0,0 +1,103 @@
+package gokuu
+
+import (
+	"time"
+
+	"github.com/robotomize/gokuu/provider"
+)
+
+// StalePolicy what to do with the exchange rates older than the max rate age
+type StalePolicy byte
+
+const (
+	// StalePolicyExclude drop the stale exchange rates before merging
+	StalePolicyExclude StalePolicy = iota
+	// StalePolicyFlag keep the stale exchange rates and flag them
+	StalePolicyFlag
+)
+
+// WithClock set the clock the age of the exchange rates is measured with
+func WithClock(now func() time.Time) Option {
+	return func(e *exchanger) {
+		e.now = now
+	}
+}
+
+// WithMaxRateAge set the max age of the exchange rates from their publication date.
+// The stale rates are excluded unless the StalePolicyFlag policy is set
+func WithMaxRateAge(d time.Duration) Option {
+	return func(e *exchanger) {
+		e.opts.MaxRateAge = d
+	}
+}
+
+// WithStalePolicy set what to do with the exchange rates older than the max rate age
+func WithStalePolicy(policy StalePolicy) Option {
+	return func(e *exchanger) {
+		e.opts.StalePolicy = policy
+	}
+}
+
+// WithPreferFreshest the race and priority merge strategies choose only from the quotes published on the latest date
+func WithPreferFreshest() Option {
+	return func(e *exchanger) {
+		e.opts.PreferFreshest = true
+	}
+}
+
+// checkStale flags the exchange rates published more than the max rate age before ref and drops them with
+// the StalePolicyExclude policy. It returns the latest publication date of the rates and whether it is stale
+func (e *exchanger) checkStale(rates []ExchangeRate, ref time.Time) ([]ExchangeRate, time.Time, bool) {
+	var published time.Time
+	for _, r := range rates {
+		if r.time.After(published) {
+			published = r.time
+		}
+	}
+
+	if e.opts.MaxRateAge <= 0 {
+		return rates, published, false
+	}
+
+	kept := rates[:0]
+	for _, r := range rates {
+		r.stale = ref.Sub(r.time) > e.opts.MaxRateAge
+		if r.stale && e.opts.StalePolicy == StalePolicyExclude {
+			continue
+		}
+
+		kept = append(kept, r)
+	}
+
+	return kept, published, ref.Sub(published) > e.opts.MaxRateAge
+}
+
+// freshest reduces only the quotes published on the latest date
+func freshest(reduce reduceFunc) reduceFunc {
+	return func(quotes []ExchangeRate) (ExchangeRate, []bool) {
+		latest := provider.Day(quotes[0].time)
+		for _, q := range quotes[1:] {
+			if day := provider.Day(q.time); day.After(latest) {
+				latest = day
+			}
+		}
+
+		candidates := make([]ExchangeRate, 0, len(quotes))
+		idx := make([]int, 0, len(quotes))
+		for i, q := range quotes {
+			if provider.Day(q.time).Equal(latest) {
+				candidates = append(candidates, q)
+				idx = append(idx, i)
+			}
+		}
+
+		r, used := reduce(candidates)
+
+		all := make([]bool, len(quotes))
+		for i, ok := range used {
+			all[idx[i]] = ok
+		}
+
+		return r, all
+	}
+}
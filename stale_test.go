@@ -0,0 +1,133 @@
+package gokuu
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+func TestExchanger_MaxRateAge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 8, 2, 12, 0, 0, 0, time.UTC)
+	published := map[string]time.Time{
+		ProviderNameECB: time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC),
+		ProviderNameCAE: time.Date(2021, 7, 29, 0, 0, 0, 0, time.UTC),
+	}
+	quoted := map[string]string{
+		ProviderNameECB: "73",
+		ProviderNameCAE: "75",
+	}
+
+	testCases := []struct {
+		name          string
+		opts          []Option
+		expectedRate  string
+		expectedStale bool
+		expectedInfo  []SourceInfo
+	}{
+		{
+			name:         "test_without_max_age",
+			opts:         []Option{WithAverageMergeStrategy()},
+			expectedRate: "74",
+			expectedInfo: []SourceInfo{
+				{Name: ProviderNameCAE, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameCAE]},
+				{Name: ProviderNameECB, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameECB]},
+			},
+		},
+		{
+			name:         "test_exclude_stale",
+			opts:         []Option{WithAverageMergeStrategy(), WithMaxRateAge(72 * time.Hour)},
+			expectedRate: "73",
+			expectedInfo: []SourceInfo{
+				{Name: ProviderNameCAE, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameCAE], Stale: true},
+				{Name: ProviderNameECB, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameECB]},
+			},
+		},
+		{
+			name: "test_flag_stale",
+			opts: []Option{
+				WithAverageMergeStrategy(),
+				WithMaxRateAge(72 * time.Hour),
+				WithStalePolicy(StalePolicyFlag),
+			},
+			expectedRate:  "74",
+			expectedStale: true,
+			expectedInfo: []SourceInfo{
+				{Name: ProviderNameCAE, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameCAE], Stale: true},
+				{Name: ProviderNameECB, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameECB]},
+			},
+		},
+		{
+			name: "test_prefer_freshest",
+			opts: []Option{
+				WithPriorityMergeStrategy(),
+				WithMaxRateAge(72 * time.Hour),
+				WithStalePolicy(StalePolicyFlag),
+				WithPreferFreshest(),
+			},
+			expectedRate: "73",
+			expectedInfo: []SourceInfo{
+				{Name: ProviderNameCAE, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameCAE], Stale: true},
+				{Name: ProviderNameECB, Status: ProviderRespStatusOK, PublishedAt: published[ProviderNameECB]},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			e := New(http.DefaultClient, append(tc.opts, WithClock(func() time.Time { return now }))...)
+			e.providers = make([]*Provider, 0)
+
+			for name, prior := range map[string]Prior{ProviderNameECB: 0, ProviderNameCAE: 1} {
+				r := provider.NewMockDecimalExchangeRate(ctrl)
+				r.EXPECT().From().Return(label.Currencies[label.USD]).AnyTimes()
+				r.EXPECT().To().Return(label.Currencies[label.RUB]).AnyTimes()
+				r.EXPECT().Decimal().Return(decimal.RequireFromString(quoted[name])).AnyTimes()
+				r.EXPECT().Time().Return(published[name]).AnyTimes()
+
+				source := provider.NewMockSource(ctrl)
+				source.EXPECT().GetExchangeable().Return([]label.Symbol{label.USD, label.RUB}).AnyTimes()
+				source.EXPECT().FetchLatest(gomock.Any()).Return([]provider.ExchangeRate{r}, nil).AnyTimes()
+
+				e.Register(name, source, prior)
+			}
+
+			resp := e.GetLatest(ctx)
+
+			sort.Slice(resp.Info, func(i, j int) bool {
+				return resp.Info[i].Name < resp.Info[j].Name
+			})
+
+			if diff := cmp.Diff(tc.expectedInfo, resp.Info); diff != "" {
+				t.Errorf("bad info (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(1, len(resp.Result)); diff != "" {
+				t.Fatalf("bad result len (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(tc.expectedRate, resp.Result[0].Decimal().String()); diff != "" {
+				t.Errorf("bad rate (-want, +got): %s", diff)
+			}
+
+			if diff := cmp.Diff(tc.expectedStale, resp.Result[0].Stale()); diff != "" {
+				t.Errorf("bad stale (-want, +got): %s", diff)
+			}
+		})
+	}
+}
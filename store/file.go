@@ -0,0 +1,555 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+// The file starts with the magic and the format version followed by the records. A record is the 4-byte big-endian
+// payload length, the 4-byte CRC-32 of the payload and the payload starting with the record kind. The rates record
+// holds the varint day number since the Unix epoch, the varint fetch time in Unix seconds, the uvarint number of
+// rates and the rates of the day, each one is 3-byte from and to symbols and the uvarint-prefixed decimal string.
+// The fetch record holds the varint fetch time of the snapshot and the uvarint-prefixed lists of the 6-byte pairs
+// that joined and left the snapshot since the previous one. A save appends a rates record per day with the changed
+// rates only and the fetch record unless the snapshot repeats the previous one. The torn or corrupted last record
+// left by a crash is truncated on open, a damaged record followed by other records fails the open with ErrBadFile
+const (
+	fileVersion  = 2
+	headerSize   = 5
+	recordHeader = 8
+	maxRecord    = 16 << 20
+	secondsInDay = 24 * 60 * 60
+)
+
+const (
+	recordRates byte = iota + 1
+	recordFetch
+)
+
+var (
+	fileMagic = []byte("GKRS")
+
+	ErrBadFile = errors.New("bad store file")
+)
+
+var _ Store = (*FileStore)(nil)
+
+// OpenFile opens the append-only store file creating it if it does not exist
+func OpenFile(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	s := &FileStore{
+		f:         f,
+		index:     make(map[time.Time][]int64),
+		pairIndex: make(pairIndex),
+		fetches:   newFetches(),
+	}
+	if err := s.load(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	return s, nil
+}
+
+type FileStore struct {
+	mtx  sync.RWMutex
+	f    *os.File
+	size int64
+	days []time.Time
+	// index the offsets of the records by day in the order of writing
+	index map[time.Time][]int64
+	// pairIndex the days of the records of each pair
+	pairIndex pairIndex
+	// fetches the last fetch time of each pair
+	fetches *fetches
+}
+
+// Close closes the store file
+func (s *FileStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.f.Close()
+}
+
+func (s *FileStore) Save(_ context.Context, snapshot Snapshot) error {
+	rates, err := validate(snapshot)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var buf bytes.Buffer
+
+	// the days written to the buffer, the file offsets and the rates of their records
+	var written []time.Time
+	var offsets []int64
+	var lists [][]Rate
+
+	dates, groups := byDay(rates)
+	for _, day := range dates {
+		stored, err := s.read(day)
+		if err != nil {
+			return fmt.Errorf("save: %w", err)
+		}
+
+		list := changed(stored, groups[day])
+		if len(list) == 0 {
+			continue
+		}
+
+		written = append(written, day)
+		offsets = append(offsets, s.size+int64(buf.Len()))
+		lists = append(lists, list)
+
+		if err := encodeRecord(&buf, day, snapshot.FetchedAt, list); err != nil {
+			return fmt.Errorf("save: %w", err)
+		}
+	}
+
+	at := fetchTime(snapshot)
+	joined, left := s.fetches.diff(rates)
+	if len(joined) > 0 || len(left) > 0 || !at.Equal(s.fetches.last) {
+		if err := encodeFetch(&buf, at, joined, left); err != nil {
+			return fmt.Errorf("save: %w", err)
+		}
+	}
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	if err := s.append(buf.Bytes()); err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+
+	for i, day := range written {
+		s.add(day, offsets[i], lists[i])
+	}
+
+	s.fetches.add(at, joined, left)
+
+	s.size += int64(buf.Len())
+
+	return nil
+}
+
+func (s *FileStore) Get(_ context.Context, date time.Time, from, to label.Symbol) (Rate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return get(s, date, from, to)
+}
+
+func (s *FileStore) Range(_ context.Context, start, end time.Time, from, to label.Symbol) ([]Rate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return rangeRates(s, start, end, from, to)
+}
+
+func (s *FileStore) On(_ context.Context, date time.Time) ([]Rate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return on(s, date)
+}
+
+func (s *FileStore) LastDate(context.Context) (time.Time, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return lastDate(s)
+}
+
+func (s *FileStore) FetchedAt(_ context.Context, from, to label.Symbol) (time.Time, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.fetches.fetchedAt(from, to)
+}
+
+func (s *FileStore) dates() []time.Time {
+	return s.days
+}
+
+func (s *FileStore) pairs() pairIndex {
+	return s.pairIndex
+}
+
+// read merges the records of the day, the later records replace the rates of the earlier ones
+func (s *FileStore) read(day time.Time) (dayRates, error) {
+	offsets := s.index[day]
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	rates := make(dayRates)
+	for _, offset := range offsets {
+		payload, err := s.readRecord(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		_, list, err := decodeRecord(payload)
+		if err != nil {
+			return nil, fmt.Errorf("record at %d: %w", offset, err)
+		}
+
+		for _, r := range list {
+			rates[pair{from: r.From, to: r.To}] = r
+		}
+	}
+
+	return rates, nil
+}
+
+// load checks the header and indexes the records truncating the torn last record
+func (s *FileStore) load() error {
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() < headerSize {
+		return s.reset(info.Size())
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := s.f.ReadAt(header, 0); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(header[:len(fileMagic)], fileMagic) || header[len(fileMagic)] != fileVersion {
+		return ErrBadFile
+	}
+
+	offset := int64(headerSize)
+	for offset < info.Size() {
+		size, err := s.loadRecord(offset)
+		if err != nil {
+			// a crash damages the last record only, the records after the damaged one are not dropped
+			if size > 0 && offset+size < info.Size() {
+				return fmt.Errorf("%w: record at %d: %v", ErrBadFile, offset, err)
+			}
+
+			break
+		}
+
+		offset += size
+	}
+
+	s.size = offset
+	if offset < info.Size() {
+		if err := s.f.Truncate(offset); err != nil {
+			return err
+		}
+
+		return s.f.Sync()
+	}
+
+	return nil
+}
+
+// reset writes the header to the empty file or to the file torn before the header was written
+func (s *FileStore) reset(size int64) error {
+	header := append(append([]byte{}, fileMagic...), fileVersion)
+
+	b := make([]byte, size)
+	if _, err := s.f.ReadAt(b, 0); err != nil {
+		return err
+	}
+
+	if !bytes.HasPrefix(header, b) {
+		return ErrBadFile
+	}
+
+	if _, err := s.f.WriteAt(header, 0); err != nil {
+		return err
+	}
+
+	s.size = headerSize
+
+	return s.f.Sync()
+}
+
+// append writes the records at the end of the file. A failed write is rolled back
+func (s *FileStore) append(b []byte) error {
+	if _, err := s.f.WriteAt(b, s.size); err != nil {
+		return s.rollback(err)
+	}
+
+	if err := s.f.Sync(); err != nil {
+		return s.rollback(err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) rollback(err error) error {
+	if tErr := s.f.Truncate(s.size); tErr != nil {
+		return fmt.Errorf("%v, truncate: %w", err, tErr)
+	}
+
+	return err
+}
+
+// loadRecord reads, decodes and indexes the record at the offset. The size of the record is returned once its
+// header is read, zero if the header is torn
+func (s *FileStore) loadRecord(offset int64) (int64, error) {
+	header := make([]byte, recordHeader)
+	if _, err := s.f.ReadAt(header, offset); err != nil {
+		return 0, err
+	}
+
+	size := recordHeader + int64(binary.BigEndian.Uint32(header[:4]))
+
+	payload, err := s.readRecord(offset)
+	if err != nil {
+		return size, err
+	}
+
+	if len(payload) > 0 && payload[0] == recordFetch {
+		at, joined, left, err := decodeFetch(payload)
+		if err != nil {
+			return size, err
+		}
+
+		s.fetches.add(at, joined, left)
+
+		return size, nil
+	}
+
+	day, list, err := decodeRecord(payload)
+	if err != nil {
+		return size, err
+	}
+
+	s.add(day, offset, list)
+
+	return size, nil
+}
+
+func (s *FileStore) readRecord(offset int64) ([]byte, error) {
+	header := make([]byte, recordHeader)
+	if _, err := s.f.ReadAt(header, offset); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(header[:4])
+	if n > maxRecord {
+		return nil, fmt.Errorf("%w: record at %d is too large", ErrBadFile, offset)
+	}
+
+	payload := make([]byte, n)
+	if _, err := s.f.ReadAt(payload, offset+recordHeader); err != nil {
+		return nil, err
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch of record at %d", ErrBadFile, offset)
+	}
+
+	return payload, nil
+}
+
+// add indexes the record of the day with the rates
+func (s *FileStore) add(day time.Time, offset int64, rates []Rate) {
+	if _, ok := s.index[day]; !ok {
+		s.days = insertDay(s.days, day)
+	}
+
+	s.index[day] = append(s.index[day], offset)
+	s.pairIndex.add(day, rates)
+}
+
+func encodeRecord(w *bytes.Buffer, day, fetchedAt time.Time, rates []Rate) error {
+	payload := make([]byte, 1, 1+3*binary.MaxVarintLen64+len(rates)*16)
+	payload[0] = recordRates
+	payload = appendVarint(payload, day.Unix()/secondsInDay)
+	payload = appendVarint(payload, fetchedAt.Unix())
+	payload = appendUvarint(payload, uint64(len(rates)))
+
+	for _, r := range rates {
+		value := r.Rate.String()
+
+		payload = append(payload, r.From...)
+		payload = append(payload, r.To...)
+		payload = appendUvarint(payload, uint64(len(value)))
+		payload = append(payload, value...)
+	}
+
+	if len(payload) > maxRecord {
+		return fmt.Errorf("record of %s is too large", day.Format("2006-01-02"))
+	}
+
+	writeRecord(w, payload)
+
+	return nil
+}
+
+func encodeFetch(w *bytes.Buffer, fetchedAt time.Time, joined, left []pair) error {
+	payload := make([]byte, 1, 1+3*binary.MaxVarintLen64+(len(joined)+len(left))*6)
+	payload[0] = recordFetch
+	payload = appendVarint(payload, fetchedAt.Unix())
+
+	for _, keys := range [][]pair{joined, left} {
+		payload = appendUvarint(payload, uint64(len(keys)))
+		for _, key := range keys {
+			payload = append(payload, key.from...)
+			payload = append(payload, key.to...)
+		}
+	}
+
+	if len(payload) > maxRecord {
+		return errors.New("fetch record is too large")
+	}
+
+	writeRecord(w, payload)
+
+	return nil
+}
+
+// writeRecord writes the header and the payload of the record
+func writeRecord(w *bytes.Buffer, payload []byte) {
+	header := make([]byte, recordHeader)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+
+	w.Write(header)
+	w.Write(payload)
+}
+
+func decodeRecord(payload []byte) (time.Time, []Rate, error) {
+	if len(payload) == 0 || payload[0] != recordRates {
+		return time.Time{}, nil, fmt.Errorf("%w: bad record kind", ErrBadFile)
+	}
+
+	r := bytes.NewReader(payload[1:])
+
+	days, err := binary.ReadVarint(r)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("decode day: %w", err)
+	}
+
+	fetched, err := binary.ReadVarint(r)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("decode fetch time: %w", err)
+	}
+
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("decode rates number: %w", err)
+	}
+
+	if n > uint64(r.Len()) {
+		return time.Time{}, nil, fmt.Errorf("%w: bad rates number %d", ErrBadFile, n)
+	}
+
+	day := time.Unix(days*secondsInDay, 0).UTC()
+	fetchedAt := time.Unix(fetched, 0).UTC()
+
+	rates := make([]Rate, 0, n)
+	for i := uint64(0); i < n; i++ {
+		symbols := make([]byte, 6)
+		if _, err := io.ReadFull(r, symbols); err != nil {
+			return time.Time{}, nil, fmt.Errorf("decode symbols: %w", err)
+		}
+
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("decode rate: %w", err)
+		}
+
+		if size > uint64(r.Len()) {
+			return time.Time{}, nil, fmt.Errorf("%w: bad rate length %d", ErrBadFile, size)
+		}
+
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return time.Time{}, nil, fmt.Errorf("decode rate: %w", err)
+		}
+
+		rate, err := decimal.NewFromString(string(value))
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("decode rate: %w", err)
+		}
+
+		rates = append(rates, Rate{
+			Date:      day,
+			From:      label.Symbol(symbols[:3]),
+			To:        label.Symbol(symbols[3:]),
+			Rate:      rate,
+			FetchedAt: fetchedAt,
+		})
+	}
+
+	if r.Len() != 0 {
+		return time.Time{}, nil, fmt.Errorf("%w: trailing bytes", ErrBadFile)
+	}
+
+	return day, rates, nil
+}
+
+func decodeFetch(payload []byte) (time.Time, []pair, []pair, error) {
+	r := bytes.NewReader(payload[1:])
+
+	fetched, err := binary.ReadVarint(r)
+	if err != nil {
+		return time.Time{}, nil, nil, fmt.Errorf("decode fetch time: %w", err)
+	}
+
+	var lists [2][]pair
+	for i := range lists {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return time.Time{}, nil, nil, fmt.Errorf("decode pairs number: %w", err)
+		}
+
+		if n > uint64(r.Len()) {
+			return time.Time{}, nil, nil, fmt.Errorf("%w: bad pairs number %d", ErrBadFile, n)
+		}
+
+		for j := uint64(0); j < n; j++ {
+			symbols := make([]byte, 6)
+			if _, err := io.ReadFull(r, symbols); err != nil {
+				return time.Time{}, nil, nil, fmt.Errorf("decode symbols: %w", err)
+			}
+
+			lists[i] = append(lists[i], pair{from: label.Symbol(symbols[:3]), to: label.Symbol(symbols[3:])})
+		}
+	}
+
+	if r.Len() != 0 {
+		return time.Time{}, nil, nil, fmt.Errorf("%w: trailing bytes", ErrBadFile)
+	}
+
+	return time.Unix(fetched, 0).UTC(), lists[0], lists[1], nil
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutVarint(buf, v)]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutUvarint(buf, v)]...)
+}
@@ -0,0 +1,379 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+)
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+
+	return info.Size()
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "rates.db")
+
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	rates := []Rate{
+		testRate(day1, label.EUR, label.USD, "1.1579"),
+		testRate(day2, label.EUR, label.USD, "1.1622"),
+	}
+	if err := s.Save(ctx, Snapshot{FetchedAt: fetchedAt, Rates: rates}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	size := fileSize(t, path)
+
+	// the saved rates are not written again
+	if err := s.Save(ctx, Snapshot{FetchedAt: fetchedAt, Rates: rates}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if diff := cmp.Diff(size, fileSize(t, path)); diff != "" {
+		t.Errorf("bad file size (-want, +got): %s", diff)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	s, err = OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+
+	list, err := s.Range(ctx, day1, day3, label.EUR, label.USD)
+	if err != nil {
+		t.Fatalf("range: %v", err)
+	}
+
+	if diff := cmp.Diff(rates, list); diff != "" {
+		t.Errorf("bad rates (-want, +got): %s", diff)
+	}
+}
+
+func TestFileStore_ReopenFetchedAt(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rates.db")
+
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	saveFetches(t, s)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	s, err = OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+
+	checkFetches(t, s)
+}
+
+func TestFileStore_TornTail(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// damage corrupts the last record of the file of the size
+		damage func(t *testing.T, f *os.File, size int64)
+	}{
+		{
+			name: "test_torn_record",
+			damage: func(t *testing.T, f *os.File, size int64) {
+				if err := f.Truncate(size - 3); err != nil {
+					t.Fatalf("truncate: %v", err)
+				}
+			},
+		},
+		{
+			name: "test_corrupted_record",
+			damage: func(t *testing.T, f *os.File, size int64) {
+				if _, err := f.WriteAt([]byte{0xff}, size-1); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "rates.db")
+
+			s, err := OpenFile(path)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+
+			first := testRate(day1, label.EUR, label.USD, "1.1579")
+			if err := s.Save(ctx, Snapshot{FetchedAt: fetchedAt, Rates: []Rate{first}}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			valid := fileSize(t, path)
+
+			second := testRate(day2, label.EUR, label.USD, "1.1622")
+			if err := s.Save(ctx, Snapshot{FetchedAt: fetchedAt, Rates: []Rate{second}}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			s.Close()
+
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatalf("open file: %v", err)
+			}
+
+			tc.damage(t, f, fileSize(t, path))
+			f.Close()
+
+			s, err = OpenFile(path)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			defer s.Close()
+
+			if diff := cmp.Diff(valid, fileSize(t, path)); diff != "" {
+				t.Errorf("bad file size (-want, +got): %s", diff)
+			}
+
+			got, err := s.Get(ctx, day3, label.EUR, label.USD)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+
+			if diff := cmp.Diff(first, got); diff != "" {
+				t.Errorf("bad rate (-want, +got): %s", diff)
+			}
+
+			// the store keeps appending after the last valid record
+			if err := s.Save(ctx, Snapshot{FetchedAt: fetchedAt, Rates: []Rate{second}}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			if got, err = s.Get(ctx, day3, label.EUR, label.USD); err != nil {
+				t.Fatalf("get: %v", err)
+			}
+
+			if diff := cmp.Diff(second, got); diff != "" {
+				t.Errorf("bad rate (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFileStore_BadFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rates.db")
+	if err := os.WriteFile(path, []byte("not a store file"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if _, err := OpenFile(path); !errors.Is(err, ErrBadFile) {
+		t.Errorf("bad error, want %v, got %v", ErrBadFile, err)
+	}
+}
+
+func TestFileStore_CorruptedMiddleRecord(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// damage corrupts the first record ending at the offset
+		damage func(t *testing.T, f *os.File, end int64)
+	}{
+		{
+			name: "test_checksum",
+			damage: func(t *testing.T, f *os.File, end int64) {
+				if _, err := f.WriteAt([]byte{0xff}, end-1); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			},
+		},
+		{
+			name: "test_bad_varint",
+			damage: func(t *testing.T, f *os.File, end int64) {
+				// the payload of the varint continuation bytes with the matching checksum
+				payload := bytes.Repeat([]byte{0xff}, int(end-headerSize-recordHeader))
+
+				header := make([]byte, recordHeader)
+				binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+				binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+
+				if _, err := f.WriteAt(append(header, payload...), headerSize); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "rates.db")
+
+			s, err := OpenFile(path)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+
+			if err := s.Save(ctx, Snapshot{
+				FetchedAt: fetchedAt,
+				Rates:     []Rate{testRate(day1, label.EUR, label.USD, "1.1579")},
+			}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			end := fileSize(t, path)
+
+			if err := s.Save(ctx, Snapshot{
+				FetchedAt: fetchedAt,
+				Rates:     []Rate{testRate(day2, label.EUR, label.USD, "1.1622")},
+			}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			s.Close()
+
+			size := fileSize(t, path)
+
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatalf("open file: %v", err)
+			}
+
+			tc.damage(t, f, end)
+			f.Close()
+
+			if _, err := OpenFile(path); !errors.Is(err, ErrBadFile) {
+				t.Errorf("bad error, want %v, got %v", ErrBadFile, err)
+			}
+
+			// the valid records after the damaged one are kept
+			if diff := cmp.Diff(size, fileSize(t, path)); diff != "" {
+				t.Errorf("bad file size (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+// countingDays counts the publication days read by the lookups
+type countingDays struct {
+	days
+	reads []time.Time
+}
+
+func (d *countingDays) read(day time.Time) (dayRates, error) {
+	d.reads = append(d.reads, day)
+	return d.days.read(day)
+}
+
+func TestFileStore_IndexedLookups(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	s, err := OpenFile(filepath.Join(t.TempDir(), "rates.db"))
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+
+	defer s.Close()
+
+	// a month of the EUR-USD rates, the EUR-RUB rate only on the first day
+	start := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	rates := []Rate{testRate(start, label.EUR, label.RUB, "86.4")}
+	for i := 0; i < 30; i++ {
+		rates = append(rates, testRate(start.AddDate(0, 0, i), label.EUR, label.USD, "1.18"))
+	}
+
+	if err := s.Save(ctx, Snapshot{FetchedAt: fetchedAt, Rates: rates}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	last := start.AddDate(0, 0, 29)
+
+	d := &countingDays{days: s}
+	if _, err := get(d, last, label.EUR, label.USD); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if diff := cmp.Diff([]time.Time{last}, d.reads); diff != "" {
+		t.Errorf("bad days read by get (-want, +got): %s", diff)
+	}
+
+	d = &countingDays{days: s}
+
+	list, err := on(d, last.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("on: %v", err)
+	}
+
+	if diff := cmp.Diff(2, len(list)); diff != "" {
+		t.Errorf("bad rates on date (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(2, len(d.reads)); diff != "" {
+		t.Errorf("bad number of days read by on (-want, +got): %s", diff)
+	}
+
+	// the index is rebuilt on open
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := OpenFile(s.f.Name())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	defer reopened.Close()
+
+	r, err := reopened.Get(ctx, last, label.EUR, label.RUB)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if diff := cmp.Diff(start, r.Date); diff != "" {
+		t.Errorf("bad publication date (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/robotomize/gokuu/label"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns the store keeping the exchange rates for the process lifetime
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{rates: make(map[time.Time]dayRates), index: make(pairIndex), fetches: newFetches()}
+}
+
+type MemoryStore struct {
+	mtx   sync.RWMutex
+	days  []time.Time
+	rates map[time.Time]dayRates
+	index pairIndex
+	// fetches the last fetch time of each pair
+	fetches *fetches
+}
+
+func (s *MemoryStore) Save(_ context.Context, snapshot Snapshot) error {
+	rates, err := validate(snapshot)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	dates, groups := byDay(rates)
+	for _, day := range dates {
+		stored, ok := s.rates[day]
+		if !ok {
+			stored = make(dayRates)
+			s.rates[day] = stored
+			s.days = insertDay(s.days, day)
+		}
+
+		list := changed(stored, groups[day])
+		for _, r := range list {
+			stored[pair{from: r.From, to: r.To}] = r
+		}
+
+		s.index.add(day, list)
+	}
+
+	joined, left := s.fetches.diff(rates)
+	s.fetches.add(fetchTime(snapshot), joined, left)
+
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, date time.Time, from, to label.Symbol) (Rate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return get(s, date, from, to)
+}
+
+func (s *MemoryStore) Range(_ context.Context, start, end time.Time, from, to label.Symbol) ([]Rate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return rangeRates(s, start, end, from, to)
+}
+
+func (s *MemoryStore) On(_ context.Context, date time.Time) ([]Rate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return on(s, date)
+}
+
+func (s *MemoryStore) LastDate(context.Context) (time.Time, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return lastDate(s)
+}
+
+func (s *MemoryStore) FetchedAt(_ context.Context, from, to label.Symbol) (time.Time, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.fetches.fetchedAt(from, to)
+}
+
+func (s *MemoryStore) dates() []time.Time {
+	return s.days
+}
+
+func (s *MemoryStore) pairs() pairIndex {
+	return s.index
+}
+
+func (s *MemoryStore) read(day time.Time) (dayRates, error) {
+	return s.rates[day], nil
+}
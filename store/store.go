@@ -0,0 +1,323 @@
+// Package store keeps the archive of the exchange rates by publication date. The exchanger saves the latest
+// exchange rates to the store and answers the historical queries from it, see gokuu.WithStore
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+	"github.com/robotomize/gokuu/provider"
+)
+
+var (
+	ErrNotFound    = errors.New("exchange rate not found")
+	ErrInvalidRate = errors.New("invalid exchange rate")
+)
+
+// Rate the exchange rate of the currency pair published on the date
+type Rate struct {
+	// Date the publication day of the exchange rate, midnight UTC
+	Date time.Time
+	From label.Symbol
+	To   label.Symbol
+	Rate decimal.Decimal
+	// FetchedAt time when the exchange rate was first fetched with this value, second precision
+	FetchedAt time.Time
+}
+
+// Snapshot the exchange rates fetched at once
+type Snapshot struct {
+	FetchedAt time.Time
+	Rates     []Rate
+}
+
+// Store the archive of the exchange rates. The rate saved again for the same day and pair replaces the stored one
+type Store interface {
+	// Save stores the exchange rates of the snapshot
+	Save(ctx context.Context, s Snapshot) error
+	// Get returns the exchange rate of the pair in effect on the date: the last one published on or before the day
+	Get(ctx context.Context, date time.Time, from, to label.Symbol) (Rate, error)
+	// Range returns the exchange rates of the pair published between start and end inclusive in ascending order
+	Range(ctx context.Context, start, end time.Time, from, to label.Symbol) ([]Rate, error)
+	// On returns the exchange rates of all pairs in effect on the date
+	On(ctx context.Context, date time.Time) ([]Rate, error)
+	// LastDate returns the latest publication date of the stored exchange rates
+	LastDate(ctx context.Context) (time.Time, error)
+	// FetchedAt returns the fetch time of the last saved snapshot holding the exchange rate of the pair
+	FetchedAt(ctx context.Context, from, to label.Symbol) (time.Time, error)
+}
+
+type pair struct {
+	from, to label.Symbol
+}
+
+// dayRates the exchange rates of a publication day by pair
+type dayRates map[pair]Rate
+
+// pairIndex the publication days of the exchange rates of each pair in ascending order, the lookups read
+// only the days holding the rates they need
+type pairIndex map[pair][]time.Time
+
+// add indexes the rates of the day
+func (x pairIndex) add(day time.Time, rates []Rate) {
+	for _, r := range rates {
+		key := pair{from: r.From, to: r.To}
+
+		dates := x[key]
+		if i := searchAfter(dates, day); i > 0 && dates[i-1].Equal(day) {
+			continue
+		}
+
+		x[key] = insertDay(dates, day)
+	}
+}
+
+// fetches the fetch time of the last saved snapshot holding each pair. A save of the unchanged rates stores
+// nothing but the pairs that joined or left the snapshot
+type fetches struct {
+	last time.Time
+	// held the pairs of the last snapshot
+	held map[pair]struct{}
+	// gone the fetch time of the last snapshot holding the pairs missing from the last one
+	gone map[pair]time.Time
+}
+
+func newFetches() *fetches {
+	return &fetches{held: make(map[pair]struct{}), gone: make(map[pair]time.Time)}
+}
+
+// diff returns the pairs of the rates missing from the last snapshot and the pairs of the last snapshot missing
+// from the rates
+func (f *fetches) diff(rates []Rate) (joined, left []pair) {
+	keys := make(map[pair]struct{}, len(rates))
+	for _, r := range rates {
+		key := pair{from: r.From, to: r.To}
+		if _, ok := keys[key]; ok {
+			continue
+		}
+
+		keys[key] = struct{}{}
+		if _, ok := f.held[key]; !ok {
+			joined = append(joined, key)
+		}
+	}
+
+	for key := range f.held {
+		if _, ok := keys[key]; !ok {
+			left = append(left, key)
+		}
+	}
+
+	sort.Slice(left, func(i, j int) bool {
+		if left[i].from != left[j].from {
+			return left[i].from < left[j].from
+		}
+
+		return left[i].to < left[j].to
+	})
+
+	return joined, left
+}
+
+// add records the snapshot fetched at the time
+func (f *fetches) add(at time.Time, joined, left []pair) {
+	for _, key := range left {
+		delete(f.held, key)
+		f.gone[key] = f.last
+	}
+
+	for _, key := range joined {
+		f.held[key] = struct{}{}
+		delete(f.gone, key)
+	}
+
+	f.last = at
+}
+
+func (f *fetches) fetchedAt(from, to label.Symbol) (time.Time, error) {
+	key := pair{from: from, to: to}
+	if _, ok := f.held[key]; ok {
+		return f.last, nil
+	}
+
+	if t, ok := f.gone[key]; ok {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s-%s", ErrNotFound, from, to)
+}
+
+// days reads the exchange rates of the stored publication days
+type days interface {
+	// dates returns the publication days in ascending order
+	dates() []time.Time
+	// pairs returns the publication days of each pair
+	pairs() pairIndex
+	// read returns the exchange rates of the publication day
+	read(day time.Time) (dayRates, error)
+}
+
+// validate checks the rates of the snapshot and sets their publication days and fetch time
+func validate(s Snapshot) ([]Rate, error) {
+	rates := make([]Rate, len(s.Rates))
+	for i, r := range s.Rates {
+		if r.Date.IsZero() || len(r.From) != 3 || len(r.To) != 3 || r.Rate.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: %s-%s %s on %s", ErrInvalidRate, r.From, r.To, r.Rate, r.Date)
+		}
+
+		r.Date = provider.Day(r.Date)
+		r.FetchedAt = fetchTime(s)
+		rates[i] = r
+	}
+
+	return rates, nil
+}
+
+// fetchTime returns the fetch time of the snapshot as stored, second precision
+func fetchTime(s Snapshot) time.Time {
+	return s.FetchedAt.Truncate(time.Second).UTC()
+}
+
+// changed returns the rates of the day that differ from the stored ones
+func changed(stored dayRates, rates []Rate) []Rate {
+	var list []Rate
+	for _, r := range rates {
+		if cur, ok := stored[pair{from: r.From, to: r.To}]; ok && cur.Rate.Equal(r.Rate) {
+			continue
+		}
+
+		list = append(list, r)
+	}
+
+	return list
+}
+
+// byDay groups the rates by publication day in ascending order of the days
+func byDay(rates []Rate) ([]time.Time, map[time.Time][]Rate) {
+	groups := make(map[time.Time][]Rate)
+
+	var dates []time.Time
+	for _, r := range rates {
+		if _, ok := groups[r.Date]; !ok {
+			dates = append(dates, r.Date)
+		}
+
+		groups[r.Date] = append(groups[r.Date], r)
+	}
+
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	return dates, groups
+}
+
+// searchAfter returns the index of the first day after the date
+func searchAfter(dates []time.Time, date time.Time) int {
+	return sort.Search(len(dates), func(i int) bool {
+		return dates[i].After(date)
+	})
+}
+
+// insertDay adds the day keeping the days sorted
+func insertDay(dates []time.Time, day time.Time) []time.Time {
+	idx := searchAfter(dates, day)
+
+	dates = append(dates, time.Time{})
+	copy(dates[idx+1:], dates[idx:])
+	dates[idx] = day
+
+	return dates
+}
+
+func get(d days, date time.Time, from, to label.Symbol) (Rate, error) {
+	key := pair{from: from, to: to}
+	dates := d.pairs()[key]
+
+	if i := searchAfter(dates, provider.Day(date)) - 1; i >= 0 {
+		rates, err := d.read(dates[i])
+		if err != nil {
+			return Rate{}, err
+		}
+
+		if r, ok := rates[key]; ok {
+			return r, nil
+		}
+	}
+
+	return Rate{}, fmt.Errorf("%w: %s-%s on %s", ErrNotFound, from, to, date.Format("2006-01-02"))
+}
+
+func rangeRates(d days, start, end time.Time, from, to label.Symbol) ([]Rate, error) {
+	start, end = provider.Day(start), provider.Day(end)
+	key := pair{from: from, to: to}
+	dates := d.pairs()[key]
+
+	var list []Rate
+	for i := searchAfter(dates, start.Add(-time.Nanosecond)); i < len(dates) && !dates[i].After(end); i++ {
+		rates, err := d.read(dates[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if r, ok := rates[key]; ok {
+			list = append(list, r)
+		}
+	}
+
+	return list, nil
+}
+
+func on(d days, date time.Time) ([]Rate, error) {
+	day := provider.Day(date)
+
+	// the pairs by the day of their last rate on or before the date
+	last := make(map[time.Time][]pair)
+	for key, dates := range d.pairs() {
+		if i := searchAfter(dates, day) - 1; i >= 0 {
+			last[dates[i]] = append(last[dates[i]], key)
+		}
+	}
+
+	var list []Rate
+	for published, keys := range last {
+		rates, err := d.read(published)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if r, ok := rates[key]; ok {
+				list = append(list, r)
+			}
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%w: on %s", ErrNotFound, date.Format("2006-01-02"))
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].From != list[j].From {
+			return list[i].From < list[j].From
+		}
+
+		return list[i].To < list[j].To
+	})
+
+	return list, nil
+}
+
+func lastDate(d days) (time.Time, error) {
+	dates := d.dates()
+	if len(dates) == 0 {
+		return time.Time{}, ErrNotFound
+	}
+
+	return dates[len(dates)-1], nil
+}
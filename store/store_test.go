@@ -0,0 +1,335 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+var (
+	day1 = time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	day2 = time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+	day3 = time.Date(2021, 10, 5, 0, 0, 0, 0, time.UTC)
+
+	fetchedAt = time.Date(2021, 10, 5, 16, 30, 0, 0, time.UTC)
+)
+
+func testRate(date time.Time, from, to label.Symbol, rate string) Rate {
+	return Rate{
+		Date:      date,
+		From:      from,
+		To:        to,
+		Rate:      decimal.RequireFromString(rate),
+		FetchedAt: fetchedAt,
+	}
+}
+
+// testStores returns the stores under test by name
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	fs, err := OpenFile(filepath.Join(t.TempDir(), "rates.db"))
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+
+	t.Cleanup(func() {
+		fs.Close()
+	})
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   fs,
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	rates := []Rate{
+		testRate(day1, label.EUR, label.USD, "1.1579"),
+		testRate(day1, label.EUR, label.RUB, "84.2476"),
+		testRate(day2.Add(15*time.Hour), label.EUR, label.USD, "1.1622"),
+		testRate(day3, label.EUR, label.USD, "1.1593"),
+	}
+
+	for name, s := range testStores(t) {
+		name, s := name, s
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			if _, err := s.LastDate(ctx); !errors.Is(err, ErrNotFound) {
+				t.Errorf("bad empty last date error, want %v, got %v", ErrNotFound, err)
+			}
+
+			if err := s.Save(ctx, Snapshot{FetchedAt: fetchedAt, Rates: rates}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			last, err := s.LastDate(ctx)
+			if err != nil {
+				t.Fatalf("last date: %v", err)
+			}
+
+			if diff := cmp.Diff(day3, last); diff != "" {
+				t.Errorf("bad last date (-want, +got): %s", diff)
+			}
+
+			testCases := []struct {
+				name        string
+				date        time.Time
+				from, to    label.Symbol
+				expected    Rate
+				expectedErr error
+			}{
+				{
+					name:     "test_published_day",
+					date:     day2.Add(20 * time.Hour),
+					from:     label.EUR,
+					to:       label.USD,
+					expected: testRate(day2, label.EUR, label.USD, "1.1622"),
+				},
+				{
+					name:     "test_weekend",
+					date:     day1.AddDate(0, 0, 2),
+					from:     label.EUR,
+					to:       label.USD,
+					expected: testRate(day1, label.EUR, label.USD, "1.1579"),
+				},
+				{
+					name:     "test_pair_not_published_on_day",
+					date:     day3,
+					from:     label.EUR,
+					to:       label.RUB,
+					expected: testRate(day1, label.EUR, label.RUB, "84.2476"),
+				},
+				{
+					name:        "test_before_first_day",
+					date:        day1.AddDate(0, 0, -1),
+					from:        label.EUR,
+					to:          label.USD,
+					expectedErr: ErrNotFound,
+				},
+				{
+					name:        "test_unknown_pair",
+					date:        day3,
+					from:        label.USD,
+					to:          label.JPY,
+					expectedErr: ErrNotFound,
+				},
+			}
+
+			for _, tc := range testCases {
+				got, err := s.Get(ctx, tc.date, tc.from, tc.to)
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("%s: bad error, want %v, got %v", tc.name, tc.expectedErr, err)
+				}
+
+				if diff := cmp.Diff(tc.expected, got); diff != "" {
+					t.Errorf("%s: bad rate (-want, +got): %s", tc.name, diff)
+				}
+			}
+
+			list, err := s.Range(ctx, day1.Add(time.Hour), day2, label.EUR, label.USD)
+			if err != nil {
+				t.Fatalf("range: %v", err)
+			}
+
+			expected := []Rate{
+				testRate(day1, label.EUR, label.USD, "1.1579"),
+				testRate(day2, label.EUR, label.USD, "1.1622"),
+			}
+			if diff := cmp.Diff(expected, list); diff != "" {
+				t.Errorf("bad range (-want, +got): %s", diff)
+			}
+
+			list, err = s.On(ctx, day3)
+			if err != nil {
+				t.Fatalf("on: %v", err)
+			}
+
+			expected = []Rate{
+				testRate(day1, label.EUR, label.RUB, "84.2476"),
+				testRate(day3, label.EUR, label.USD, "1.1593"),
+			}
+			if diff := cmp.Diff(expected, list); diff != "" {
+				t.Errorf("bad rates on date (-want, +got): %s", diff)
+			}
+
+			if _, err := s.On(ctx, day1.AddDate(0, 0, -1)); !errors.Is(err, ErrNotFound) {
+				t.Errorf("bad rates on date error, want %v, got %v", ErrNotFound, err)
+			}
+		})
+	}
+}
+
+func TestStore_SaveReplaces(t *testing.T) {
+	t.Parallel()
+
+	for name, s := range testStores(t) {
+		name, s := name, s
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			later := fetchedAt.Add(time.Hour)
+
+			if err := s.Save(ctx, Snapshot{
+				FetchedAt: fetchedAt,
+				Rates: []Rate{
+					testRate(day1, label.EUR, label.USD, "1.1579"),
+					testRate(day1, label.EUR, label.RUB, "84.2476"),
+				},
+			}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			// the unchanged rate keeps the first fetch time
+			if err := s.Save(ctx, Snapshot{
+				FetchedAt: later,
+				Rates: []Rate{
+					testRate(day1, label.EUR, label.USD, "1.1579"),
+					testRate(day1, label.EUR, label.RUB, "84.3"),
+				},
+			}); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			list, err := s.On(ctx, day1)
+			if err != nil {
+				t.Fatalf("on: %v", err)
+			}
+
+			updated := testRate(day1, label.EUR, label.RUB, "84.3")
+			updated.FetchedAt = later
+
+			expected := []Rate{updated, testRate(day1, label.EUR, label.USD, "1.1579")}
+			if diff := cmp.Diff(expected, list); diff != "" {
+				t.Errorf("bad rates (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+// saveFetches saves the EUR-USD and EUR-RUB rates, then the EUR-USD rate unchanged an hour later
+func saveFetches(t *testing.T, s Store) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if err := s.Save(ctx, Snapshot{
+		FetchedAt: fetchedAt,
+		Rates: []Rate{
+			testRate(day1, label.EUR, label.USD, "1.1579"),
+			testRate(day1, label.EUR, label.RUB, "84.2476"),
+		},
+	}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if err := s.Save(ctx, Snapshot{
+		FetchedAt: fetchedAt.Add(time.Hour),
+		Rates:     []Rate{testRate(day1, label.EUR, label.USD, "1.1579")},
+	}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+}
+
+// checkFetches checks the fetch times of the pairs saved by saveFetches
+func checkFetches(t *testing.T, s Store) {
+	t.Helper()
+
+	testCases := []struct {
+		name        string
+		from, to    label.Symbol
+		expected    time.Time
+		expectedErr error
+	}{
+		{
+			name:     "test_refetched_unchanged",
+			from:     label.EUR,
+			to:       label.USD,
+			expected: fetchedAt.Add(time.Hour),
+		},
+		{
+			name:     "test_missing_from_last",
+			from:     label.EUR,
+			to:       label.RUB,
+			expected: fetchedAt,
+		},
+		{
+			name:        "test_not_saved",
+			from:        label.EUR,
+			to:          label.GBP,
+			expectedErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		at, err := s.FetchedAt(context.Background(), tc.from, tc.to)
+		if !errors.Is(err, tc.expectedErr) {
+			t.Errorf("%s: bad error, want %v, got %v", tc.name, tc.expectedErr, err)
+		}
+
+		if diff := cmp.Diff(tc.expected, at); diff != "" {
+			t.Errorf("%s: bad fetch time (-want, +got): %s", tc.name, diff)
+		}
+	}
+}
+
+func TestStore_FetchedAt(t *testing.T) {
+	t.Parallel()
+
+	for name, s := range testStores(t) {
+		name, s := name, s
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			saveFetches(t, s)
+			checkFetches(t, s)
+		})
+	}
+}
+
+func TestStore_SaveInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		rate Rate
+	}{
+		{
+			name: "test_zero_date",
+			rate: testRate(time.Time{}, label.EUR, label.USD, "1.1579"),
+		},
+		{
+			name: "test_bad_symbol",
+			rate: testRate(day1, "EU", label.USD, "1.1579"),
+		},
+		{
+			name: "test_zero_rate",
+			rate: testRate(day1, label.EUR, label.USD, "0"),
+		},
+	}
+
+	for name, s := range testStores(t) {
+		for _, tc := range testCases {
+			err := s.Save(context.Background(), Snapshot{FetchedAt: fetchedAt, Rates: []Rate{tc.rate}})
+			if !errors.Is(err, ErrInvalidRate) {
+				t.Errorf("%s %s: bad error, want %v, got %v", name, tc.name, ErrInvalidRate, err)
+			}
+		}
+
+		if _, err := s.LastDate(context.Background()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: invalid rates saved", name)
+		}
+	}
+}
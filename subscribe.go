@@ -0,0 +1,179 @@
+package gokuu
+
+import (
+	"context"
+	"sync"
+
+	"github.com/robotomize/gokuu/decimal"
+	"github.com/robotomize/gokuu/label"
+)
+
+// DefaultSubscriptionBuffer the number of events buffered for a subscriber
+const DefaultSubscriptionBuffer = 64
+
+// Pair the currency pair
+type Pair struct {
+	From label.Symbol
+	To   label.Symbol
+}
+
+// RateFilter selects the rate events delivered to the subscriber
+type RateFilter struct {
+	// Pairs the currency pairs of the events, all pairs if empty
+	Pairs []Pair
+	// MinChange the min absolute change of the rate in percent
+	MinChange float64
+	// Buffer the size of the channel buffer, DefaultSubscriptionBuffer if zero
+	Buffer int
+}
+
+// RateEvent the change of the exchange rate between two snapshots of the cache
+type RateEvent struct {
+	From label.Symbol
+	To   label.Symbol
+	Old  decimal.Decimal
+	New  decimal.Decimal
+	// Change of the rate in percent, negative if the rate fell
+	Change decimal.Decimal
+	// Providers whose quotes took part in the new rate
+	Providers []string
+	// Rate the new exchange rate with the quotes of the providers
+	Rate ExchangeRate
+	// Missed the number of events dropped before this one because the channel of the subscriber was full
+	Missed int
+}
+
+// Subscribe returns the channel receiving the events for the exchange rates that changed since the previous refresh
+// of the cache, enable it with WithAutoRefresh. The pairs first appearing in a refresh have no event.
+// The delivery never blocks, the events are dropped while the channel is full and counted in the next event.
+// The channel is closed when ctx is done
+func (e *exchanger) Subscribe(ctx context.Context, filter RateFilter) <-chan RateEvent {
+	sub := newSubscription(filter)
+	e.subs.add(sub)
+
+	go func() {
+		<-ctx.Done()
+		e.subs.remove(sub)
+	}()
+
+	return sub.ch
+}
+
+type subscription struct {
+	ch        chan RateEvent
+	pairs     map[Pair]struct{}
+	minChange decimal.Decimal
+	// missed the number of dropped events since the last delivered one
+	missed int
+}
+
+func newSubscription(filter RateFilter) *subscription {
+	buffer := filter.Buffer
+	if buffer <= 0 {
+		buffer = DefaultSubscriptionBuffer
+	}
+
+	sub := &subscription{
+		ch:        make(chan RateEvent, buffer),
+		pairs:     make(map[Pair]struct{}, len(filter.Pairs)),
+		minChange: decimal.NewFromFloat(filter.MinChange).Abs(),
+	}
+
+	for _, p := range filter.Pairs {
+		sub.pairs[p] = struct{}{}
+	}
+
+	return sub
+}
+
+func (s *subscription) match(ev RateEvent) bool {
+	if len(s.pairs) > 0 {
+		if _, ok := s.pairs[Pair{From: ev.From, To: ev.To}]; !ok {
+			return false
+		}
+	}
+
+	return ev.Change.Abs().Cmp(s.minChange) >= 0
+}
+
+// deliver sends the event without blocking
+func (s *subscription) deliver(ev RateEvent) {
+	if !s.match(ev) {
+		return
+	}
+
+	ev.Missed = s.missed
+	ev.Providers = ev.Rate.Providers()
+
+	select {
+	case s.ch <- ev:
+		s.missed = 0
+	default:
+		s.missed++
+	}
+}
+
+// hub delivers the changes of the exchange rates to the subscribers
+type hub struct {
+	mtx  sync.Mutex
+	subs map[*subscription]struct{}
+	// last the last known exchange rates, the pairs missing in a refresh keep their previous rates
+	last rateIndex
+}
+
+func newHub() *hub {
+	return &hub{
+		subs: make(map[*subscription]struct{}),
+		last: make(rateIndex),
+	}
+}
+
+func (h *hub) add(sub *subscription) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.subs[sub] = struct{}{}
+}
+
+func (h *hub) remove(sub *subscription) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// publish compares the rates with the last known ones and delivers the changes, the changes in percent
+// are rounded to the precision decimal places
+func (h *hub) publish(rates []ExchangeRate, precision int32) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	for _, r := range rates {
+		from, to := r.from.Symbol, r.to.Symbol
+
+		old, ok := h.last[from][to]
+		if _, exists := h.last[from]; !exists {
+			h.last[from] = make(map[label.Symbol]ExchangeRate)
+		}
+
+		h.last[from][to] = r
+
+		if !ok || old.rate.Equal(r.rate) || old.rate.IsZero() {
+			continue
+		}
+
+		ev := RateEvent{
+			From:   from,
+			To:     to,
+			Old:    old.rate,
+			New:    r.rate,
+			Change: r.rate.Sub(old.rate).Div(old.rate, precision).Mul(decimal.New(100, 0)),
+			Rate:   r,
+		}
+
+		for sub := range h.subs {
+			sub.deliver(ev)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package gokuu
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/robotomize/gokuu/label"
+)
+
+func TestExchanger_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		From   label.Symbol
+		To     label.Symbol
+		Old    string
+		New    string
+		Change string
+		Missed int
+	}
+
+	testCases := []struct {
+		name      string
+		filter    RateFilter
+		refreshes int
+		expected  []event
+	}{
+		{
+			name:      "test_all_pairs",
+			refreshes: 2,
+			expected: []event{
+				{From: label.USD, To: label.RUB, Old: "71", New: "72", Change: "1.40845070422535"},
+			},
+		},
+		{
+			name:      "test_pair_filter",
+			filter:    RateFilter{Pairs: []Pair{{From: label.USD, To: label.RUB}}},
+			refreshes: 3,
+			expected: []event{
+				{From: label.USD, To: label.RUB, Old: "71", New: "72", Change: "1.40845070422535"},
+				{From: label.USD, To: label.RUB, Old: "72", New: "73", Change: "1.38888888888889"},
+			},
+		},
+		{
+			name:      "test_min_change",
+			filter:    RateFilter{Pairs: []Pair{{From: label.USD, To: label.RUB}}, MinChange: 1.4},
+			refreshes: 3,
+			expected: []event{
+				{From: label.USD, To: label.RUB, Old: "71", New: "72", Change: "1.40845070422535"},
+			},
+		},
+		{
+			name:      "test_first_refresh",
+			refreshes: 1,
+		},
+		{
+			name:      "test_slow_consumer",
+			filter:    RateFilter{Pairs: []Pair{{From: label.USD, To: label.RUB}}, Buffer: 1},
+			refreshes: 4,
+			expected: []event{
+				{From: label.USD, To: label.RUB, Old: "71", New: "72", Change: "1.40845070422535"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			e := newCachedExchanger(&countingSource{}, WithCache(0))
+			ch := e.Subscribe(ctx, tc.filter)
+
+			for i := 0; i < tc.refreshes; i++ {
+				e.refresh(ctx)
+			}
+
+			var got []event
+			for len(ch) > 0 {
+				ev := <-ch
+				got = append(got, event{
+					From:   ev.From,
+					To:     ev.To,
+					Old:    ev.Old.Round(16).String(),
+					New:    ev.New.Round(16).String(),
+					Change: ev.Change.Round(14).String(),
+					Missed: ev.Missed,
+				})
+
+				if diff := cmp.Diff([]string{"test_source"}, ev.Providers); diff != "" {
+					t.Errorf("bad providers (-want, +got): %s", diff)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("bad events (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestExchanger_SubscribeMissed(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := newCachedExchanger(&countingSource{}, WithCache(0))
+	ch := e.Subscribe(ctx, RateFilter{Pairs: []Pair{{From: label.USD, To: label.RUB}}, Buffer: 1})
+
+	// the second event is dropped while the first one is not received
+	for i := 0; i < 3; i++ {
+		e.refresh(ctx)
+	}
+
+	if ev := <-ch; ev.Missed != 0 || ev.New.String() != "72" {
+		t.Fatalf("bad first event: new %s, missed %d", ev.New, ev.Missed)
+	}
+
+	e.refresh(ctx)
+
+	ev := <-ch
+	if diff := cmp.Diff("74", ev.New.String()); diff != "" {
+		t.Errorf("bad new rate (-want, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(1, ev.Missed); diff != "" {
+		t.Errorf("bad missed events (-want, +got): %s", diff)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("unexpected event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("channel is not closed after cancel")
+	}
+}